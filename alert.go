@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+// alertRule matches traces that meet all of its conditions.
+type alertRule struct {
+	text string
+
+	// over is only checked if timed.
+	over  time.Duration
+	timed bool
+
+	errors bool
+	span   *trot.Selector
+}
+
+// parseAlertRule parses conditions joined by commas: duration>D, errors, or
+// span=path, e.g. "duration>5s,span=**/db.query".
+func parseAlertRule(s string) (alertRule, error) {
+	rule := alertRule{text: s}
+	for _, cond := range strings.Split(s, ",") {
+		cond = strings.TrimSpace(cond)
+		if d, ok := strings.CutPrefix(cond, "duration>"); ok {
+			over, err := time.ParseDuration(d)
+			if err != nil {
+				return rule, err
+			}
+			rule.over, rule.timed = over, true
+		} else if path, ok := strings.CutPrefix(cond, "span="); ok {
+			sel, err := trot.ParseSelector(path)
+			if err != nil {
+				return rule, err
+			}
+			rule.span = sel
+		} else if cond == "errors" || cond == "error" {
+			rule.errors = true
+		} else {
+			return rule, fmt.Errorf("unknown condition %q (want duration>D, errors, or span=path)", cond)
+		}
+	}
+	return rule, nil
+}
+
+func (r alertRule) match(t *trot.Trace, s trot.Summary) bool {
+	if r.timed && s.Duration <= r.over {
+		return false
+	}
+	if r.errors && s.Errors == 0 {
+		return false
+	}
+	return r.span == nil || len(t.Tree("", trot.RootID).Select(r.span)) != 0
+}
+
+// alert is what -webhook URLs are sent about a trace that matched a rule.
+type alert struct {
+	Rule     string        `json:"rule"`
+	TraceID  string        `json:"trace_id"`
+	Name     string        `json:"name"`
+	Service  string        `json:"service"`
+	Duration time.Duration `json:"duration_ns"`
+	Spans    int           `json:"spans"`
+	Errors   int           `json:"errors"`
+	URL      string        `json:"url"`
+}
+
+// maxSent is how many traces alerter remembers alerting about, so receive
+// doesn't grow without bound. Spans of long-forgotten traces rarely arrive.
+const maxSent = 10000
+
+// alerter notifies webhooks and Slack about traces that match its rules,
+// at most once per trace.
+type alerter struct {
+	ctx      context.Context
+	rules    []alertRule
+	webhooks []string
+	slack    []string
+
+	// page returns the URL of a trace's page.
+	page func(*trot.Trace) string
+
+	// precision is the significant digits in durations, as in -duration-precision.
+	precision int
+
+	mu      sync.Mutex
+	sent    map[string]bool
+	order   []string // sent's keys, oldest first
+	closed  bool
+	pending sync.WaitGroup
+}
+
+// check alerts about t if it matches a rule, without waiting for the
+// notifications to be sent.
+func (a *alerter) check(t *trot.Trace) {
+	s := t.Summarize()
+	for _, rule := range a.rules {
+		if !rule.match(t, s) {
+			continue
+		}
+
+		a.mu.Lock()
+		skip := a.closed || a.sent[s.TraceID]
+		if !skip {
+			a.remember(s.TraceID)
+			// Under mu, so wait can't start waiting first.
+			a.pending.Add(1)
+		}
+		a.mu.Unlock()
+		if skip {
+			return
+		}
+
+		al := alert{
+			Rule:     rule.text,
+			TraceID:  s.TraceID,
+			Name:     s.Name,
+			Service:  s.Service,
+			Duration: s.Duration,
+			Spans:    s.Spans,
+			Errors:   s.Errors,
+			URL:      a.page(t),
+		}
+		slog.Info("alert", "rule", al.Rule, "trace", al.TraceID, "name", al.Name, "duration", trot.FormatDuration(al.Duration, a.precision))
+		go a.notify(al)
+		return
+	}
+}
+
+// remember that tid was alerted about, forgetting the oldest trace if there
+// are too many. It must be called with mu held.
+func (a *alerter) remember(tid string) {
+	a.sent[tid] = true
+	a.order = append(a.order, tid)
+	if len(a.order) > maxSent {
+		delete(a.sent, a.order[0])
+		a.order = a.order[1:]
+	}
+}
+
+// wait stops check from alerting about any more traces, and waits for the
+// notifications it has started to be sent.
+func (a *alerter) wait() {
+	a.mu.Lock()
+	a.closed = true
+	a.mu.Unlock()
+
+	a.pending.Wait()
+}
+
+func (a *alerter) notify(al alert) {
+	defer a.pending.Done()
+
+	// Finish sending alerts about the last traces before shutting down.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(a.ctx), 10*time.Second)
+	defer cancel()
+
+	for _, u := range a.webhooks {
+		if err := postJSON(ctx, u, al); err != nil {
+			slog.Warn("alert", "webhook", u, "err", err)
+		}
+	}
+	text := a.slackText(al)
+	for _, u := range a.slack {
+		if err := postJSON(ctx, u, map[string]string{"text": text}); err != nil {
+			slog.Warn("alert", "slack", u, "err", err)
+		}
+	}
+}
+
+// slackEscaper escapes the characters Slack treats as markup, so a span named
+// <!channel> can't ping anyone and names can't forge <url|label> links.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func (a *alerter) slackText(al alert) string {
+	return fmt.Sprintf("%s took %s with %d errors, matching %s: %s",
+		slackEscaper.Replace(al.Name), trot.FormatDuration(al.Duration, a.precision), al.Errors, slackEscaper.Replace(al.Rule), al.URL)
+}
+
+func postJSON(ctx context.Context, u string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("POST %s: %s: %s", u, resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+func TestParseAlertRule(t *testing.T) {
+	for _, tc := range []struct {
+		in     string
+		over   time.Duration
+		timed  bool
+		errors bool
+		span   bool
+	}{
+		{in: "duration>5s", over: 5 * time.Second, timed: true},
+		{in: "duration>0s", timed: true},
+		{in: "errors", errors: true},
+		{in: "error, span=**/db.query", errors: true, span: true},
+		{in: "duration>1m,errors,span=a/b", over: time.Minute, timed: true, errors: true, span: true},
+	} {
+		rule, err := parseAlertRule(tc.in)
+		if err != nil {
+			t.Errorf("parseAlertRule(%q): %v", tc.in, err)
+			continue
+		}
+		if rule.text != tc.in || rule.over != tc.over || rule.timed != tc.timed || rule.errors != tc.errors || (rule.span != nil) != tc.span {
+			t.Errorf("parseAlertRule(%q) = %+v", tc.in, rule)
+		}
+	}
+
+	for _, bad := range []string{"", "duration>soon", "duration<5s", "span=", "errors,nope"} {
+		if _, err := parseAlertRule(bad); err == nil {
+			t.Errorf("parseAlertRule(%q) succeeded", bad)
+		}
+	}
+}
+
+// alertTrace is a one-span trace that took d, and failed if failed.
+func alertTrace(tid string, d time.Duration, failed bool) *trot.Trace {
+	span := &trot.Span{Name: "job", StartTime: time.Unix(0, 0), EndTime: time.Unix(0, 0).Add(d)}
+	span.SpanContext.TraceID = tid
+	span.SpanContext.SpanID = "0000000000000001"
+	span.Parent.SpanID = trot.RootID
+	if failed {
+		span.Status.Code = "Error"
+	}
+	t := trot.NewTrace()
+	t.Add(span)
+	return t
+}
+
+func TestAlerterCheck(t *testing.T) {
+	var mu sync.Mutex
+	got := []alert{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var al alert
+		if err := json.NewDecoder(r.Body).Decode(&al); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		got = append(got, al)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	a := &alerter{
+		ctx:      context.Background(),
+		webhooks: []string{srv.URL},
+		page:     func(t *trot.Trace) string { return "/" + t.Summarize().TraceID },
+		sent:     map[string]bool{},
+	}
+	for _, s := range []string{"duration>1s", "errors"} {
+		rule, err := parseAlertRule(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.rules = append(a.rules, rule)
+	}
+
+	a.check(alertTrace("fast", time.Millisecond, false))
+	a.check(alertTrace("slow", 2*time.Second, false))
+	// Only once per trace, even if it matches again.
+	a.check(alertTrace("slow", 3*time.Second, true))
+	// Quick, but failed: errors has no duration to check.
+	a.check(alertTrace("failed", time.Millisecond, true))
+	a.wait()
+	// Nothing more once waiting has started.
+	a.check(alertTrace("late", time.Hour, true))
+
+	mu.Lock()
+	defer mu.Unlock()
+	rules := map[string]string{}
+	for _, al := range got {
+		rules[al.TraceID] = al.Rule
+	}
+	if len(got) != 2 || rules["slow"] != "duration>1s" || rules["failed"] != "errors" {
+		t.Errorf("alerts = %+v", got)
+	}
+	if len(got) != 0 && got[0].URL != "/"+got[0].TraceID {
+		t.Errorf("URL = %q", got[0].URL)
+	}
+}
+
+func TestAlerterForgets(t *testing.T) {
+	a := &alerter{sent: map[string]bool{}}
+	for i := 0; i < maxSent+10; i++ {
+		a.remember(strconv.Itoa(i))
+	}
+	if len(a.sent) != maxSent || len(a.order) != maxSent || a.sent[strconv.Itoa(0)] || !a.sent[strconv.Itoa(maxSent+9)] {
+		t.Errorf("remembering %d traces: %d, %d", maxSent+10, len(a.sent), len(a.order))
+	}
+}
+
+func TestSlackText(t *testing.T) {
+	a := &alerter{precision: 2}
+	al := alert{
+		Rule:     "duration>1s,span=**/<a>",
+		Name:     "<!channel> & <https://evil|click>",
+		Duration: 1234 * time.Millisecond,
+		Errors:   1,
+		URL:      "http://localhost:8080/trace",
+	}
+	want := "&lt;!channel&gt; &amp; &lt;https://evil|click&gt; took 1.2s with 1 errors, matching duration&gt;1s,span=**/&lt;a&gt;: http://localhost:8080/trace"
+	if got := a.slackText(al); got != want {
+		t.Errorf("slackText() =\n%s\nwant\n%s", got, want)
+	}
+}
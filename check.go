@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+func checkCmd() *command {
+	cmd := newCommand("check", "[flags] [file...]", "Report spans whose ChildSpanCount exceeds the children present in the input.")
+
+	in := formatFlags(cmd.flags)
+	selector := cmd.flags.String("select", "", "only check spans at this path of span names, e.g. root/serve/**/db.query")
+
+	cmd.run = func(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+		var sel *trot.Selector
+		if *selector != "" {
+			var err error
+			sel, err = trot.ParseSelector(*selector)
+			if err != nil {
+				return fmt.Errorf("-select: %w", err)
+			}
+		}
+
+		t, err := readTrace(ctx, r, args, *in)
+		if err != nil {
+			return err
+		}
+		return writeCheck(w, t, sel)
+	}
+
+	return cmd
+}
+
+// writeCheck reports t's truncated spans, or only those sel selects if it
+// isn't nil.
+func writeCheck(w io.Writer, t *trot.Trace, sel *trot.Selector) error {
+	traces := t.Truncations()
+	if sel != nil {
+		for tid, ts := range traces {
+			kept := ts[:0]
+			for _, t := range ts {
+				if sel.Match(t.Path) {
+					kept = append(kept, t)
+				}
+			}
+			if len(kept) == 0 {
+				delete(traces, tid)
+			} else {
+				traces[tid] = kept
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(traces))
+	for tid := range traces {
+		ids = append(ids, tid)
+	}
+	sort.Strings(ids)
+
+	for _, tid := range ids {
+		fmt.Fprintf(w, "trace %s is incomplete:\n", tid)
+		for _, t := range traces[tid] {
+			fmt.Fprintf(w, "  %s (%s): expected %d children, found %d\n", strings.Join(t.Path, " > "), t.Span.SpanContext.SpanID, t.Expected, t.Found)
+		}
+	}
+
+	if len(ids) != 0 {
+		return fmt.Errorf("%d incomplete traces", len(ids))
+	}
+
+	return nil
+}
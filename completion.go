@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func completionCmd() *command {
+	cmd := newCommand("completion", "bash|zsh|fish", "Print a shell completion script, e.g. source <(trot completion bash).")
+
+	cmd.run = func(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: trot completion bash|zsh|fish")
+		}
+
+		// Built here rather than passed in, since commands() includes this one.
+		cmds := commands()
+		switch args[0] {
+		case "bash":
+			return completeBash(w, cmds)
+		case "zsh":
+			return completeZsh(w, cmds)
+		case "fish":
+			return completeFish(w, cmds)
+		}
+		return fmt.Errorf("unknown shell %q (want bash, zsh, or fish)", args[0])
+	}
+
+	return cmd
+}
+
+type compFlag struct {
+	name, usage string
+	bool        bool
+}
+
+func flagsOf(c *command) []compFlag {
+	var flags []compFlag
+	c.flags.VisitAll(func(f *flag.Flag) {
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, compFlag{name: f.Name, usage: f.Usage, bool: ok && b.IsBoolFlag()})
+	})
+	return flags
+}
+
+func names(cmds []*command) []string {
+	var ns []string
+	for _, c := range cmds {
+		ns = append(ns, c.name)
+	}
+	return ns
+}
+
+func completeBash(w io.Writer, cmds []*command) error {
+	fmt.Fprintln(w, `_trot() {
+	local cur=${COMP_WORDS[COMP_CWORD]} cmd=${COMP_WORDS[1]} flags
+	if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then
+		COMPREPLY=($(compgen -W "`+strings.Join(names(cmds), " ")+`" -- "$cur"))
+		return
+	fi
+	case $cmd in`)
+	// The default command goes last, since it also matches no subcommand.
+	order := append(append([]*command{}, cmds[1:]...), cmds[0])
+	for _, c := range order {
+		var fs []string
+		for _, f := range flagsOf(c) {
+			fs = append(fs, "-"+f.name)
+		}
+		pattern := c.name
+		if c == cmds[0] {
+			pattern = "*"
+		}
+		fmt.Fprintf(w, "\t%s) flags='%s' ;;\n", pattern, strings.Join(fs, " "))
+	}
+	fmt.Fprintln(w, `	esac
+	if [[ $cur == -* ]]; then
+		COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+	else
+		COMPREPLY=($(compgen -f -- "$cur"))
+	fi
+}
+complete -o filenames -F _trot trot`)
+	return nil
+}
+
+// zshQuote escapes s for use inside a single-quoted _arguments spec.
+func zshQuote(s string) string {
+	return strings.NewReplacer(`'`, `'\''`, `[`, `\[`, `]`, `\]`, `:`, `\:`).Replace(s)
+}
+
+func completeZsh(w io.Writer, cmds []*command) error {
+	fmt.Fprintln(w, "#compdef trot\n\n_trot() {\n\tlocal -a cmds\n\tcmds=(")
+	for _, c := range cmds {
+		fmt.Fprintf(w, "\t\t'%s:%s'\n", c.name, zshQuote(c.short))
+	}
+	fmt.Fprintln(w, `	)
+	if (( CURRENT == 2 )) && [[ $words[2] != -* ]]; then
+		_describe command cmds
+		return
+	fi
+
+	local cmd=$words[2]
+	if (( ${cmds[(I)$cmd:*]} )); then
+		shift words
+		(( CURRENT-- ))
+	else
+		cmd=`+cmds[0].name+`
+	fi
+
+	case $cmd in`)
+	for _, c := range cmds {
+		fmt.Fprintf(w, "\t%s)\n\t\t_arguments \\\n", c.name)
+		for _, f := range flagsOf(c) {
+			if f.bool {
+				fmt.Fprintf(w, "\t\t\t'-%s[%s]' \\\n", f.name, zshQuote(f.usage))
+			} else {
+				fmt.Fprintf(w, "\t\t\t'-%s[%s]:%s:' \\\n", f.name, zshQuote(f.usage), f.name)
+			}
+		}
+		fmt.Fprintln(w, "\t\t\t'*:file:_files' ;;")
+	}
+	fmt.Fprintln(w, "\tesac\n}\n\ncompdef _trot trot")
+	return nil
+}
+
+// fishQuote escapes s for use inside a single-quoted fish string.
+func fishQuote(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
+
+func completeFish(w io.Writer, cmds []*command) error {
+	all := strings.Join(names(cmds), " ")
+	for _, c := range cmds {
+		fmt.Fprintf(w, "complete -c trot -f -n '__fish_use_subcommand' -a %s -d '%s'\n", c.name, fishQuote(c.short))
+	}
+	for i, c := range cmds {
+		cond := fmt.Sprintf("__fish_seen_subcommand_from %s", c.name)
+		if i == 0 {
+			cond = fmt.Sprintf("not __fish_seen_subcommand_from %s; or __fish_seen_subcommand_from %s", all, c.name)
+		}
+		for _, f := range flagsOf(c) {
+			req := " -r"
+			if f.bool {
+				req = ""
+			}
+			fmt.Fprintf(w, "complete -c trot -n '%s' -o %s -d '%s'%s\n", cond, f.name, fishQuote(f.usage), req)
+		}
+	}
+	return nil
+}
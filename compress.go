@@ -0,0 +1,97 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// gzipped calls fn with a writer that gzips into w.
+func gzipped(w io.Writer, fn func(io.Writer) error) error {
+	zw := gzip.NewWriter(w)
+	if err := fn(zw); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// gzipResponses compresses responses for clients that accept gzip.
+// trot's pages are very repetitive, so this is usually a ~20x win.
+// Responses without a body, to HEAD or with a 204 or 304, aren't compressed.
+func gzipResponses(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, zw: gzip.NewWriter(w)}
+		defer func() {
+			// Leave bodiless responses like redirects bodiless.
+			if gw.wroteHeader && !gw.bodiless {
+				gw.zw.Close()
+			}
+		}()
+
+		w.Header().Set("Content-Encoding", "gzip")
+		h.ServeHTTP(gw, r)
+	})
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		enc, q, _ := strings.Cut(strings.TrimSpace(enc), ";")
+		if enc == "gzip" && strings.TrimSpace(q) != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	zw          *gzip.Writer
+	wroteHeader bool
+	// bodiless is set for statuses that can't have a body, which are sent
+	// as they are.
+	bodiless bool
+}
+
+func (g *gzipResponseWriter) WriteHeader(code int) {
+	if g.wroteHeader {
+		return
+	}
+	g.wroteHeader = true
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		g.bodiless = true
+		g.Header().Del("Content-Encoding")
+	} else {
+		g.Header().Del("Content-Length")
+	}
+	g.ResponseWriter.WriteHeader(code)
+}
+
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+	if g.bodiless {
+		return g.ResponseWriter.Write(b)
+	}
+	if !g.wroteHeader {
+		// Sniff the uncompressed bytes, since net/http would see gzip.
+		if g.Header().Get("Content-Type") == "" {
+			g.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		g.WriteHeader(http.StatusOK)
+	}
+	return g.zw.Write(b)
+}
+
+func (g *gzipResponseWriter) Flush() {
+	if !g.bodiless {
+		g.zw.Flush()
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
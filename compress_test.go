@@ -0,0 +1,63 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGzipResponses(t *testing.T) {
+	page := strings.Repeat("<p>hello</p>", 100)
+	h := gzipResponses(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		case "/cached":
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			if r.Method != http.MethodHead {
+				io.WriteString(w, page)
+			}
+		}
+	}))
+
+	for _, tc := range []struct {
+		method, path string
+		code         int
+		gzipped      bool
+	}{
+		{"GET", "/", http.StatusOK, true},
+		{"HEAD", "/", http.StatusOK, false},
+		{"GET", "/empty", http.StatusNoContent, false},
+		{"GET", "/cached", http.StatusNotModified, false},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != tc.code {
+			t.Errorf("%s %s: code = %d, want %d", tc.method, tc.path, rec.Code, tc.code)
+		}
+		if got := rec.Header().Get("Content-Encoding") == "gzip"; got != tc.gzipped {
+			t.Errorf("%s %s: gzipped = %t, want %t", tc.method, tc.path, got, tc.gzipped)
+		}
+		if !tc.gzipped {
+			if rec.Body.Len() != 0 {
+				t.Errorf("%s %s: body = %q, want none", tc.method, tc.path, rec.Body.Bytes())
+			}
+			continue
+		}
+		zr, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b, err := io.ReadAll(zr); err != nil || string(b) != page {
+			t.Errorf("%s %s: body = %q, %v", tc.method, tc.path, b, err)
+		}
+	}
+}
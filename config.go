@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// config holds flag defaults from ~/.config/trot/config.yaml. Top-level keys
+// apply to every command with a flag of that name; a key named after a
+// command holds overrides for just that command:
+//
+//	theme: dark
+//	expand: 2
+//	color:
+//	  - 'db\..*=orange'
+//	serve:
+//	  addr: localhost:9090
+type config map[string]any
+
+func configPath() string {
+	if p := os.Getenv("TROT_CONFIG"); p != "" {
+		return p
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "trot", "config.yaml")
+}
+
+func loadConfig(path string) (config, error) {
+	if path == "" {
+		return config{}, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal into a plain map so nested maps aren't decoded as config.
+	m := map[string]any{}
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return config(m), nil
+}
+
+// envName is the environment variable for flag, e.g. TROT_SERVE_ADDR, or TROT_ADDR if cmd is "".
+func envName(cmd, flag string) string {
+	name := "TROT_"
+	if cmd != "" {
+		name += cmd + "_"
+	}
+	name += flag
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// apply sets defaults for cmd's flags. From lowest to highest precedence:
+// top-level config, per-command config, TROT_<FLAG>, TROT_<CMD>_<FLAG>.
+// Each replaces the ones below it, even for repeatable flags, and flags on
+// the command line replace all of them.
+func (cfg config) apply(cmd *command) error {
+	sub, _ := cfg[cmd.name].(map[string]any)
+
+	var err error
+	cmd.flags.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+
+		var source string
+		var values []string
+		from := func(src string, v any) {
+			if v == nil {
+				return
+			}
+			source, values = src, nil
+			if list, ok := v.([]any); ok {
+				for _, item := range list {
+					values = append(values, fmt.Sprint(item))
+				}
+				return
+			}
+			values = []string{fmt.Sprint(v)}
+		}
+
+		if _, isCmd := cfg[f.Name].(map[string]any); !isCmd {
+			from("config "+cmd.name+"."+f.Name, cfg[f.Name])
+		}
+		from("config "+cmd.name+"."+f.Name, sub[f.Name])
+		for _, env := range []string{envName("", f.Name), envName(cmd.name, f.Name)} {
+			if v, ok := os.LookupEnv(env); ok {
+				from(env, v)
+			}
+		}
+		if source == "" {
+			return
+		}
+
+		if l, ok := f.Value.(*stringList); ok {
+			*l = values
+			f.Value = &listDefault{stringList: l}
+			return
+		}
+		for _, v := range values {
+			if e := cmd.flags.Set(f.Name, v); e != nil {
+				err = fmt.Errorf("%s: %w", source, e)
+				return
+			}
+		}
+	})
+
+	return err
+}
+
+// stringList is a flag that can be repeated.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// listDefault is a stringList holding defaults from config or the
+// environment, which the first value on the command line replaces.
+type listDefault struct {
+	*stringList
+	replaced bool
+}
+
+func (d *listDefault) Set(v string) error {
+	if !d.replaced {
+		*d.stringList, d.replaced = nil, true
+	}
+	return d.stringList.Set(v)
+}
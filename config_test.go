@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// unsetenv unsets key until t is done.
+func unsetenv(t *testing.T, key string) {
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	cfg := config{
+		"theme": "dark",
+		"color": []any{"a=red"},
+		"serve": map[string]any{
+			"color": []any{"b=red", "c=red"},
+		},
+	}
+
+	for _, tc := range []struct {
+		env         map[string]string
+		args        []string
+		theme, list string
+	}{
+		{nil, nil, "dark", "[b=red c=red]"},
+		{map[string]string{"TROT_COLOR": "d=red"}, nil, "dark", "[d=red]"},
+		{map[string]string{"TROT_COLOR": "d=red", "TROT_SERVE_COLOR": "e=red", "TROT_THEME": "light"}, nil, "light", "[e=red]"},
+		{map[string]string{"TROT_SERVE_THEME": "light"}, []string{"-theme", "auto"}, "auto", "[b=red c=red]"},
+		{map[string]string{"TROT_COLOR": "d=red"}, []string{"-color", "f=red", "-color", "g=red"}, "dark", "[f=red g=red]"},
+	} {
+		t.Run(fmt.Sprint(tc.env, tc.args), func(t *testing.T) {
+			for _, k := range []string{"TROT_THEME", "TROT_SERVE_THEME", "TROT_COLOR", "TROT_SERVE_COLOR"} {
+				unsetenv(t, k)
+			}
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			cmd := newCommand("serve", "", "")
+			theme := cmd.flags.String("theme", "", "")
+			colors := &stringList{}
+			cmd.flags.Var(colors, "color", "")
+
+			if err := cfg.apply(cmd); err != nil {
+				t.Fatal(err)
+			}
+			if err := cmd.flags.Parse(tc.args); err != nil {
+				t.Fatal(err)
+			}
+			if *theme != tc.theme {
+				t.Errorf("theme = %q, want %q", *theme, tc.theme)
+			}
+			if got := fmt.Sprint(*colors); got != tc.list {
+				t.Errorf("color = %s, want %s", got, tc.list)
+			}
+		})
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	unsetenv(t, "TROT_EXPAND")
+	t.Setenv("TROT_SERVE_EXPAND", "lots")
+
+	cmd := newCommand("serve", "", "")
+	cmd.flags.Int("expand", 0, "")
+	if err := (config{}).apply(cmd); err == nil || err.Error() != `TROT_SERVE_EXPAND: parse error` {
+		t.Errorf("apply: %v", err)
+	}
+}
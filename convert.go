@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+func convertCmd() *command {
+	cmd := newCommand("convert", "[flags] [file...]", "Convert any supported input format to stdouttrace, OTLP/JSON, or flat NDJSON.")
+
+	in := formatFlags(cmd.flags)
+	to := cmd.flags.String("to", "stdouttrace", "output format (stdouttrace, otlp, or ndjson, one flat span object per line written as each trace completes)")
+
+	cmd.run = func(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+		if *to == "ndjson" {
+			return streamTrace(r, args, *in, 0, func(t *trot.Trace) error {
+				return trot.EncodeNDJSON(w, t)
+			})
+		}
+
+		t, err := readTrace(ctx, r, args, *in)
+		if err != nil {
+			return err
+		}
+
+		switch *to {
+		case "stdouttrace":
+			return trot.EncodeStdouttrace(w, t)
+		case "otlp":
+			return trot.EncodeOTLP(w, t)
+		}
+
+		return fmt.Errorf("unknown -to format %q (want stdouttrace, otlp, or ndjson)", *to)
+	}
+
+	return cmd
+}
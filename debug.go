@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+)
+
+// sizer is a store that can say how much it holds.
+type sizer interface {
+	Len() (traces, spans int)
+}
+
+// serveDebug serves pprof at /debug/pprof/ and trot's own metrics, in the
+// Prometheus text format, at /debug/metrics, on addr (localhost if it has no
+// host) until ctx is cancelled. That's apart from the traces, so it isn't
+// exposed to whoever can see them.
+func serveDebug(ctx context.Context, addr string, store sizer) {
+	if host, port, err := net.SplitHostPort(addr); err == nil && host == "" {
+		addr = net.JoinHostPort("localhost", port)
+	}
+
+	mux := http.NewServeMux()
+	// Not /debug/pprof/cmdline, whose flags may hold tokens and webhook URLs.
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writeMetrics(w, store)
+	})
+
+	go func() {
+		if err := listenAndServe(ctx, addr, mux); err != nil {
+			slog.Error("debug", "err", err)
+		}
+	}()
+}
+func writeMetrics(w http.ResponseWriter, store sizer) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	traces, spans := store.Len()
+	last := time.Duration(0)
+	if ms.NumGC != 0 {
+		last = time.Duration(ms.PauseNs[(ms.NumGC+255)%256])
+	}
+
+	for _, m := range []struct {
+		name, kind, help string
+		value            any
+	}{
+		{"trot_traces_held", "gauge", "Traces in memory.", traces},
+		{"trot_spans_held", "gauge", "Spans in memory.", spans},
+		{"trot_heap_inuse_bytes", "gauge", "Bytes of heap in use.", ms.HeapInuse},
+		{"trot_heap_objects", "gauge", "Allocated heap objects.", ms.HeapObjects},
+		{"trot_goroutines", "gauge", "Goroutines that currently exist.", runtime.NumGoroutine()},
+		{"trot_gc_runs_total", "counter", "Completed GC cycles.", ms.NumGC},
+		{"trot_gc_pause_seconds_total", "counter", "Time spent in GC stop-the-world pauses.", time.Duration(ms.PauseTotalNs).Seconds()},
+		{"trot_gc_last_pause_seconds", "gauge", "How long the last GC stop-the-world pause took.", last.Seconds()},
+	} {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", m.name, m.help, m.name, m.kind, m.name, m.value)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+func diffCmd() *command {
+	cmd := newCommand("diff", "[flags] <before> <after>", "Compare total time and count per span name path between two inputs.")
+
+	in := formatFlags(cmd.flags)
+	min := cmd.flags.Duration("min", 0, "hide paths whose total time changed by less than this")
+	precision := cmd.flags.Int("duration-precision", 3, "significant digits in durations")
+
+	cmd.run = func(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+		if len(args) != 2 {
+			cmd.flags.Usage()
+			return fmt.Errorf("diff takes exactly two inputs, got %d", len(args))
+		}
+
+		before, err := readFile(ctx, args[0], in.format)
+		if err != nil {
+			return err
+		}
+		after, err := readFile(ctx, args[1], in.format)
+		if err != nil {
+			return err
+		}
+
+		return writeDiff(w, flatten(before.Flame()), flatten(after.Flame()), *min, *precision)
+	}
+
+	return cmd
+}
+
+type pathStat struct {
+	count int
+	total time.Duration
+}
+
+// flatten turns a merged flame graph into totals keyed by "a/b/c" name paths.
+func flatten(root *trot.Frame) map[string]pathStat {
+	paths := map[string]pathStat{}
+
+	var walk func(f *trot.Frame, path []string)
+	walk = func(f *trot.Frame, path []string) {
+		path = append(path, f.Name)
+		paths[strings.Join(path, "/")] = pathStat{f.Count, f.Total}
+		for _, kid := range f.Children {
+			walk(kid, path)
+		}
+	}
+	for _, kid := range root.Children {
+		walk(kid, nil)
+	}
+
+	return paths
+}
+
+func writeDiff(w io.Writer, before, after map[string]pathStat, min time.Duration, precision int) error {
+	paths := []string{}
+	seen := map[string]struct{}{}
+	for _, m := range []map[string]pathStat{before, after} {
+		for path := range m {
+			if _, ok := seen[path]; !ok {
+				seen[path] = struct{}{}
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	delta := func(path string) time.Duration {
+		return after[path].total - before[path].total
+	}
+	abs := func(d time.Duration) time.Duration {
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		di, dj := abs(delta(paths[i])), abs(delta(paths[j]))
+		if di == dj {
+			return paths[i] < paths[j]
+		}
+		return di > dj
+	})
+
+	fmt.Fprintf(w, "%-14s %-20s %-20s %s\n", "DELTA", "BEFORE", "AFTER", "PATH")
+	for _, path := range paths {
+		d := delta(path)
+		if abs(d) < min {
+			continue
+		}
+		b, a := before[path], after[path]
+		sign := "+"
+		if d < 0 {
+			sign = "-"
+		}
+		fmt.Fprintf(w, "%-14s %-20s %-20s %s\n", sign+trot.FormatDuration(abs(d), precision),
+			fmt.Sprintf("%s (%dx)", trot.FormatDuration(b.total, precision), b.count),
+			fmt.Sprintf("%s (%dx)", trot.FormatDuration(a.total, precision), a.count),
+			path)
+	}
+
+	return nil
+}
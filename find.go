@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+func findCmd() *command {
+	cmd := newCommand("find", "[flags] [file...]", "Print spans whose name matches, with the path of spans above them.")
+
+	in := formatFlags(cmd.flags)
+	name := cmd.flags.String("name", "", "find spans whose name matches this regexp")
+	selector := cmd.flags.String("select", "", "find spans at this path of span names, e.g. root/serve/**/db.query")
+	jsonOut := cmd.flags.Bool("json", false, "write a JSON object per span instead of text")
+	precision := cmd.flags.Int("duration-precision", 3, "significant digits in durations")
+
+	cmd.run = func(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+		if *name == "" && *selector == "" {
+			return fmt.Errorf("-name or -select is required")
+		}
+		re, err := regexp.Compile(*name)
+		if err != nil {
+			return fmt.Errorf("-name: %w", err)
+		}
+		var sel *trot.Selector
+		if *selector != "" {
+			sel, err = trot.ParseSelector(*selector)
+			if err != nil {
+				return fmt.Errorf("-select: %w", err)
+			}
+		}
+
+		t, err := readTrace(ctx, r, args, *in)
+		if err != nil {
+			return err
+		}
+
+		matches := findSpans(t, sel, func(path []string) bool {
+			return re.MatchString(path[len(path)-1])
+		})
+		if *jsonOut {
+			enc := json.NewEncoder(w)
+			for _, m := range matches {
+				if err := enc.Encode(m); err != nil {
+					return err
+				}
+			}
+		} else {
+			for _, m := range matches {
+				writeMatch(w, m, *precision)
+			}
+		}
+
+		if len(matches) == 0 {
+			return fmt.Errorf("no spans match")
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// match is a span find found, and the spans above it from its root down.
+type match struct {
+	TraceID  string        `json:"trace_id"`
+	SpanID   string        `json:"span_id"`
+	Name     string        `json:"name"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration_ns"`
+	Path     []pathStep    `json:"path"`
+}
+
+type pathStep struct {
+	SpanID   string        `json:"span_id"`
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// findSpans walks each trace in t, oldest first, for spans that sel selects,
+// if it isn't nil, and whose path of names from their root down matches pred.
+func findSpans(t *trot.Trace, sel *trot.Selector, pred func(path []string) bool) []match {
+	matches := []match{}
+	traces := t.Split()
+	for _, s := range summarize(traces) {
+		for _, root := range traces[s.TraceID].Roots() {
+			var selected map[*trot.Span]bool
+			if sel != nil {
+				selected = map[*trot.Span]bool{}
+				for _, span := range (&trot.Node{Children: []*trot.Node{root}}).Select(sel) {
+					selected[span] = true
+				}
+			}
+
+			path, names := []pathStep{}, []string{}
+			root.Walk(func(n *trot.Node, depth int) bool {
+				span := n.Span
+				path = append(path[:depth], pathStep{span.SpanContext.SpanID, span.Name, span.Duration()})
+				names = append(names[:depth], span.Name)
+				if (sel == nil || selected[span]) && pred(names) {
+					matches = append(matches, match{
+						TraceID:  span.SpanContext.TraceID,
+						SpanID:   span.SpanContext.SpanID,
+						Name:     span.Name,
+						Start:    span.StartTime,
+						Duration: span.Duration(),
+						Path:     append([]pathStep(nil), path...),
+					})
+				}
+				return true
+			})
+		}
+	}
+	return matches
+}
+
+func writeMatch(w io.Writer, m match, precision int) {
+	steps := make([]string, len(m.Path))
+	for i, step := range m.Path {
+		steps[i] = fmt.Sprintf("%s (%s)", step.Name, trot.FormatDuration(step.Duration, precision))
+	}
+	fmt.Fprintf(w, "%s %s  %s\n", m.TraceID, m.SpanID, strings.Join(steps, " > "))
+}
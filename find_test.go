@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+// findInput is two traces, each root/serve/db.query, plus root/db.query in
+// the older one, written as stdouttrace JSON.
+func findInput(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	add := func(tid, id, parent, name string, start, end int) {
+		span := trot.Span{Name: name, StartTime: time.Unix(int64(start), 0), EndTime: time.Unix(int64(end), 0)}
+		span.SpanContext.TraceID = tid
+		span.SpanContext.SpanID = id
+		span.Parent.SpanID = parent
+		if err := enc.Encode(span); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newer, older := strings.Repeat("b", 32), strings.Repeat("a", 32)
+	add(newer, "0000000000000011", trot.RootID, "root", 100, 110)
+	add(newer, "0000000000000012", "0000000000000011", "serve", 101, 109)
+	add(newer, "0000000000000013", "0000000000000012", "db.query", 102, 103)
+	add(older, "0000000000000001", trot.RootID, "root", 0, 10)
+	add(older, "0000000000000002", "0000000000000001", "serve", 1, 9)
+	add(older, "0000000000000003", "0000000000000002", "db.query", 2, 4)
+	add(older, "0000000000000004", "0000000000000001", "db.query", 5, 6)
+	return buf.Bytes()
+}
+
+func runFind(t *testing.T, input []byte, args ...string) (string, error) {
+	t.Helper()
+	cmd := findCmd()
+	if err := cmd.flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	err := cmd.run(context.Background(), &out, bytes.NewReader(input), cmd.flags.Args())
+	return out.String(), err
+}
+
+func TestFindSpans(t *testing.T) {
+	tr, err := trot.Parse(bytes.NewReader(findInput(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := findSpans(tr, nil, func(path []string) bool {
+		return path[len(path)-1] == "db.query"
+	})
+	got := []string{}
+	for _, m := range matches {
+		names := []string{}
+		for _, step := range m.Path {
+			names = append(names, step.Name)
+		}
+		got = append(got, m.SpanID+" "+strings.Join(names, "/"))
+	}
+	// Oldest trace first, and depth first within a trace.
+	want := []string{
+		"0000000000000003 root/serve/db.query",
+		"0000000000000004 root/db.query",
+		"0000000000000013 root/serve/db.query",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findSpans() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if m := matches[0]; m.Duration != 2*time.Second || m.Path[1].Duration != 8*time.Second || m.Path[0].SpanID != "0000000000000001" {
+		t.Errorf("first match = %+v", m)
+	}
+}
+
+func TestFindJSON(t *testing.T) {
+	out, err := runFind(t, findInput(t), "-json", "-name", "^db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(strings.NewReader(out))
+	got := []match{}
+	for dec.More() {
+		var m match
+		if err := dec.Decode(&m); err != nil {
+			t.Fatalf("decoding %q: %v", out, err)
+		}
+		got = append(got, m)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d matches, want 3:\n%s", len(got), out)
+	}
+	m := got[0]
+	if m.TraceID != strings.Repeat("a", 32) || m.Name != "db.query" || m.Duration != 2*time.Second || !m.Start.Equal(time.Unix(2, 0)) || len(m.Path) != 3 {
+		t.Errorf("first match = %+v", m)
+	}
+	if !strings.Contains(out, `"duration_ns":2000000000`) {
+		t.Errorf("durations aren't in nanoseconds:\n%s", out)
+	}
+}
+
+func TestFindSelect(t *testing.T) {
+	input := findInput(t)
+	for _, tc := range []struct {
+		args []string
+		want []string
+	}{
+		// -select alone matches on the whole path.
+		{[]string{"-select", "root/db.query"}, []string{"0000000000000004"}},
+		{[]string{"-select", "**/db.query"}, []string{"0000000000000003", "0000000000000004", "0000000000000013"}},
+		// With -name, a span has to match both.
+		{[]string{"-select", "root/serve/**", "-name", "^db"}, []string{"0000000000000003", "0000000000000013"}},
+		{[]string{"-select", "root/*", "-name", "serve"}, []string{"0000000000000002", "0000000000000012"}},
+	} {
+		out, err := runFind(t, input, append([]string{"-json"}, tc.args...)...)
+		if err != nil {
+			t.Errorf("find %v: %v", tc.args, err)
+			continue
+		}
+		got := []string{}
+		dec := json.NewDecoder(strings.NewReader(out))
+		for dec.More() {
+			var m match
+			if err := dec.Decode(&m); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, m.SpanID)
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("find %v = %v, want %v", tc.args, got, tc.want)
+		}
+	}
+
+	if _, err := runFind(t, input, "-select", "root/nope"); err == nil || err.Error() != "no spans match" {
+		t.Errorf("find with no matches: %v", err)
+	}
+	if _, err := runFind(t, input); err == nil {
+		t.Error("find without -name or -select succeeded")
+	}
+}
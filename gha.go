@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+func ghaCmd() *command {
+	cmd := newCommand("gha", "[flags]", "Render a GitHub Actions workflow run's jobs and steps.")
+
+	repo := cmd.flags.String("repo", os.Getenv("GITHUB_REPOSITORY"), "owner/repo the run belongs to")
+	run := cmd.flags.String("run", "", "workflow run ID")
+	api := cmd.flags.String("api", "https://api.github.com", "GitHub API URL")
+	out := cmd.flags.String("o", "", "write to this file instead of stdout")
+	open := cmd.flags.Bool("open", false, "open the output in a browser (writes to a temp file without -o)")
+	jsonOut := cmd.flags.Bool("json", false, "write the jobs API response, which render reads as -format gha, instead of HTML")
+
+	cmd.run = func(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+		if *repo == "" || *run == "" {
+			return fmt.Errorf("gha requires -repo and -run")
+		}
+
+		jobs, err := fetchJobs(ctx, *api, *repo, *run)
+		if err != nil {
+			return err
+		}
+		if *jsonOut {
+			_, err := w.Write(jobs)
+			return err
+		}
+
+		t, err := trot.ParseFormat(bytes.NewReader(jobs), "gha")
+		if err != nil {
+			return err
+		}
+		render := func(w io.Writer, t *trot.Trace) error {
+			return trot.RenderHTML(w, t, trot.Options{Title: *repo + " run " + *run})
+		}
+		if *out == "" && !*open {
+			return render(w, t)
+		}
+
+		path := *out
+		if path == "" {
+			f, err := os.CreateTemp("", "trot-*.html")
+			if err != nil {
+				return err
+			}
+			f.Close()
+			path = f.Name()
+		}
+		if err := renderFile(path, t, render); err != nil {
+			return err
+		}
+		if *open {
+			return openBrowser(path)
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// fetchJobs gets every page of a workflow run's jobs as one response,
+// authenticating with $GITHUB_TOKEN or $GH_TOKEN if either is set.
+func fetchJobs(ctx context.Context, api, repo, run string) ([]byte, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+
+	var all struct {
+		TotalCount int               `json:"total_count"`
+		Jobs       []json.RawMessage `json:"jobs"`
+	}
+	for page := 1; ; page++ {
+		u := fmt.Sprintf("%s/repos/%s/actions/runs/%s/jobs?filter=all&per_page=100&page=%d", api, repo, run, page)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("GET %s: %s: %s", u, resp.Status, bytes.TrimSpace(body))
+		}
+
+		var got struct {
+			TotalCount int               `json:"total_count"`
+			Jobs       []json.RawMessage `json:"jobs"`
+		}
+		if err := json.Unmarshal(body, &got); err != nil {
+			return nil, fmt.Errorf("GET %s: %w", u, err)
+		}
+		all.TotalCount = got.TotalCount
+		all.Jobs = append(all.Jobs, got.Jobs...)
+		if len(got.Jobs) == 0 || len(all.Jobs) >= got.TotalCount {
+			return json.Marshal(all)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+// githubTop is how many of the slowest spans the job summary lists.
+const githubTop = 5
+
+// writeGitHubSummary appends a Markdown report on t to the job summary that
+// GitHub Actions shows for the step, and sets the step's "html" output to
+// page, if there is one, for actions/upload-artifact.
+func writeGitHubSummary(t *trot.Trace, page string, precision int) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return fmt.Errorf("-github-summary: GITHUB_STEP_SUMMARY is not set")
+	}
+	if err := appendFile(path, func(w io.Writer) {
+		writeMarkdown(w, t, page, precision)
+	}); err != nil {
+		return fmt.Errorf("-github-summary: %w", err)
+	}
+
+	if out := os.Getenv("GITHUB_OUTPUT"); out != "" && page != "" {
+		if err := appendFile(out, func(w io.Writer) {
+			fmt.Fprintf(w, "html=%s\n", page)
+		}); err != nil {
+			return fmt.Errorf("-github-summary: %w", err)
+		}
+	}
+	return nil
+}
+
+func appendFile(path string, write func(io.Writer)) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	write(f)
+	return f.Close()
+}
+
+func writeMarkdown(w io.Writer, t *trot.Trace, page string, precision int) {
+	traces := t.Split()
+	for _, s := range summarize(traces) {
+		tt := traces[s.TraceID]
+		fmt.Fprintf(w, "### %s\n\n", markdown(s.Name))
+		fmt.Fprintf(w, "**%s** total, %d spans, errors: %d (trace `%s`)\n\n", trot.FormatDuration(s.Duration, precision), s.Spans, s.Errors, s.TraceID)
+
+		spans := make([]*trot.Span, 0, len(tt.Spans))
+		for _, span := range tt.Spans {
+			spans = append(spans, span)
+		}
+		sort.Slice(spans, func(i, j int) bool {
+			if di, dj := spans[i].Duration(), spans[j].Duration(); di != dj {
+				return di > dj
+			}
+			return spans[i].SpanContext.SpanID < spans[j].SpanContext.SpanID
+		})
+
+		fmt.Fprintln(w, "| slowest spans | duration |")
+		fmt.Fprintln(w, "|---|---:|")
+		for _, span := range spans[:min(len(spans), githubTop)] {
+			fmt.Fprintf(w, "| %s | %s |\n", markdown(span.Name), trot.FormatDuration(span.Duration(), precision))
+		}
+		fmt.Fprintln(w)
+
+		if s.Errors == 0 {
+			continue
+		}
+		fmt.Fprintln(w, "| error spans | status |")
+		fmt.Fprintln(w, "|---|---|")
+		for _, span := range spans {
+			if span.IsError() {
+				fmt.Fprintf(w, "| %s | %s |\n", markdown(span.Name), markdown(span.Status.Description))
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	if page != "" {
+		fmt.Fprintf(w, "Rendered to `%s`.\n\n", page)
+	}
+}
+
+// markdown escapes s for a table cell.
+var markdown = strings.NewReplacer("|", `\|`, "\n", " ", "<", "&lt;", "`", "\\`", "*", `\*`, "_", `\_`).Replace
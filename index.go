@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+func indexCmd() *command {
+	cmd := newCommand("index", "[flags] dir", "Write an index.html listing the rendered pages in a directory, using their -emit-meta files.")
+
+	out := cmd.flags.String("o", "", "write to this file instead of index.html in dir")
+	title := cmd.flags.String("title", "", "page title")
+	precision := cmd.flags.Int("duration-precision", 3, "significant digits in durations")
+
+	cmd.run = func(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("want one directory, got %d arguments", len(args))
+		}
+		dir := args[0]
+		path := *out
+		if path == "" {
+			path = filepath.Join(dir, "index.html")
+		}
+
+		metas, err := findPages(dir, path)
+		if err != nil {
+			return err
+		}
+		return writeFile(path, func(w io.Writer) error {
+			return trot.RenderArchive(w, metas, trot.Options{Title: *title, Precision: *precision})
+		})
+	}
+
+	return cmd
+}
+
+// findPages finds the pages under dir and describes them, newest first, with
+// Pages relative to index. Pages with an -emit-meta file get its metadata;
+// others just their title and when they were written.
+func findPages(dir, index string) ([]trot.Meta, error) {
+	metas := []trot.Meta{}
+	pages := []string{}
+	described := map[string]bool{}
+	rel := func(path string) string {
+		if r, err := filepath.Rel(filepath.Dir(index), path); err == nil {
+			path = r
+		}
+		return filepath.ToSlash(path)
+	}
+
+	if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Indexes, like this one or -split -publish's, aren't pages.
+		if d.IsDir() || d.Name() == "index.html" || d.Name() == "index.html.gz" || filepath.Clean(path) == filepath.Clean(index) {
+			return nil
+		}
+
+		switch {
+		case strings.HasSuffix(path, ".html"), strings.HasSuffix(path, ".html.gz"):
+			pages = append(pages, path)
+		case strings.HasSuffix(path, ".json"):
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			var mf metaFile
+			// Plenty of JSON files aren't ours, like traces.
+			if json.Unmarshal(b, &mf) != nil {
+				return nil
+			}
+			for _, m := range mf.Traces {
+				if m.TraceID == "" {
+					continue
+				}
+				if m.Page != "" {
+					page := filepath.Join(filepath.Dir(path), filepath.FromSlash(m.Page))
+					described[filepath.Clean(page)] = true
+					m.Page = rel(page)
+				}
+				metas = append(metas, m)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, page := range pages {
+		if described[filepath.Clean(page)] {
+			continue
+		}
+		info, err := os.Stat(page)
+		if err != nil {
+			return nil, err
+		}
+		name, err := pageTitle(page)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			name = filepath.Base(page)
+		}
+		metas = append(metas, trot.Meta{Name: name, Start: info.ModTime(), Page: rel(page)})
+	}
+
+	sort.SliceStable(metas, func(i, j int) bool {
+		return metas[i].Start.After(metas[j].Start)
+	})
+	return metas, nil
+}
+
+var titleRE = regexp.MustCompile(`<title>([^<]*)</title>`)
+
+// pageTitle returns the <title> of the page at path, which may be gzipped.
+func pageTitle(path string) (string, error) {
+	var title string
+	err := withFile(path, func(r io.Reader) error {
+		// The title is at the top of the page.
+		b, err := io.ReadAll(io.LimitReader(r, 4096))
+		if err != nil {
+			return err
+		}
+		if m := titleRE.FindSubmatch(b); m != nil {
+			title = html.UnescapeString(string(m[1]))
+		}
+		return nil
+	})
+	return title, err
+}
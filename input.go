@@ -0,0 +1,451 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+	"github.com/klauspost/compress/zstd"
+)
+
+// readTrace parses every file in args into one Trace, or r if there are none.
+// Spans from different files are merged by TraceID and SpanID.
+func readTrace(ctx context.Context, r io.Reader, args []string, in input) (*trot.Trace, error) {
+	if len(args) == 0 {
+		t, err := parseStdin(r, in.format)
+		if err != nil {
+			return nil, err
+		}
+		return inferRoots(t, in.orphans)
+	}
+
+	paths, err := expandArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode files concurrently, but merge them in order so that which
+	// duplicate span wins doesn't depend on scheduling.
+	traces := make([]*trot.Trace, len(paths))
+	errs := make([]error, len(paths))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for i, path := range paths {
+		if path == "-" {
+			// There's only one stdin, so don't bother with a goroutine.
+			traces[i], errs[i] = parseStdin(r, in.format)
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, path string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			traces[i], errs[i] = readFile(ctx, path, in.format)
+		}(i, path)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	t := trot.NewTrace()
+	for _, ft := range traces {
+		t.Duplicates += ft.Duplicates
+		for _, span := range ft.Spans {
+			if len(paths) > 1 {
+				span.SpanContext.TraceID = stitchID(span.SpanContext.TraceID)
+				span.Parent.TraceID = stitchID(span.Parent.TraceID)
+				span.SpanContext.SpanID = strings.ToLower(span.SpanContext.SpanID)
+				span.Parent.SpanID = strings.ToLower(span.Parent.SpanID)
+			}
+			t.Add(span)
+		}
+	}
+
+	return inferRoots(t, in.orphans)
+}
+
+// stitchID writes TraceIDs the same way whichever process's file they came
+// from: lowercase, with 64-bit IDs (from B3 or Jaeger) zero-padded to 128 bits
+// as W3C propagation does.
+func stitchID(id string) string {
+	if len(id) == 16 {
+		id = "0000000000000000" + id
+	}
+	return strings.ToLower(id)
+}
+
+// expandArgs expands glob patterns ourselves, since shells don't always
+// (quoted patterns, Windows, more files than ARG_MAX allows), and lists the
+// files in any directories.
+func expandArgs(args []string) ([]string, error) {
+	paths := []string{}
+	for _, arg := range args {
+		if fi, err := os.Stat(arg); err == nil && fi.IsDir() {
+			found, err := findTraces(arg)
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, found...)
+			continue
+		}
+
+		if !strings.ContainsAny(arg, "*?[") {
+			paths = append(paths, arg)
+			continue
+		}
+
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("bad pattern %q: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", arg)
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// findTraces walks dir for files that look like traces, skipping hidden
+// files and directories, so a collector's output directory (which may also
+// hold rendered pages) can be read as is.
+func findTraces(dir string) ([]string, error) {
+	paths := []string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && d.Type().IsRegular() && traceFile(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// traceExts are extensions of files that findTraces reads without looking.
+var traceExts = map[string]bool{
+	".json": true, ".jsonl": true, ".ndjson": true,
+	".csv": true, ".tsv": true, ".folded": true, ".pprof": true, ".pb": true, ".strace": true,
+}
+
+// compressExts are extensions that findTraces looks past, to the one before.
+var compressExts = map[string]bool{".gz": true, ".zst": true, ".zstd": true}
+
+// traceFile reports whether path is named like a trace, or, if its name
+// doesn't say, starts like JSON or compressed data. Compressed files go by
+// the name inside, so rendered pages like a.html.gz are skipped, and so is
+// JSON that trot wrote itself, like -emit-meta files and search.json.
+func traceFile(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	ext := filepath.Ext(name)
+	if compressExts[ext] {
+		ext = filepath.Ext(strings.TrimSuffix(name, ext))
+	}
+	if ext != "" && !traceExts[ext] && ext != ".log" && ext != ".out" && ext != ".txt" {
+		return false
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(len(zstdMagic))
+	compressed := bytes.HasPrefix(magic, gzipMagic) || bytes.HasPrefix(magic, zstdMagic)
+
+	zr, err := decompress(br)
+	if err != nil {
+		return false
+	}
+	defer zr.Close()
+	peek := make([]byte, 512)
+	n, _ := io.ReadFull(zr, peek)
+	peek = peek[:n]
+
+	if ownJSON(peek) {
+		return false
+	}
+	if traceExts[ext] || compressed {
+		return true
+	}
+	peek = bytes.TrimLeft(peek, " \t\r\n")
+	return len(peek) != 0 && (peek[0] == '{' || peek[0] == '[')
+}
+
+// ownJSON reports whether peek starts a metaFile or searchManifest, whose
+// first key is "traces".
+func ownJSON(peek []byte) bool {
+	dec := json.NewDecoder(bytes.NewReader(peek))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return false
+	}
+	key, err := dec.Token()
+	return err == nil && key == "traces"
+}
+
+func readFile(ctx context.Context, path, format string) (*trot.Trace, error) {
+	if strings.HasPrefix(path, ociScheme) {
+		return pullTrace(ctx, path)
+	}
+	var t *trot.Trace
+	if err := withFile(path, func(r io.Reader) (err error) {
+		t, err = trot.ParseFormat(r, format)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	slog.Debug("read", "path", path, "spans", len(t.Spans))
+
+	return t, nil
+}
+
+// readLogs parses every log file in args, for attaching to spans.
+func readLogs(args []string) ([]trot.Log, error) {
+	paths, err := expandArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := []trot.Log{}
+	for _, path := range paths {
+		if err := withFile(path, func(r io.Reader) error {
+			l, err := trot.ParseLogs(r)
+			logs = append(logs, l...)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return logs, nil
+}
+
+// attachLogs adds logs to their spans in t.
+func attachLogs(t *trot.Trace, logs []trot.Log) {
+	if len(logs) == 0 {
+		return
+	}
+	n := t.AttachLogs(logs)
+	slog.Debug("attached logs", "matched", n, "logs", len(logs))
+}
+
+// streamTrace is readTrace for inputs too big to hold in memory: fn gets
+// each trace as soon as it is complete instead. If maxMemory is positive,
+// incomplete traces beyond that many bytes are spilled to a temp dir.
+func streamTrace(r io.Reader, args []string, in input, maxMemory int64, fn func(*trot.Trace) error) error {
+	s := trot.NewStreamer(func(t *trot.Trace) error {
+		t, err := inferRoots(t, in.orphans)
+		if err != nil {
+			return err
+		}
+		return fn(t)
+	})
+	stdin := func() error {
+		zr, err := decompress(r)
+		if err != nil {
+			return fmt.Errorf("stdin: %w", err)
+		}
+		defer zr.Close()
+		return s.Decode(zr, in.format)
+	}
+
+	if maxMemory > 0 {
+		dir, err := os.MkdirTemp("", "trot-spill-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		s.Spill(dir, maxMemory)
+	}
+
+	if len(args) == 0 {
+		if err := stdin(); err != nil {
+			return err
+		}
+		return s.Close()
+	}
+
+	paths, err := expandArgs(args)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if path == "-" {
+			err = stdin()
+		} else {
+			err = withFile(path, func(r io.Reader) error {
+				return s.Decode(r, in.format)
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.Close()
+}
+
+// withFile calls fn with the decompressed contents of path.
+func withFile(path string, fn func(io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := decompress(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	defer r.Close()
+
+	if err := fn(r); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// decompress gunzips or unzstds r if its magic bytes say it's compressed,
+// whatever it's called, since collectors rotate compressed files and stdin
+// has no name at all.
+func decompress(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return io.NopCloser(br), nil
+}
+
+// parseStdin is trot.ParseFormat for stdin, which may be compressed.
+func parseStdin(r io.Reader, format string) (*trot.Trace, error) {
+	zr, err := decompress(r)
+	if err != nil {
+		return nil, fmt.Errorf("stdin: %w", err)
+	}
+	defer zr.Close()
+	return trot.ParseFormat(zr, format)
+}
+
+const formatUsage = "input format (stdouttrace, otlp, jaeger, zipkin, gotest, gha, bep, buildkit, folded, pprof, strace, honeycomb, datadog, otelcli, csv, tsv); sniffed if empty"
+
+// inferRoots handles spans whose parent isn't in t per policy, one of
+// -orphans. Spans with an empty or all-zero parent are always roots.
+func inferRoots(t *trot.Trace, policy string) (*trot.Trace, error) {
+	switch policy {
+	case "root":
+		return t.PromoteOrphans(), nil
+	case "placeholder":
+		return t.AddPlaceholders(), nil
+	case "drop":
+		kept := t.DropOrphans()
+		if n := len(t.Spans) - len(kept.Spans); n != 0 {
+			slog.Warn("dropped spans with missing parents", "spans", n)
+		}
+		return kept, nil
+	case "fail":
+		if n := orphans(t); n != 0 {
+			return nil, fmt.Errorf("%d spans with missing parents", n)
+		}
+	}
+	return t, nil
+}
+
+// input is how to read traces, as set by formatFlags.
+type input struct {
+	format string
+
+	// orphans is -orphans, or "root" for -roots orphans.
+	orphans string
+}
+
+// formatFlags adds -format, -roots, -orphans, and -columns for the csv and
+// tsv formats.
+func formatFlags(fs *flag.FlagSet) *input {
+	in := &input{orphans: "keep"}
+	// -roots is the older, narrower -orphans. Mixed, whichever came last
+	// would win, so they can't be.
+	var roots, orphans bool
+	fs.Func("roots", "deprecated: use -orphans root for orphans, or leave it out for parentless", func(s string) error {
+		if orphans {
+			return fmt.Errorf("can't be combined with -orphans; use -orphans alone")
+		}
+		roots = true
+		switch s {
+		case "parentless":
+			in.orphans = "keep"
+		case "orphans":
+			in.orphans = "root"
+		default:
+			return fmt.Errorf("want parentless or orphans")
+		}
+		return nil
+	})
+	fs.Func("orphans", "what to do with spans whose parent isn't in the input: keep (in a section of unparented spans after the tree; the default), root (make them roots), placeholder (under an \"unknown parent\" span for each missing parent), drop (with a warning), or fail", func(s string) error {
+		if roots {
+			return fmt.Errorf("can't be combined with the deprecated -roots; use -orphans alone")
+		}
+		orphans = true
+		switch s {
+		case "keep", "root", "placeholder", "drop", "fail":
+			in.orphans = s
+		default:
+			return fmt.Errorf("want keep, root, placeholder, drop, or fail")
+		}
+		return nil
+	})
+	fs.Func("columns", "which csv/tsv header holds each span field, e.g. name=task,start=began_at,duration=elapsed:ms (fields: name, id, parent, trace, service, start, end, duration, status)", func(s string) error {
+		cols, err := trot.ParseCSVColumns(s)
+		if err != nil {
+			return err
+		}
+		// Registered later, these shadow the defaults.
+		trot.Register(trot.CSV{Columns: cols})
+		trot.Register(trot.CSV{Comma: '\t', Columns: cols})
+		return nil
+	})
+	fs.StringVar(&in.format, "format", "", formatUsage)
+	return in
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindTraces(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if filepath.Ext(name) == ".gz" {
+			zw := gzip.NewWriter(f)
+			defer zw.Close()
+			zw.Write([]byte(content))
+			return
+		}
+		f.WriteString(content)
+	}
+	write("spans.json", `{"resourceSpans": []}`)
+	write("spans.json.gz", `{"resourceSpans": []}`)
+	write("spans.gz", `{"resourceSpans": []}`)
+	write("spans.log", `{"resourceSpans": []}`)
+	write("notes.log", `not json`)
+	write("page.html", `<!doctype html>`)
+	write("page.html.gz", `<!doctype html>`)
+	write("meta.json", `{"traces": [{"trace_id": "1"}]}`)
+	write("search.json.gz", `{"traces": []}`)
+
+	got, err := findTraces(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, path := range got {
+		got[i] = filepath.Base(path)
+	}
+	if want := []string{"spans.gz", "spans.json", "spans.json.gz", "spans.log"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("findTraces = %q, want %q", got, want)
+	}
+}
+
+func TestFormatFlags(t *testing.T) {
+	for _, tc := range []struct {
+		args    []string
+		orphans string
+	}{
+		{nil, "keep"},
+		{[]string{"-roots", "orphans"}, "root"},
+		{[]string{"-orphans", "drop"}, "drop"},
+		{[]string{"-roots", "orphans", "-roots", "parentless"}, "keep"},
+	} {
+		fs := flag.NewFlagSet("render", flag.ContinueOnError)
+		in := formatFlags(fs)
+		if err := fs.Parse(append(tc.args, "-format", "otlp")); err != nil {
+			t.Fatal(err)
+		}
+		if in.orphans != tc.orphans || in.format != "otlp" {
+			t.Errorf("%q: %+v, want orphans %s", tc.args, *in, tc.orphans)
+		}
+	}
+
+	// The deprecated -roots can't be mixed with -orphans, in either order.
+	for _, args := range [][]string{
+		{"-orphans", "fail", "-roots", "orphans"},
+		{"-roots", "parentless", "-orphans", "keep"},
+	} {
+		fs := flag.NewFlagSet("render", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		formatFlags(fs)
+		if err := fs.Parse(args); err == nil || !strings.Contains(err.Error(), "can't be combined") {
+			t.Errorf("%q: %v", args, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+type logFlags struct {
+	quiet   *bool
+	verbose *bool
+	format  *string
+}
+
+func addLogFlags(fs *flag.FlagSet) *logFlags {
+	return &logFlags{
+		quiet:   fs.Bool("quiet", false, "only log errors"),
+		verbose: fs.Bool("verbose", false, "log debug details"),
+		format:  fs.String("log-format", "text", "log format on stderr (text or json)"),
+	}
+}
+
+// setup installs the default slog.Logger, writing to w.
+func (lf *logFlags) setup(w io.Writer) error {
+	level := slog.LevelInfo
+	switch {
+	case *lf.quiet && *lf.verbose:
+		return fmt.Errorf("-quiet and -verbose are mutually exclusive")
+	case *lf.quiet:
+		level = slog.LevelError
+	case *lf.verbose:
+		level = slog.LevelDebug
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler
+	switch *lf.format {
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("unknown -log-format %q (want text or json)", *lf.format)
+	}
+
+	slog.SetDefault(slog.New(h))
+	return nil
+}
@@ -1,255 +1,140 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
-	"log"
 	"os"
-	"time"
-
-	"golang.org/x/exp/slices"
+	"os/exec"
+	"os/signal"
+	"syscall"
 )
 
-func main() {
-	if err := mainE(os.Stdout, os.Stdin); err != nil {
-		log.Fatal(err)
-	}
+type command struct {
+	name  string
+	usage string
+	short string
+	flags *flag.FlagSet
+	log   *logFlags
+	run   func(ctx context.Context, w io.Writer, r io.Reader, args []string) error
+
+	// graceful commands get a ctx that's cancelled on SIGINT or SIGTERM, so
+	// they can shut down cleanly. Others are killed by them as usual.
+	graceful bool
 }
 
-func mainE(w io.Writer, r io.Reader) error {
-	spans := map[string]*Span{}
-	children := map[string][]*Span{}
-
-	i := 0
-
-	dec := json.NewDecoder(r)
-	for {
-		i++
-		var span Span
-		if err := dec.Decode(&span); err != nil {
-			if errors.Is(err, io.EOF) {
-				break
-			}
-
-			return fmt.Errorf("line %d: %w", i, err)
-		}
-
-		spans[span.SpanContext.SpanID] = &span
-
-		kids, ok := children[span.Parent.SpanID]
-		if !ok {
-			kids = []*Span{}
-		}
-		kids = append(kids, &span)
-		children[span.Parent.SpanID] = kids
+func newCommand(name, usage, short string) *command {
+	cmd := &command{
+		name:  name,
+		usage: usage,
+		short: short,
+		flags: flag.NewFlagSet(name, flag.ContinueOnError),
+	}
+	cmd.log = addLogFlags(cmd.flags)
+	cmd.flags.Usage = func() {
+		fmt.Fprintf(cmd.flags.Output(), "usage: trot %s %s\n\n%s\n\n", name, usage, short)
+		cmd.flags.PrintDefaults()
 	}
+	return cmd
+}
 
-	missing := map[string]struct{}{}
+// commands returns every subcommand; the first is the default.
+func commands() []*command {
+	return []*command{
+		renderCmd(),
+		serveCmd(),
+		receiveCmd(),
+		runCmd(),
+		statsCmd(),
+		findCmd(),
+		indexCmd(),
+		siteCmd(),
+		diffCmd(),
+		convertCmd(),
+		pushCmd(),
+		ghaCmd(),
+		checkCmd(),
+		validateCmd(),
+		completionCmd(),
+		versionCmd(),
+	}
+}
 
-	for parent := range children {
-		if _, ok := spans[parent]; !ok {
-			missing[parent] = struct{}{}
+func main() {
+	if err := mainE(context.Background(), os.Stdout, os.Stdin, os.Args[1:]); err != nil {
+		// Not through slog, which would quote a ParseError's snippet onto one line.
+		fmt.Fprintln(os.Stderr, err)
+		// trot run exits like the command it ran.
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && exit.ExitCode() > 0 {
+			os.Exit(exit.ExitCode())
 		}
+		os.Exit(1)
 	}
-	for missed := range missing {
-		log.Printf("missing %q", missed)
-	}
+}
 
-	// TODO: This feels not right.
-	rootSpans, ok := children["0000000000000000"]
-	if !ok {
-		log.Printf("no root")
+func mainE(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+	cmds := commands()
 
-		for missed := range missing {
-			root := &Node{
-				Span: &Span{
-					Name: "Missing span",
-					SpanContext: SpanContext{
-						SpanID: missed,
-					},
-				},
+	// Without a subcommand, behave like the original stdin -> stdout pipeline.
+	cmd := cmds[0]
+	if len(args) != 0 {
+		switch args[0] {
+		case "help", "-h", "-help", "--help":
+			usage(os.Stderr, cmds)
+			return nil
+		}
+		for _, c := range cmds {
+			if c.name == args[0] {
+				cmd = c
+				args = args[1:]
+				break
 			}
-
-			buildTree(root, children, spans)
-
-			writeSpan(w, nil, root)
 		}
 	}
 
-	fmt.Fprint(w, header)
-
-	root := &Node{
-		Span: &Span{
-			Name: "root",
-			SpanContext: SpanContext{
-				SpanID: "0000000000000000",
-			},
-		},
-	}
-
-	for _, rootSpan := range rootSpans {
-		root.Children = append(root.Children, &Node{
-			Span: rootSpan,
-		})
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		return err
 	}
-
-	buildTree(root, children, spans)
-
-	writeSpan(w, nil, root)
-
-	fmt.Fprint(w, footer)
-	return nil
-}
-
-func buildTree(root *Node, children map[string][]*Span, spans map[string]*Span) {
-	kids, ok := children[root.Span.SpanContext.SpanID]
-	if !ok {
-		return
+	if err := cfg.apply(cmd); err != nil {
+		return err
 	}
 
-	root.Children = make([]*Node, len(kids))
-	for i, kid := range kids {
-		node := &Node{
-			Span: kid,
+	if err := cmd.flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
 		}
-		buildTree(node, children, spans)
-		root.Children[i] = node
+		return err
 	}
 
-	slices.SortFunc(root.Children, func(a, b *Node) int {
-		return a.Span.StartTime.Compare(b.Span.StartTime)
-	})
-
-	if root.Span.StartTime == root.Span.EndTime {
-		root.Span.StartTime = root.Children[0].Span.StartTime
-
-		last := slices.MaxFunc(root.Children, func(a, b *Node) int {
-			return a.Span.EndTime.Compare(b.Span.EndTime)
-		})
-		root.Span.EndTime = last.Span.EndTime
-	}
-}
-
-func writeSpan(w io.Writer, parent, node *Node) {
-	if parent == nil {
-		fmt.Fprint(w, `<div>`)
-	} else {
-		total := parent.Span.EndTime.Sub(parent.Span.StartTime)
-		left := node.Span.StartTime.Sub(parent.Span.StartTime)
-		right := parent.Span.EndTime.Sub(node.Span.EndTime)
-
-		leftpad := float64(left) / float64(total)
-		rightpad := float64(right) / float64(total)
-
-		if len(node.Children) == 0 {
-			fmt.Fprintf(w, `<div style="margin: 1px %f%% 0 %f%%">`, 100.0*rightpad, 100.0*leftpad)
-		} else {
-			fmt.Fprintf(w, `<div class="parent" style="margin: 1px %f%% 0 %f%%">`, 100.0*rightpad, 100.0*leftpad)
-		}
+	if err := cmd.log.setup(os.Stderr); err != nil {
+		return err
 	}
 
-	dur := node.Span.EndTime.Sub(node.Span.StartTime)
-
-	if len(node.Children) == 0 {
-		fmt.Fprintf(w, `<span>%s %s</span>`, node.Span.Name, dur)
-	} else {
-		if parent == nil {
-			// Default to root being open.
-			fmt.Fprintf(w, `<details open><summary>%s %s</summary>`, node.Span.Name, dur)
-		} else {
-			fmt.Fprintf(w, `<details><summary>%s %s</summary>`, node.Span.Name, dur)
-		}
-		for _, child := range node.Children {
-			writeSpan(w, node, child)
-		}
-		fmt.Fprint(w, `</details>`)
+	if cmd.graceful {
+		var cancel context.CancelFunc
+		ctx, cancel = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+		go func() {
+			// Let a second signal kill trot while it's shutting down.
+			<-ctx.Done()
+			cancel()
+		}()
 	}
-	fmt.Fprintln(w, "</div>")
-}
 
-const header = `
-<html>
-<head>
-<title>trot</title>
-<style>
-summary {
-  border: 1px solid;
-  display: block;
-  white-space: nowrap;
-  padding: 3px;
-}
-span {
-  border: 1px solid;
-  display: block;
-  white-space: nowrap;
-  padding: 3px;
-}
-body {
-	width: 100%;
-	margin: 0px;
-}
-div.parent:hover {
-	outline: 1.5px solid lightgrey;
+	return cmd.run(ctx, w, r, cmd.flags.Args())
 }
-</style>
-</head>
-<body>`
 
-const footer = `
-    </body>
-</html>
-`
-
-type Node struct {
-	Span     *Span
-	Children []*Node
-}
-
-type SpanContext struct {
-	TraceID    string `json:"TraceID"`
-	SpanID     string `json:"SpanID"`
-	TraceFlags string `json:"TraceFlags"`
-	TraceState string `json:"TraceState"`
-	Remote     bool   `json:"Remote"`
-}
-
-// Thank you mholt.
-type Span struct {
-	Name        string      `json:"Name"`
-	SpanContext SpanContext `json:"SpanContext"`
-	Parent      struct {
-		TraceID    string `json:"TraceID"`
-		SpanID     string `json:"SpanID"`
-		TraceFlags string `json:"TraceFlags"`
-		TraceState string `json:"TraceState"`
-		Remote     bool   `json:"Remote"`
-	} `json:"Parent"`
-	SpanKind   int       `json:"SpanKind"`
-	StartTime  time.Time `json:"StartTime"`
-	EndTime    time.Time `json:"EndTime"`
-	Attributes any       `json:"Attributes"`
-	Events     any       `json:"Events"`
-	Links      any       `json:"Links"`
-	Status     struct {
-		Code        string `json:"Code"`
-		Description string `json:"Description"`
-	} `json:"Status"`
-	DroppedAttributes int `json:"DroppedAttributes"`
-	DroppedEvents     int `json:"DroppedEvents"`
-	DroppedLinks      int `json:"DroppedLinks"`
-	ChildSpanCount    int `json:"ChildSpanCount"`
-	Resource          []struct {
-		Key   string `json:"Key"`
-		Value struct {
-			Type  string `json:"Type"`
-			Value string `json:"Value"`
-		} `json:"Value"`
-	} `json:"Resource"`
-	InstrumentationLibrary struct {
-		Name      string `json:"Name"`
-		Version   string `json:"Version"`
-		SchemaURL string `json:"SchemaURL"`
-	} `json:"InstrumentationLibrary"`
+func usage(w io.Writer, cmds []*command) {
+	fmt.Fprintln(w, "usage: trot <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, c := range cmds {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.short)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Without a command, trot runs %q.\n", cmds[0].name)
 }
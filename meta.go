@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+// metaFile is what -emit-meta writes, and index reads.
+type metaFile struct {
+	Traces []trot.Meta `json:"traces"`
+}
+
+// writeMeta writes metadata for each trace in t to path, linking each to
+// its page: page, or with split, its PageName in that directory.
+func writeMeta(path string, t *trot.Trace, page string, split bool, ext string) error {
+	metas := t.Metas()
+	for i, m := range metas {
+		p := page
+		if split {
+			p = filepath.Join(page, trot.PageName(m.TraceID)+ext)
+		}
+		if p == "" {
+			continue
+		}
+		if rel, err := relPath(filepath.Dir(path), p); err == nil {
+			p = rel
+		}
+		metas[i].Page = filepath.ToSlash(p)
+	}
+	return writeFile(path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(metaFile{Traces: metas})
+	})
+}
+
+// relPath is filepath.Rel, but for paths that are relative to different
+// places, like "." and "../pages".
+func relPath(base, target string) (string, error) {
+	base, err := filepath.Abs(base)
+	if err != nil {
+		return "", err
+	}
+	target, err = filepath.Abs(target)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(base, target)
+}
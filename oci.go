@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+// Media types for traces pushed to OCI registries.
+const (
+	ociArtifactType = "application/vnd.trot.trace.v1"
+	ociTraceType    = "application/vnd.trot.stdouttrace.v1+json"
+	ociPageType     = "text/html"
+)
+
+// ociScheme marks an input argument as a reference to pull, e.g.
+// oci://ghcr.io/org/app/traces:build-123.
+const ociScheme = "oci://"
+
+func pushCmd() *command {
+	cmd := newCommand("push", "[flags] ref [file...]", "Push traces and their rendered page to an OCI registry as an artifact.")
+
+	in := formatFlags(cmd.flags)
+	title := cmd.flags.String("title", "", "page title")
+	theme := cmd.flags.String("theme", "light", "color theme (light or dark)")
+
+	cmd.run = func(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("push requires a reference")
+		}
+		ref, err := ociRef(args[0])
+		if err != nil {
+			return err
+		}
+
+		t, err := readTrace(ctx, r, args[1:], *in)
+		if err != nil {
+			return err
+		}
+
+		dir, err := os.MkdirTemp("", "trot-push-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		if err := writeFile(filepath.Join(dir, "trace.json"), func(w io.Writer) error {
+			return trot.EncodeStdouttrace(w, t)
+		}); err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(dir, "trace.html"), func(w io.Writer) error {
+			return trot.RenderHTML(w, t, trot.Options{Title: *title, Theme: *theme})
+		}); err != nil {
+			return err
+		}
+
+		// oras does the registry auth dance with the user's docker credentials.
+		push := exec.CommandContext(ctx, "oras", "push", "--artifact-type", ociArtifactType, ref,
+			"trace.json:"+ociTraceType, "trace.html:"+ociPageType)
+		push.Dir = dir
+		push.Stdout, push.Stderr = os.Stderr, os.Stderr
+		if err := push.Run(); err != nil {
+			return fmt.Errorf("pushing %s: %w", ref, err)
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+// ociRef is ref without ociScheme, as long as oras can't take it for a flag.
+func ociRef(ref string) (string, error) {
+	ref = strings.TrimPrefix(ref, ociScheme)
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("bad reference %q", ref)
+	}
+	return ref, nil
+}
+
+// pullTrace reads the trace pushed to ref by trot push.
+func pullTrace(ctx context.Context, ref string) (*trot.Trace, error) {
+	ref, err := ociRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "trot-pull-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	pull := exec.CommandContext(ctx, "oras", "pull", "--output", dir, ref)
+	pull.Stdout, pull.Stderr = os.Stderr, os.Stderr
+	if err := pull.Run(); err != nil {
+		return nil, fmt.Errorf("pulling %s: %w", ref, err)
+	}
+	slog.Debug("pulled", "ref", ref)
+
+	// trot push wrote it, whatever -format says the other inputs are.
+	return readFile(ctx, filepath.Join(dir, "trace.json"), "stdouttrace")
+}
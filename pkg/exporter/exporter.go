@@ -0,0 +1,117 @@
+// Package exporter implements an OpenTelemetry SpanExporter that writes trot HTML.
+//
+//	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter.New("traces")))
+//	defer tp.Shutdown(ctx)
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var _ sdktrace.SpanExporter = (*Exporter)(nil)
+
+// Exporter buffers spans in memory and writes <dir>/<TraceID>.html for each trace on Shutdown.
+type Exporter struct {
+	dir  string
+	opts trot.Options
+
+	mu     sync.Mutex
+	traces map[string]*bytes.Buffer
+	order  []string
+	done   bool
+}
+
+// New returns an Exporter that writes into dir, creating it if necessary.
+func New(dir string) *Exporter {
+	return &Exporter{
+		dir:    dir,
+		traces: map[string]*bytes.Buffer{},
+	}
+}
+
+// WithOptions sets the options used to render each trace.
+func (e *Exporter) WithOptions(opts trot.Options) *Exporter {
+	e.opts = opts
+	return e
+}
+
+// ExportSpans buffers spans as stdouttrace JSON, which is what trot parses best.
+func (e *Exporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.done {
+		return nil
+	}
+
+	for _, stub := range tracetest.SpanStubsFromReadOnlySpans(spans) {
+		tid := stub.SpanContext.TraceID().String()
+		buf, ok := e.traces[tid]
+		if !ok {
+			buf = &bytes.Buffer{}
+			e.traces[tid] = buf
+			e.order = append(e.order, tid)
+		}
+		if err := json.NewEncoder(buf).Encode(&stub); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Shutdown renders every buffered trace. Spans exported after Shutdown are dropped.
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.done {
+		return nil
+	}
+	e.done = true
+
+	if err := os.MkdirAll(e.dir, 0o755); err != nil {
+		return err
+	}
+
+	for _, tid := range e.order {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := e.write(tid, e.traces[tid]); err != nil {
+			return fmt.Errorf("writing trace %s: %w", tid, err)
+		}
+		delete(e.traces, tid)
+	}
+
+	return nil
+}
+
+func (e *Exporter) write(tid string, buf *bytes.Buffer) error {
+	t, err := trot.ParseFormat(buf, "stdouttrace")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(e.dir, tid+".html"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := trot.RenderHTML(f, t, e.opts); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
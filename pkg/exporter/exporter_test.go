@@ -0,0 +1,56 @@
+package exporter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestExporter(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "traces")
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(New(dir)))
+	tracer := tp.Tracer("test")
+
+	ctx, parent := tracer.Start(ctx, "build")
+	_, child := tracer.Start(ctx, "compile")
+	child.End()
+	parent.End()
+	_, other := tracer.Start(context.Background(), "deploy")
+	other.End()
+
+	if err := tp.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		tid       string
+		has, hasn []string
+	}{
+		{parent.SpanContext().TraceID().String(), []string{"build", "compile", child.SpanContext().SpanID().String()}, []string{"deploy"}},
+		{other.SpanContext().TraceID().String(), []string{"deploy"}, []string{"build", "compile"}},
+	} {
+		b, err := os.ReadFile(filepath.Join(dir, tc.tid+".html"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		page := string(b)
+		for _, s := range tc.has {
+			if !strings.Contains(page, s) {
+				t.Errorf("%s.html doesn't have %q", tc.tid, s)
+			}
+		}
+		for _, s := range tc.hasn {
+			if strings.Contains(page, s) {
+				t.Errorf("%s.html has %q from another trace", tc.tid, s)
+			}
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("wrote %d files, want 2", len(entries))
+	}
+}
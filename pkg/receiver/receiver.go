@@ -0,0 +1,100 @@
+// Package receiver implements the trace half of an OTLP/HTTP receiver.
+package receiver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Path is where OTLP/HTTP exporters send traces.
+const Path = "/v1/traces"
+
+// MaxRequestSize is the most a request's body can take up, before and after
+// decompressing it, like the OpenTelemetry Collector's default.
+const MaxRequestSize = 20 << 20
+
+var errTooLarge = fmt.Errorf("request body over %d bytes", MaxRequestSize)
+
+// Adder receives every decoded request, e.g. a *trot.MemStore.
+type Adder interface {
+	Add(*trot.Trace)
+}
+
+// Handler accepts OTLP/HTTP ExportTraceServiceRequests in protobuf or JSON.
+func Handler(a Adder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, MaxRequestSize)
+		t, err := decode(r)
+		var mbe *http.MaxBytesError
+		if errors.Is(err, errTooLarge) || errors.As(err, &mbe) {
+			http.Error(w, errTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		a.Add(t)
+
+		// An empty body is an empty protobuf ExportTraceServiceResponse.
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		if isJSON(r) {
+			fmt.Fprint(w, "{}")
+		}
+	})
+}
+
+func isJSON(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+}
+
+func decode(r *http.Request) (*trot.Trace, error) {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		body = zr
+	}
+
+	// A small gzipped body can decompress to a huge one.
+	b, err := io.ReadAll(io.LimitReader(body, MaxRequestSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > MaxRequestSize {
+		return nil, errTooLarge
+	}
+
+	if !isJSON(r) {
+		var req coltracepb.ExportTraceServiceRequest
+		if err := proto.Unmarshal(b, &req); err != nil {
+			return nil, fmt.Errorf("unmarshaling protobuf: %w", err)
+		}
+
+		// trot's OTLP/JSON decoder accepts protojson's base64 IDs, so reuse it.
+		b, err = protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(&req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return trot.ParseFormat(bytes.NewReader(b), "otlp")
+}
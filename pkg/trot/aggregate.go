@@ -0,0 +1,108 @@
+package trot
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Aggregate is how spans with one service and name did across many traces.
+type Aggregate struct {
+	Service string
+	Name    string
+
+	// Count is how many spans there were, in how many Traces.
+	Count  int
+	Traces int
+	Errors int
+
+	Total time.Duration
+	P50   time.Duration
+	P95   time.Duration
+	Max   time.Duration
+}
+
+// Aggregates groups the spans of traces by service and name, most total
+// time first.
+func Aggregates(traces map[string]*Trace) []Aggregate {
+	type key struct{ service, name string }
+	aggs := map[key]*Aggregate{}
+	durations := map[key][]time.Duration{}
+	for _, t := range traces {
+		seen := map[key]bool{}
+		for _, span := range t.Spans {
+			k := key{span.Service(), span.Name}
+			a, ok := aggs[k]
+			if !ok {
+				a = &Aggregate{Service: k.service, Name: k.name}
+				aggs[k] = a
+			}
+			if !seen[k] {
+				seen[k] = true
+				a.Traces++
+			}
+			a.Count++
+			if span.IsError() {
+				a.Errors++
+			}
+			d := span.Duration()
+			a.Total += d
+			durations[k] = append(durations[k], d)
+		}
+	}
+
+	all := make([]Aggregate, 0, len(aggs))
+	for k, a := range aggs {
+		ds := durations[k]
+		sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+		a.P50 = ds[int(0.5*float64(len(ds)-1))]
+		a.P95 = ds[int(0.95*float64(len(ds)-1))]
+		a.Max = ds[len(ds)-1]
+		all = append(all, *a)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Total != all[j].Total {
+			return all[i].Total > all[j].Total
+		}
+		if all[i].Service != all[j].Service {
+			return all[i].Service < all[j].Service
+		}
+		return all[i].Name < all[j].Name
+	})
+	return all
+}
+
+// RenderAggregates writes aggs as a table that sorts by any column.
+func RenderAggregates(w io.Writer, aggs []Aggregate, opts Options) error {
+	if opts.Title == "" {
+		opts.Title = "stats"
+	}
+
+	writeHeader(w, opts)
+	fmt.Fprint(w, `<table class="sortable"><thead><tr><th>service</th><th>name</th><th class="num">count</th><th class="num">traces</th><th class="num">errors</th><th class="num">total</th><th class="num">p50</th><th class="num">p95</th><th class="num">max</th></tr></thead><tbody>`+"\n")
+	b := []byte{}
+	for _, a := range aggs {
+		b = append(b[:0], `<tr`...)
+		if a.Errors != 0 {
+			b = append(b, ` class="error"`...)
+		}
+		b = append(b, '>')
+		b = appendCell(b, html.EscapeString(strings.ToLower(a.Service)), html.EscapeString(a.Service), false)
+		b = appendCell(b, html.EscapeString(strings.ToLower(a.Name)), html.EscapeString(a.Name), false)
+		b = appendCell(b, a.Count, fmt.Sprint(a.Count), true)
+		b = appendCell(b, a.Traces, fmt.Sprint(a.Traces), true)
+		b = appendCell(b, a.Errors, fmt.Sprint(a.Errors), true)
+		for _, d := range []time.Duration{a.Total, a.P50, a.P95, a.Max} {
+			b = appendCell(b, int64(d), opts.duration(d), true)
+		}
+		b = append(b, "</tr>\n"...)
+		w.Write(b)
+	}
+	fmt.Fprint(w, `</tbody></table>`)
+	writeSortable(w, opts)
+	writeFooter(w)
+	return nil
+}
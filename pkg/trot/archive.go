@@ -0,0 +1,51 @@
+package trot
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// RenderArchive writes an index of rendered pages, newest first, as a table
+// that sorts by date, name, service, duration, spans, or errors. Metas for
+// pages whose metadata isn't known (no -emit-meta) have no Spans, and only a
+// Name and Start.
+func RenderArchive(w io.Writer, metas []Meta, opts Options) error {
+	if opts.Title == "" {
+		opts.Title = "traces"
+	}
+
+	writeHeader(w, opts)
+	fmt.Fprint(w, `<table class="sortable"><thead><tr><th class="num">date</th><th>name</th><th>service</th><th class="num">duration</th><th class="num">spans</th><th class="num">errors</th></tr></thead><tbody>`+"\n")
+	b := []byte{}
+	for _, m := range metas {
+		b = append(b[:0], `<tr`...)
+		if m.Errors != 0 {
+			b = append(b, ` class="error"`...)
+		}
+		b = append(b, '>')
+		b = appendCell(b, m.Start.Unix(), m.Start.Format(time.DateTime), true)
+
+		name := html.EscapeString(m.Name)
+		if m.Page != "" {
+			name = `<a href="` + html.EscapeString(m.Page) + `">` + name + `</a>`
+		}
+		b = appendCell(b, html.EscapeString(strings.ToLower(m.Name)), name, false)
+		if m.Spans == 0 {
+			b = append(b, `<td data-sort=""></td><td class="num" data-sort="-1"></td><td class="num" data-sort="-1"></td><td class="num" data-sort="-1"></td>`...)
+		} else {
+			b = appendCell(b, html.EscapeString(strings.ToLower(m.Service)), html.EscapeString(m.Service), false)
+			b = appendCell(b, int64(m.Duration), opts.duration(m.Duration), true)
+			b = appendCell(b, m.Spans, fmt.Sprint(m.Spans), true)
+			b = appendCell(b, m.Errors, fmt.Sprint(m.Errors), true)
+		}
+		b = append(b, "</tr>\n"...)
+		w.Write(b)
+	}
+	fmt.Fprint(w, `</tbody></table>`)
+	writeSortable(w, opts)
+	writeFooter(w)
+	return nil
+}
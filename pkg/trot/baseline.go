@@ -0,0 +1,237 @@
+package trot
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Baselines are traces that later ones with the same name (see Summary) are
+// compared to, so regressions stand out as they arrive.
+type Baselines struct {
+	// Threshold is how much slower than its baseline, as a fraction of it, a
+	// trace has to be to count as a regression, 0.1 if zero.
+	Threshold float64
+
+	dir string
+
+	mu        sync.Mutex
+	traces    map[string]*Trace
+	summaries map[string]Summary
+}
+
+// LoadBaselines returns the Baselines kept in dir as OTLP/JSON, one file per
+// name, or if dir is "", Baselines that are only kept in memory.
+func LoadBaselines(dir string) (*Baselines, error) {
+	b := &Baselines{
+		dir:       dir,
+		traces:    map[string]*Trace{},
+		summaries: map[string]Summary{},
+	}
+	if dir == "" {
+		return b, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		t, err := ParseFormat(f, "otlp")
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("loading baseline %s: %w", path, err)
+		}
+		b.add(t)
+	}
+	return b, nil
+}
+
+func (b *Baselines) add(t *Trace) Summary {
+	s := t.Summarize()
+	b.traces[s.Name], b.summaries[s.Name] = t, s
+	return s
+}
+
+// Get returns the baseline for traces named name.
+func (b *Baselines) Get(name string) (*Trace, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t, ok := b.traces[name]
+	return t, ok
+}
+
+// Set makes t, a single trace, the baseline for traces with its name,
+// replacing any other.
+func (b *Baselines) Set(t *Trace) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.add(t)
+	if b.dir == "" {
+		return nil
+	}
+
+	f, err := os.CreateTemp(b.dir, ".baseline-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := EncodeOTLP(f, t); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(b.dir, url.PathEscape(s.Name)+".json"))
+}
+
+// Delta is how a trace compares to its baseline.
+type Delta struct {
+	// Baseline is the baseline's TraceID.
+	Baseline string
+
+	Before time.Duration
+	After  time.Duration
+
+	// Regressed is whether After is over Threshold slower than Before.
+	Regressed bool
+}
+
+func (d Delta) String() string {
+	diff := d.After - d.Before
+	sign := "+"
+	if diff < 0 {
+		sign, diff = "-", -diff
+	}
+	s := sign + FormatDuration(diff, 3)
+	if d.Before > 0 {
+		s += fmt.Sprintf(" (%+.0f%%)", 100*float64(d.After-d.Before)/float64(d.Before))
+	}
+	return s
+}
+
+// Compare returns how the trace s describes compares to its baseline, unless
+// it has none or is its own.
+func (b *Baselines) Compare(s Summary) (Delta, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	base, ok := b.summaries[s.Name]
+	if !ok || base.TraceID == s.TraceID {
+		return Delta{}, false
+	}
+	threshold := b.Threshold
+	if threshold == 0 {
+		threshold = 0.1
+	}
+	return Delta{
+		Baseline:  base.TraceID,
+		Before:    base.Duration,
+		After:     s.Duration,
+		Regressed: float64(s.Duration) > float64(base.Duration)*(1+threshold),
+	}, true
+}
+
+// isBaseline reports whether s is the baseline for its name.
+func (b *Baselines) isBaseline(s Summary) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.summaries[s.Name].TraceID == s.TraceID
+}
+
+// baselinePaths is how many span name paths writeBaseline lists.
+const baselinePaths = 10
+
+// writeBaseline compares t to opts.Baseline by span name path, listing the
+// paths whose total time changed the most.
+func writeBaseline(w io.Writer, t *Trace, opts Options) {
+	if opts.Baseline == nil {
+		return
+	}
+	s, base := t.Summarize(), opts.Baseline.Summarize()
+	if s.TraceID == base.TraceID {
+		return
+	}
+
+	before, after := framePaths(opts.Baseline.Flame()), framePaths(t.Flame())
+	paths := []string{}
+	for path := range before {
+		paths = append(paths, path)
+	}
+	for path := range after {
+		if _, ok := before[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	total := func(m map[string]*Frame, path string) time.Duration {
+		if f, ok := m[path]; ok {
+			return f.Total
+		}
+		return 0
+	}
+	delta := func(path string) time.Duration {
+		d := total(after, path) - total(before, path)
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if di, dj := delta(paths[i]), delta(paths[j]); di != dj {
+			return di > dj
+		}
+		return paths[i] < paths[j]
+	})
+	if len(paths) > baselinePaths {
+		paths = paths[:baselinePaths]
+	}
+
+	d := Delta{Before: base.Duration, After: s.Duration}
+	fmt.Fprintf(w, `<details open class="baseline"><summary>%s vs the baseline, %s</summary><table>`, html.EscapeString(d.String()), html.EscapeString(shortID(base.TraceID)))
+	fmt.Fprint(w, `<tr><th>path</th><th class="num">baseline</th><th class="num">this</th><th class="num">change</th></tr>`)
+	for _, path := range paths {
+		b, a := total(before, path), total(after, path)
+		class := ""
+		if a > b {
+			class = ` class="slower"`
+		}
+		fmt.Fprintf(w, `<tr%s><td>%s</td><td class="num">%s</td><td class="num">%s</td><td class="num">%s</td></tr>`,
+			class, html.EscapeString(path), opts.duration(b), opts.duration(a), html.EscapeString(Delta{Before: b, After: a}.String()))
+	}
+	fmt.Fprintln(w, `</table></details>`)
+}
+
+// framePaths flattens the frames below root by "a/b/c" name path.
+func framePaths(root *Frame) map[string]*Frame {
+	paths := map[string]*Frame{}
+	var walk func(f *Frame, path []string)
+	walk = func(f *Frame, path []string) {
+		path = append(path, f.Name)
+		paths[strings.Join(path, "/")] = f
+		for _, kid := range f.Children {
+			walk(kid, path)
+		}
+	}
+	for _, kid := range root.Children {
+		walk(kid, nil)
+	}
+	return paths
+}
@@ -0,0 +1,234 @@
+package trot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+const fanout = 8
+
+var spanNames = []string{"HTTP GET", "db.query", "compute", "cache.get", "render"}
+
+// synthetic builds a single trace of n spans, each with fanout children,
+// every child taking up most of its slot in the parent's interval.
+func synthetic(n int) *Trace {
+	t := NewTrace()
+	spans := make([]*Span, n)
+	for i := range spans {
+		s := &Span{
+			Name:           spanNames[i%len(spanNames)],
+			SpanKind:       KindInternal,
+			ChildSpanCount: min(fanout, max(0, n-1-i*fanout)),
+			Attributes:     []byte(fmt.Sprintf(`[{"Key":"i","Value":{"Type":"INT64","Value":%d}}]`, i)),
+		}
+		s.SpanContext.TraceID = "00000000000000000000000000000001"
+		s.SpanContext.SpanID = fmt.Sprintf("%016x", i+1)
+		s.Parent.TraceID = s.SpanContext.TraceID
+		s.Status.Code = "Unset"
+
+		depth := 0
+		if i == 0 {
+			s.Parent.SpanID = RootID
+			s.StartTime = epoch
+			s.EndTime = epoch.Add(time.Duration(n) * time.Millisecond)
+		} else {
+			parent := spans[(i-1)/fanout]
+			s.Parent.SpanID = parent.SpanContext.SpanID
+
+			slot := parent.Duration() / fanout
+			s.StartTime = parent.StartTime.Add(time.Duration((i-1)%fanout) * slot).Add(slot / 10)
+			s.EndTime = s.StartTime.Add(slot * 8 / 10)
+
+			for p := i; p != 0; p = (p - 1) / fanout {
+				depth++
+			}
+		}
+
+		// A service per level, so there are dependency edges to find.
+		s.Resource = []KeyValue{{Key: "service.name", Value: valueOf(fmt.Sprintf("svc-%d", depth))}}
+		if depth != 0 {
+			s.SpanKind = KindServer
+		}
+
+		spans[i] = s
+		t.Add(s)
+	}
+	return t
+}
+
+var (
+	benchMu     sync.Mutex
+	benchTraces = map[int]*Trace{}
+	benchInputs = map[int][]byte{}
+)
+
+// benchTrace caches synthetic traces and their stdouttrace encoding, since
+// building the 1M span one takes a while.
+func benchTrace(b *testing.B, n int) (*Trace, []byte) {
+	b.Helper()
+
+	benchMu.Lock()
+	defer benchMu.Unlock()
+
+	if _, ok := benchTraces[n]; !ok {
+		t := synthetic(n)
+		var buf bytes.Buffer
+		if err := EncodeStdouttrace(&buf, t); err != nil {
+			b.Fatal(err)
+		}
+		benchTraces[n], benchInputs[n] = t, buf.Bytes()
+	}
+	return benchTraces[n], benchInputs[n]
+}
+
+var benchSizes = []int{1_000, 100_000, 1_000_000}
+
+func bench(b *testing.B, fn func(b *testing.B, t *Trace, input []byte)) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("spans=%d", n), func(b *testing.B) {
+			if n > 100_000 && testing.Short() {
+				b.Skip("skipping 1M spans in short mode")
+			}
+			t, input := benchTrace(b, n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			fn(b, t, input)
+		})
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	bench(b, func(b *testing.B, _ *Trace, input []byte) {
+		b.SetBytes(int64(len(input)))
+		for i := 0; i < b.N; i++ {
+			if _, err := Parse(bytes.NewReader(input)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkTree(b *testing.B) {
+	bench(b, func(b *testing.B, t *Trace, _ []byte) {
+		for i := 0; i < b.N; i++ {
+			t.Tree("root", RootID)
+		}
+	})
+}
+
+func BenchmarkRenderHTML(b *testing.B) {
+	bench(b, func(b *testing.B, t *Trace, _ []byte) {
+		for i := 0; i < b.N; i++ {
+			if err := RenderHTML(io.Discard, t, Options{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkRenderHTMLCompact(b *testing.B) {
+	bench(b, func(b *testing.B, t *Trace, _ []byte) {
+		for i := 0; i < b.N; i++ {
+			if err := RenderHTML(io.Discard, t, Options{Compact: true}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkRenderFlame(b *testing.B) {
+	bench(b, func(b *testing.B, t *Trace, _ []byte) {
+		for i := 0; i < b.N; i++ {
+			if err := RenderFlame(io.Discard, t.Flame(), Options{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkRenderDOT(b *testing.B) {
+	bench(b, func(b *testing.B, t *Trace, _ []byte) {
+		for i := 0; i < b.N; i++ {
+			if err := RenderDOT(io.Discard, t.Deps(), Options{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkRenderDepsHTML(b *testing.B) {
+	bench(b, func(b *testing.B, t *Trace, _ []byte) {
+		for i := 0; i < b.N; i++ {
+			if err := RenderDepsHTML(io.Discard, t.Deps(), Options{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkEncodeOTLP(b *testing.B) {
+	bench(b, func(b *testing.B, t *Trace, _ []byte) {
+		for i := 0; i < b.N; i++ {
+			if err := EncodeOTLP(io.Discard, t); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// TestAllocs catches changes that make the hot paths allocate much more per
+// span. The budgets have some headroom; tighten them when things improve.
+func TestAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	const n = 1000
+
+	tr := synthetic(n)
+	var input bytes.Buffer
+	if err := EncodeStdouttrace(&input, tr); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		budget float64 // allocations per span
+		fn     func()
+	}{{
+		name:   "Parse",
+		budget: 16,
+		fn: func() {
+			Parse(bytes.NewReader(input.Bytes()))
+		},
+	}, {
+		name:   "Tree",
+		budget: 2,
+		fn: func() {
+			tr.Tree("root", RootID)
+		},
+	}, {
+		name:   "RenderHTML",
+		budget: 8,
+		fn: func() {
+			RenderHTML(io.Discard, tr, Options{})
+		},
+	}, {
+		name:   "RenderHTMLCompact",
+		budget: 8,
+		fn: func() {
+			RenderHTML(io.Discard, tr, Options{Compact: true})
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := testing.AllocsPerRun(5, tc.fn) / n
+			t.Logf("%.1f allocs/span", got)
+			if got > tc.budget {
+				t.Errorf("%.1f allocs/span, want <= %.1f", got, tc.budget)
+			}
+		})
+	}
+}
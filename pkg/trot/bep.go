@@ -0,0 +1,242 @@
+package trot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bep is Bazel's Build Event Protocol, as written by
+// --build_event_json_file: a span for the command, with one per target
+// holding its actions and test attempts. BEP only times actions when Bazel
+// runs with --build_event_publish_all_actions (otherwise just failed ones).
+type bep struct{}
+
+func (bep) Name() string {
+	return "bep"
+}
+
+func (bep) Sniff(peek []byte) bool {
+	return bytes.Contains(peek, []byte(`"buildToolVersion"`))
+}
+
+type bepEvent struct {
+	ID struct {
+		ActionCompleted *struct {
+			PrimaryOutput string `json:"primaryOutput"`
+			Label         string `json:"label"`
+		} `json:"actionCompleted"`
+		TargetCompleted *struct {
+			Label string `json:"label"`
+		} `json:"targetCompleted"`
+		TestResult *struct {
+			Label   string `json:"label"`
+			Run     int    `json:"run"`
+			Shard   int    `json:"shard"`
+			Attempt int    `json:"attempt"`
+		} `json:"testResult"`
+	} `json:"id"`
+
+	Started *struct {
+		UUID             string    `json:"uuid"`
+		StartTimeMillis  string    `json:"startTimeMillis"`
+		StartTime        time.Time `json:"startTime"`
+		BuildToolVersion string    `json:"buildToolVersion"`
+		Command          string    `json:"command"`
+	} `json:"started"`
+	Finished *struct {
+		OverallSuccess   bool      `json:"overallSuccess"`
+		FinishTimeMillis string    `json:"finishTimeMillis"`
+		FinishTime       time.Time `json:"finishTime"`
+		ExitCode         struct {
+			Name string `json:"name"`
+		} `json:"exitCode"`
+	} `json:"finished"`
+	Action *struct {
+		Success   bool      `json:"success"`
+		Type      string    `json:"type"`
+		StartTime time.Time `json:"startTime"`
+		EndTime   time.Time `json:"endTime"`
+		Failure   struct {
+			Message string `json:"message"`
+		} `json:"failureDetail"`
+	} `json:"action"`
+	Completed *struct {
+		Success bool `json:"success"`
+	} `json:"completed"`
+	TestResult *struct {
+		Status                      string    `json:"status"`
+		TestAttemptStart            time.Time `json:"testAttemptStart"`
+		TestAttemptStartMillisEpoch string    `json:"testAttemptStartMillisEpoch"`
+		TestAttemptDuration         string    `json:"testAttemptDuration"`
+		TestAttemptDurationMillis   string    `json:"testAttemptDurationMillis"`
+	} `json:"testResult"`
+}
+
+func (bep) Decode(r io.Reader, t *Trace) error {
+	br := bufio.NewReader(r)
+	peek, _ := br.Peek(64)
+	if peek = bytes.TrimLeft(peek, " \t\r\n"); len(peek) != 0 && peek[0] != '{' {
+		return fmt.Errorf("only JSON build events are supported, from --build_event_json_file")
+	}
+
+	root := &Span{SpanKind: KindInternal}
+	root.Parent.SpanID = RootID
+	root.Status.Code = "Unset"
+	root.Resource = []KeyValue{keyValue("service.name", "bazel")}
+
+	targets := map[string]*Span{}
+	succeeded := map[string]bool{}
+	children := []*Span{}
+	// target finds or makes the span for label, which covers its children.
+	target := func(label string, child *Span) {
+		span, ok := targets[label]
+		if !ok {
+			span = &Span{Name: label, SpanKind: KindInternal, StartTime: child.StartTime, EndTime: child.EndTime}
+			span.SpanContext.SpanID = stableID("target " + label)
+			span.Status.Code = "Unset"
+			span.Resource = root.Resource
+			targets[label] = span
+		}
+		if child.StartTime.Before(span.StartTime) {
+			span.StartTime = child.StartTime
+		}
+		if child.EndTime.After(span.EndTime) {
+			span.EndTime = child.EndTime
+		}
+		if child.IsError() {
+			span.Status.Code = "Error"
+		}
+		child.Parent.SpanID = span.SpanContext.SpanID
+		child.Resource = root.Resource
+		children = append(children, child)
+	}
+
+	dec := json.NewDecoder(br)
+	for i := 1; ; i++ {
+		var e bepEvent
+		start := dec.InputOffset()
+		if err := dec.Decode(&e); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("event %d: %w", i, valueOffset(start, err))
+		}
+
+		switch {
+		case e.Started != nil:
+			s := e.Started
+			root.Name = "bazel " + s.Command
+			root.StartTime = bepTime(s.StartTime, s.StartTimeMillis)
+			root.SpanContext.TraceID = strings.ReplaceAll(s.UUID, "-", "")
+			root.SpanContext.SpanID = stableID("command " + s.UUID)
+			root.Attributes = rawJSON([]KeyValue{keyValue("bazel.version", s.BuildToolVersion), keyValue("bazel.invocation_id", s.UUID)})
+		case e.Finished != nil:
+			f := e.Finished
+			root.EndTime = bepTime(f.FinishTime, f.FinishTimeMillis)
+			if !f.OverallSuccess {
+				root.Status.Code = "Error"
+				root.Status.Description = f.ExitCode.Name
+			}
+		case e.Action != nil && e.ID.ActionCompleted != nil:
+			a, id := e.Action, e.ID.ActionCompleted
+			if a.StartTime.IsZero() {
+				continue
+			}
+			span := &Span{
+				Name:      strings.TrimSpace(a.Type + " " + path.Base(id.PrimaryOutput)),
+				SpanKind:  KindInternal,
+				StartTime: a.StartTime,
+				EndTime:   a.EndTime,
+			}
+			span.SpanContext.SpanID = stableID("action " + id.PrimaryOutput)
+			span.Status.Code = "Unset"
+			if !a.Success {
+				span.Status.Code = "Error"
+				span.Status.Description = a.Failure.Message
+			}
+			span.Attributes = rawJSON([]KeyValue{keyValue("bazel.mnemonic", a.Type), keyValue("bazel.primary_output", id.PrimaryOutput)})
+			target(id.Label, span)
+		case e.TestResult != nil && e.ID.TestResult != nil:
+			tr, id := e.TestResult, e.ID.TestResult
+			start := bepTime(tr.TestAttemptStart, tr.TestAttemptStartMillisEpoch)
+			if start.IsZero() {
+				continue
+			}
+			span := &Span{
+				Name:      fmt.Sprintf("test run %d shard %d attempt %d", id.Run, id.Shard, id.Attempt),
+				SpanKind:  KindInternal,
+				StartTime: start,
+				EndTime:   start.Add(bepDuration(tr.TestAttemptDuration, tr.TestAttemptDurationMillis)),
+			}
+			span.SpanContext.SpanID = stableID(fmt.Sprintf("test %s %d %d %d", id.Label, id.Run, id.Shard, id.Attempt))
+			span.Status.Code = "Unset"
+			if tr.Status != "PASSED" {
+				span.Status.Code = "Error"
+				span.Status.Description = tr.Status
+			}
+			span.Attributes = rawJSON([]KeyValue{keyValue("bazel.test_status", tr.Status)})
+			target(id.Label, span)
+		case e.Completed != nil && e.ID.TargetCompleted != nil:
+			succeeded[e.ID.TargetCompleted.Label] = e.Completed.Success
+		}
+	}
+
+	if root.SpanContext.TraceID == "" {
+		return fmt.Errorf("no started event")
+	}
+	if root.EndTime.IsZero() {
+		// The build was cut short; end it with the last thing that happened.
+		root.EndTime = root.StartTime
+		for _, span := range children {
+			if span.EndTime.After(root.EndTime) {
+				root.EndTime = span.EndTime
+			}
+		}
+	}
+
+	tid := root.SpanContext.TraceID
+	t.Add(root)
+	for label, span := range targets {
+		if ok, done := succeeded[label]; done && !ok {
+			span.Status.Code = "Error"
+		}
+		span.SpanContext.TraceID, span.Parent.TraceID = tid, tid
+		span.Parent.SpanID = root.SpanContext.SpanID
+		t.Add(span)
+	}
+	for _, span := range children {
+		span.SpanContext.TraceID, span.Parent.TraceID = tid, tid
+		t.Add(span)
+	}
+	return nil
+}
+
+// bepTime prefers ts, falling back to millis since the epoch.
+func bepTime(ts time.Time, millis string) time.Time {
+	if !ts.IsZero() {
+		return ts
+	}
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms).UTC()
+}
+
+// bepDuration parses a protobuf JSON Duration like "1.5s", falling back to
+// millis.
+func bepDuration(d, millis string) time.Duration {
+	if dur, err := time.ParseDuration(d); err == nil {
+		return dur
+	}
+	ms, _ := strconv.ParseInt(millis, 10, 64)
+	return time.Duration(ms) * time.Millisecond
+}
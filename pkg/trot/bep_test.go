@@ -0,0 +1,38 @@
+package trot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBEP(t *testing.T) {
+	in := `
+{"id":{"started":{}},"children":[{"progress":{}}],"started":{"uuid":"0b7e6a6c-1a2b-4c3d-9e8f-001122334455","startTimeMillis":"1704067200000","buildToolVersion":"7.0.0","command":"test"}}
+{"id":{"actionCompleted":{"primaryOutput":"bazel-out/k8-fastbuild/bin/app/app.a","label":"//app:app","configuration":{"id":"abc"}}},"action":{"success":true,"type":"GoCompilePkg","startTime":"2024-01-01T00:00:01Z","endTime":"2024-01-01T00:00:04Z"}}
+{"id":{"actionCompleted":{"primaryOutput":"bazel-out/k8-fastbuild/bin/app/app_test.a","label":"//app:app_test"}},"action":{"success":false,"type":"GoCompilePkg","startTime":"2024-01-01T00:00:04Z","endTime":"2024-01-01T00:00:05Z","failureDetail":{"message":"compile failed"}}}
+{"id":{"testResult":{"label":"//app:other_test","run":1,"shard":1,"attempt":1}},"testResult":{"status":"PASSED","testAttemptStart":"2024-01-01T00:00:05Z","testAttemptDuration":"2.5s"}}
+{"id":{"targetCompleted":{"label":"//app:app"}},"completed":{"success":true}}
+{"id":{"buildFinished":{}},"finished":{"overallSuccess":false,"finishTimeMillis":"1704067210000","exitCode":{"name":"BUILD_FAILURE","code":1}}}
+`
+	tr, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := tr.Tree("root", RootID).Children
+	if len(root) != 1 || root[0].Span.Name != "bazel test" {
+		t.Fatalf("roots = %s, want bazel test", names(root))
+	}
+	if got, want := root[0].Span.Duration().Seconds(), 10.0; got != want {
+		t.Errorf("command took %vs, want %vs", got, want)
+	}
+	if got, want := names(root[0].Children), "//app:app,//app:app_test,//app:other_test"; got != want {
+		t.Errorf("targets = %s, want %s", got, want)
+	}
+	if failed := root[0].Children[1]; !failed.Span.IsError() {
+		t.Errorf("%s has a failed action but isn't an error", failed.Span.Name)
+	}
+	if _, err := ParseFormat(strings.NewReader("\x0a\x05junk"), "bep"); err == nil {
+		t.Errorf("binary build events didn't fail")
+	}
+}
@@ -0,0 +1,76 @@
+package trot
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"time"
+)
+
+// Breakdown is how many spans had each value of an attribute, and how much
+// time they took, busiest first.
+type Breakdown struct {
+	Key    string
+	Values []BreakdownValue
+}
+
+// BreakdownValue is one value's share of a Breakdown.
+type BreakdownValue struct {
+	Value string
+	Count int
+	Time  time.Duration
+}
+
+// Breakdown groups t's spans by their value of the attribute key, leaving out
+// spans without it.
+func (t *Trace) Breakdown(key string) Breakdown {
+	byValue := map[string]*BreakdownValue{}
+	for _, span := range t.Spans {
+		v, ok := span.Attr(key)
+		if !ok {
+			continue
+		}
+		bv, ok := byValue[v]
+		if !ok {
+			bv = &BreakdownValue{Value: v}
+			byValue[v] = bv
+		}
+		bv.Count++
+		bv.Time += span.Duration()
+	}
+
+	b := Breakdown{Key: key}
+	for _, bv := range byValue {
+		b.Values = append(b.Values, *bv)
+	}
+	sort.Slice(b.Values, func(i, j int) bool {
+		if b.Values[i].Time != b.Values[j].Time {
+			return b.Values[i].Time > b.Values[j].Time
+		}
+		return b.Values[i].Value < b.Values[j].Value
+	})
+	return b
+}
+
+func writeBreakdowns(w io.Writer, t *Trace, opts Options) {
+	for _, key := range opts.Breakdown {
+		writeBreakdown(w, t.Breakdown(key), opts)
+	}
+}
+
+func writeBreakdown(w io.Writer, b Breakdown, opts Options) {
+	if len(b.Values) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, `<details open class="breakdown"><summary>by %s</summary><table>`, html.EscapeString(b.Key))
+	fmt.Fprint(w, `<tr><th>value</th><th class="num">spans</th><th class="num">time</th><th></th></tr>`)
+	// Values are sorted, so the first took the most time.
+	most := max(b.Values[0].Time, 1)
+	for _, v := range b.Values {
+		fmt.Fprintf(w, `<tr><td>%s</td><td class="num">%d</td><td class="num">%s</td><td><svg viewBox="0 0 1 1" preserveAspectRatio="none"><rect width="%.3f" height="1"/></svg></td></tr>`,
+			html.EscapeString(v.Value), v.Count, opts.duration(v.Time), float64(v.Time)/float64(most))
+	}
+	fmt.Fprintln(w, `</table></details>`)
+}
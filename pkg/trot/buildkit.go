@@ -0,0 +1,207 @@
+package trot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// buildkit is the progress stream from docker buildx build --progress=rawjson
+// (or buildctl build --progress=rawjson): a span per build step, grouped by
+// Dockerfile stage, with layer pulls and the like beneath them.
+type buildkit struct{}
+
+func (buildkit) Name() string {
+	return "buildkit"
+}
+
+func (buildkit) Sniff(peek []byte) bool {
+	return bytes.Contains(peek, []byte(`"vertexes"`))
+}
+
+type solveStatus struct {
+	Vertexes []struct {
+		Digest    string     `json:"digest"`
+		Name      string     `json:"name"`
+		Started   *time.Time `json:"started"`
+		Completed *time.Time `json:"completed"`
+		Cached    bool       `json:"cached"`
+		Error     string     `json:"error"`
+	} `json:"vertexes"`
+	Statuses []struct {
+		ID        string     `json:"id"`
+		Vertex    string     `json:"vertex"`
+		Name      string     `json:"name"`
+		Total     int64      `json:"total"`
+		Started   *time.Time `json:"started"`
+		Completed *time.Time `json:"completed"`
+	} `json:"statuses"`
+	Logs []struct {
+		Vertex    string    `json:"vertex"`
+		Data      []byte    `json:"data"`
+		Timestamp time.Time `json:"timestamp"`
+	} `json:"logs"`
+}
+
+// buildStep matches the stage and step that Dockerfile vertex names start
+// with, like "[build 2/5] RUN go build". Unnamed stages look like "[2/5]".
+var buildStep = regexp.MustCompile(`^\[(?:(\S+) )?\d+/\d+\] `)
+
+func (buildkit) Decode(r io.Reader, t *Trace) error {
+	vertexes := map[string]*Span{}
+	order := []*Span{}
+	statuses := map[string]*Span{}
+	logs := map[string][]Event{}
+
+	// get finds or begins the span for a vertex or status.
+	get := func(m map[string]*Span, id string) *Span {
+		span, ok := m[id]
+		if !ok {
+			span = &Span{SpanKind: KindInternal}
+			span.SpanContext.SpanID = stableID("buildkit " + id)
+			span.Status.Code = "Unset"
+			span.Resource = []KeyValue{keyValue("service.name", "buildkit")}
+			m[id] = span
+			order = append(order, span)
+		}
+		return span
+	}
+
+	dec := json.NewDecoder(r)
+	for i := 1; ; i++ {
+		var status solveStatus
+		start := dec.InputOffset()
+		if err := dec.Decode(&status); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("status %d: %w", i, valueOffset(start, err))
+		}
+
+		for _, v := range status.Vertexes {
+			span := get(vertexes, v.Digest)
+			span.Name = v.Name
+			if v.Started != nil && (span.StartTime.IsZero() || v.Started.Before(span.StartTime)) {
+				span.StartTime = *v.Started
+			}
+			if v.Completed != nil {
+				span.EndTime = *v.Completed
+			}
+			attrs := []KeyValue{keyValue("buildkit.vertex", v.Digest)}
+			if v.Cached {
+				attrs = append(attrs, keyValue("buildkit.cached", true))
+			}
+			span.Attributes = rawJSON(attrs)
+			if v.Error != "" {
+				span.Status.Code = "Error"
+				span.Status.Description = v.Error
+			}
+		}
+		for _, s := range status.Statuses {
+			span := get(statuses, s.Vertex+" "+s.ID)
+			span.Name = s.ID
+			if s.Name != "" {
+				span.Name = s.Name + " " + s.ID
+			}
+			span.Parent.SpanID = stableID("buildkit " + s.Vertex)
+			if s.Started != nil && (span.StartTime.IsZero() || s.Started.Before(span.StartTime)) {
+				span.StartTime = *s.Started
+			}
+			if s.Completed != nil {
+				span.EndTime = *s.Completed
+			}
+			if s.Total != 0 {
+				span.Attributes = rawJSON([]KeyValue{keyValue("buildkit.total_bytes", s.Total)})
+			}
+		}
+		for _, l := range status.Logs {
+			logs[l.Vertex] = append(logs[l.Vertex], Event{
+				Name:       "log",
+				Time:       l.Timestamp,
+				Attributes: []KeyValue{keyValue("message", strings.TrimRight(string(l.Data), "\n"))},
+			})
+		}
+	}
+
+	// Vertexes that never started were never needed.
+	started := order[:0]
+	for _, span := range order {
+		if !span.StartTime.IsZero() {
+			started = append(started, span)
+		}
+	}
+	if len(started) == 0 {
+		return nil
+	}
+	sort.SliceStable(started, func(i, j int) bool {
+		return started[i].StartTime.Before(started[j].StartTime)
+	})
+
+	root := &Span{Name: "build", SpanKind: KindInternal, StartTime: started[0].StartTime}
+	root.SpanContext.SpanID = stableID("buildkit build " + started[0].SpanContext.SpanID)
+	root.SpanContext.TraceID = strings.Repeat(root.SpanContext.SpanID, 2)
+	root.Parent.SpanID = RootID
+	root.Status.Code = "Unset"
+	root.Resource = started[0].Resource
+
+	stages := map[string]*Span{}
+	for _, span := range started {
+		if span.EndTime.IsZero() {
+			// Interrupted; call it done when the build stopped hearing about it.
+			span.EndTime = span.StartTime
+		}
+		if span.EndTime.After(root.EndTime) {
+			root.EndTime = span.EndTime
+		}
+		if span.IsError() {
+			root.Status.Code = "Error"
+		}
+		span.SpanContext.TraceID = root.SpanContext.TraceID
+		span.Parent.TraceID = root.SpanContext.TraceID
+		if span.Parent.SpanID != "" {
+			// A status, which belongs to its vertex.
+			continue
+		}
+
+		span.Parent.SpanID = root.SpanContext.SpanID
+		if m := buildStep.FindStringSubmatch(span.Name); m != nil && m[1] != "" {
+			stage, ok := stages[m[1]]
+			if !ok {
+				stage = &Span{Name: "stage " + m[1], SpanKind: KindInternal, StartTime: span.StartTime, EndTime: span.EndTime}
+				stage.SpanContext.TraceID = root.SpanContext.TraceID
+				stage.SpanContext.SpanID = stableID("buildkit stage " + m[1])
+				stage.Parent.TraceID, stage.Parent.SpanID = root.SpanContext.TraceID, root.SpanContext.SpanID
+				stage.Status.Code = "Unset"
+				stage.Resource = root.Resource
+				stages[m[1]] = stage
+				t.Add(stage)
+			}
+			if span.EndTime.After(stage.EndTime) {
+				stage.EndTime = span.EndTime
+			}
+			if span.IsError() {
+				stage.Status.Code = "Error"
+			}
+			span.Parent.SpanID = stage.SpanContext.SpanID
+		}
+	}
+
+	for digest, events := range logs {
+		// Logs are noise unless the step failed.
+		if span, ok := vertexes[digest]; ok && span.IsError() {
+			span.Events = rawJSON(events)
+		}
+	}
+
+	t.Add(root)
+	for _, span := range started {
+		t.Add(span)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package trot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildKit(t *testing.T) {
+	in := `
+{"vertexes":[{"digest":"sha256:a","name":"[internal] load build definition from Dockerfile","started":"2024-01-01T00:00:00Z","completed":"2024-01-01T00:00:01Z"}]}
+{"vertexes":[{"digest":"sha256:b","inputs":["sha256:a"],"name":"[build 1/2] FROM docker.io/library/golang","started":"2024-01-01T00:00:01Z"}],"statuses":[{"id":"sha256:layer","vertex":"sha256:b","name":"extracting","total":2048,"started":"2024-01-01T00:00:01Z","completed":"2024-01-01T00:00:02Z"}]}
+{"vertexes":[{"digest":"sha256:b","inputs":["sha256:a"],"name":"[build 1/2] FROM docker.io/library/golang","started":"2024-01-01T00:00:01Z","completed":"2024-01-01T00:00:03Z","cached":true}]}
+{"vertexes":[{"digest":"sha256:c","inputs":["sha256:b"],"name":"[build 2/2] RUN go build","started":"2024-01-01T00:00:03Z"}],"logs":[{"vertex":"sha256:c","stream":2,"data":"bm8gR28gZmlsZXM=","timestamp":"2024-01-01T00:00:04Z"}]}
+{"vertexes":[{"digest":"sha256:c","inputs":["sha256:b"],"name":"[build 2/2] RUN go build","started":"2024-01-01T00:00:03Z","completed":"2024-01-01T00:00:05Z","error":"exit code: 1"}]}
+{"vertexes":[{"digest":"sha256:d","inputs":["sha256:c"],"name":"exporting to image"}]}
+`
+	tr, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := tr.Tree("root", RootID).Children
+	if len(root) != 1 || root[0].Span.Name != "build" {
+		t.Fatalf("roots = %s, want build", names(root))
+	}
+	if got, want := root[0].Span.Duration().Seconds(), 5.0; got != want {
+		t.Errorf("build took %vs, want %vs", got, want)
+	}
+	if got, want := names(root[0].Children), "[internal] load build definition from Dockerfile,stage build"; got != want {
+		t.Fatalf("children = %s, want %s", got, want)
+	}
+	stage := root[0].Children[1]
+	if got, want := names(stage.Children), "[build 1/2] FROM docker.io/library/golang,[build 2/2] RUN go build"; got != want {
+		t.Fatalf("steps = %s, want %s", got, want)
+	}
+	if !stage.Span.IsError() {
+		t.Errorf("stage with a failed step isn't an error")
+	}
+
+	from, run := stage.Children[0], stage.Children[1]
+	if got := from.Span.Summary(); got != "CACHED" {
+		t.Errorf("FROM summary = %q, want CACHED", got)
+	}
+	if got, want := names(from.Children), "extracting sha256:layer"; got != want {
+		t.Errorf("FROM children = %s, want %s", got, want)
+	}
+	if got := from.Children[0].Span.Summary(); got != "2.0 KB" {
+		t.Errorf("layer summary = %q, want 2.0 KB", got)
+	}
+	if events := run.Span.DecodeEvents(); len(events) != 1 || events[0].Attributes[0].Value.String() != "no Go files" {
+		t.Errorf("failed step logs = %v, want no Go files", events)
+	}
+}
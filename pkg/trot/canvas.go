@@ -0,0 +1,247 @@
+package trot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// canvasSpan is a span as RenderCanvas's script draws it: the row it's on,
+// its start and duration in ns from the start of the page, and indexes into
+// the page's strings for its label, service, and tooltip summary.
+type canvasSpan [7]int64
+
+// RenderCanvas writes t as a flame chart drawn on a <canvas>, with time
+// across and depth down, which stays responsive with far more spans than
+// RenderHTML's one element per span. Hovering a span describes it, the
+// wheel zooms, dragging pans, and double-clicking resets.
+func RenderCanvas(w io.Writer, t *Trace, opts Options) error {
+	if opts.NoScript {
+		return fmt.Errorf("the canvas view needs a script")
+	}
+
+	if opts.Title == "" {
+		opts.Title = rootName(t)
+	}
+
+	strs := []string{}
+	index := map[string]int64{}
+	str := func(s string) int64 {
+		i, ok := index[s]
+		if !ok {
+			i = int64(len(strs))
+			index[s] = i
+			strs = append(strs, s)
+		}
+		return i
+	}
+	str("")
+
+	roots := t.Roots()
+	spans := []canvasSpan{}
+	if len(roots) == 0 {
+		return writeCanvas(w, opts, strs, spans, 0)
+	}
+	start, end := roots[0].Span.StartTime, roots[0].Span.EndTime
+	for _, root := range roots {
+		if root.Span.EndTime.After(end) {
+			end = root.Span.EndTime
+		}
+	}
+
+	var namer *namer
+	if opts.Name != nil {
+		namer = newNamer(opts.Name)
+	}
+	add := func(n *Node, row int) {
+		name := n.Span.Name
+		if namer != nil {
+			name = namer.name(n.Span)
+		}
+		errored := int64(0)
+		if n.Span.IsError() {
+			errored = 1
+		}
+		spans = append(spans, canvasSpan{
+			int64(row),
+			int64(n.Span.StartTime.Sub(start)),
+			int64(n.Span.Duration()),
+			str(name),
+			str(n.Span.Service()),
+			str(n.Span.Summary()),
+			errored,
+		})
+	}
+
+	// Each root's tree goes below the one before it.
+	row := 0
+	for _, root := range roots {
+		row += layout(root, row, add)
+	}
+	return writeCanvas(w, opts, strs, spans, int64(end.Sub(start)))
+}
+
+// layout places n on row and its children below it, and returns how many
+// rows they take. Children that overlap in time can't share a row, so they
+// go in tracks, each as tall as its tallest child, one after another.
+func layout(n *Node, row int, add func(*Node, int)) int {
+	add(n, row)
+
+	type track struct {
+		end  int64
+		kids []*Node
+	}
+	tracks := []*track{}
+	for _, kid := range n.Children {
+		var fit *track
+		for _, tr := range tracks {
+			if tr.end <= kid.Span.StartTime.UnixNano() {
+				fit = tr
+				break
+			}
+		}
+		if fit == nil {
+			fit = &track{}
+			tracks = append(tracks, fit)
+		}
+		fit.kids = append(fit.kids, kid)
+		fit.end = kid.Span.EndTime.UnixNano()
+	}
+
+	height := 1
+	for _, tr := range tracks {
+		tall := 0
+		for _, kid := range tr.kids {
+			tall = max(tall, layout(kid, row+height, add))
+		}
+		height += tall
+	}
+	return height
+}
+
+func writeCanvas(w io.Writer, opts Options, strs []string, spans []canvasSpan, total int64) error {
+	// Marshal escapes <, so this can't end the script early.
+	b, err := json.Marshal(map[string]any{
+		"strings": strs,
+		"spans":   spans,
+		"total":   total,
+	})
+	if err != nil {
+		return err
+	}
+
+	writeHeader(w, opts)
+	nonce := opts.nonce()
+	fmt.Fprintf(w, `<script type="application/json" id="trot-canvas"%s>%s</script>`, nonce, b)
+	fmt.Fprint(w, strings.NewReplacer("<script>", "<script"+nonce+">", "<style>", "<style"+nonce+">").Replace(canvasBody))
+	writeFooter(w)
+	return nil
+}
+
+const canvasBody = `
+<div class="flamechart"><canvas></canvas><div class="tip" hidden></div></div>
+<script>
+(() => {
+  const data = JSON.parse(document.getElementById('trot-canvas').textContent);
+  const S = data.strings, rowH = 18;
+  const rows = [];
+  for (const s of data.spans) (rows[s[0]] ||= []).push(s);
+  for (const row of rows) if (row) row.sort((a, b) => a[1] - b[1]);
+  const box = document.querySelector('div.flamechart');
+  const canvas = box.querySelector('canvas'), tip = box.querySelector('div.tip');
+  const ctx = canvas.getContext('2d');
+  let v0 = 0, v1 = Math.max(data.total, 1);
+
+  const color = (s) => {
+    if (s[6]) return '#e66';
+    let h = 0;
+    for (const c of S[s[4]]) h = (h * 31 + c.charCodeAt(0)) % 360;
+    return 'hsl(' + h + ', 60%, 75%)';
+  };
+  const fmt = (ns) => {
+    for (const [u, d] of [['s', 1e9], ['ms', 1e6], ['µs', 1e3]]) if (Math.abs(ns) >= d) return +(ns / d).toPrecision(3) + u;
+    return ns + 'ns';
+  };
+
+  function draw() {
+    const dpr = window.devicePixelRatio || 1, W = box.clientWidth;
+    canvas.style.width = W + 'px';
+    canvas.style.height = rows.length * rowH + 'px';
+    canvas.width = W * dpr;
+    canvas.height = rows.length * rowH * dpr;
+    ctx.scale(dpr, dpr);
+    ctx.font = '12px sans-serif';
+    ctx.textBaseline = 'middle';
+    const k = W / (v1 - v0);
+    rows.forEach((row, r) => {
+      for (const s of row) {
+        const x0 = (s[1] - v0) * k, x1 = (s[1] + s[2] - v0) * k;
+        if (x1 < 0 || x0 > W) continue;
+        const x = Math.max(x0, 0), w = Math.max(Math.min(x1, W) - x, 0.5);
+        ctx.fillStyle = color(s);
+        ctx.fillRect(x, r * rowH, w, rowH - 1);
+        if (w > 30) {
+          ctx.save();
+          ctx.beginPath();
+          ctx.rect(x, r * rowH, w, rowH);
+          ctx.clip();
+          ctx.fillStyle = '#000';
+          ctx.fillText(S[s[3]] + ' ' + fmt(s[2]), x + 3, r * rowH + rowH / 2);
+          ctx.restore();
+        }
+      }
+    });
+  }
+
+  // at finds the span under the pointer: rows are in start order and spans
+  // on a row don't overlap, so search for the last that starts before t.
+  function at(e) {
+    const rect = canvas.getBoundingClientRect();
+    const row = rows[Math.floor((e.clientY - rect.top) / rowH)];
+    if (!row) return null;
+    const t = v0 + (e.clientX - rect.left) / rect.width * (v1 - v0);
+    let lo = 0, hi = row.length - 1, found = null;
+    while (lo <= hi) {
+      const mid = (lo + hi) >> 1;
+      if (row[mid][1] <= t) { found = row[mid]; lo = mid + 1; } else hi = mid - 1;
+    }
+    return found && t <= found[1] + found[2] ? found : null;
+  }
+
+  let drag = null;
+  canvas.addEventListener('mousemove', (e) => {
+    if (drag) {
+      const dt = (drag.x - e.clientX) / canvas.clientWidth * (v1 - v0);
+      v0 = drag.v0 + dt; v1 = drag.v1 + dt;
+      draw();
+      return;
+    }
+    const s = at(e);
+    tip.hidden = !s;
+    if (!s) return;
+    tip.textContent = S[s[3]] + ' (' + S[s[4]] + ') ' + fmt(s[2]) + ' at +' + fmt(s[1]) + (s[5] ? ' ' + S[s[5]] : '');
+    tip.style.left = e.offsetX + 12 + 'px';
+    tip.style.top = e.offsetY + 12 + 'px';
+  });
+  canvas.addEventListener('mouseleave', () => { tip.hidden = true; drag = null; });
+  canvas.addEventListener('mousedown', (e) => { drag = {x: e.clientX, v0, v1}; });
+  window.addEventListener('mouseup', () => { drag = null; });
+  canvas.addEventListener('wheel', (e) => {
+    e.preventDefault();
+    const f = (e.clientX - canvas.getBoundingClientRect().left) / canvas.clientWidth;
+    const t = v0 + f * (v1 - v0), scale = Math.exp(e.deltaY / 300);
+    const span = Math.max((v1 - v0) * scale, 1000);
+    v0 = t - f * span; v1 = t + (1 - f) * span;
+    draw();
+  }, {passive: false});
+  canvas.addEventListener('dblclick', () => { v0 = 0; v1 = Math.max(data.total, 1); draw(); });
+  window.addEventListener('resize', draw);
+  draw();
+})();
+</script>
+<style>
+div.flamechart { position: relative; }
+div.flamechart canvas { display: block; cursor: crosshair; }
+div.flamechart div.tip { position: absolute; pointer-events: none; background: #ffe; color: #000; border: 1px solid #999; padding: 2px 4px; white-space: nowrap; }
+</style>`
@@ -0,0 +1,337 @@
+package trot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSV reads delimited timing data with a header row: a span per row, with
+// Columns saying which columns hold what. Columns that aren't mapped become
+// attributes.
+//
+// Times are RFC 3339, "2006-01-02 15:04:05" (UTC), or numbers since the
+// epoch in s, ms, µs, or ns, whichever is plausible. Durations are Go
+// durations like 1.5s, or numbers of DurationUnit.
+type CSV struct {
+	// Comma separates fields, ',' if zero.
+	Comma rune
+
+	Columns CSVColumns
+}
+
+// CSVColumns names the header of each column CSV understands. Only Name is
+// required; without Start, rows run back to back.
+type CSVColumns struct {
+	Name     string
+	ID       string
+	Parent   string
+	Trace    string
+	Service  string
+	Start    string
+	End      string
+	Duration string
+	Status   string
+
+	// DurationUnit is what a bare number in Duration counts, seconds if zero.
+	DurationUnit time.Duration
+}
+
+// DefaultCSVColumns are the headers CSV looks for unless told otherwise.
+var DefaultCSVColumns = CSVColumns{
+	Name:     "name",
+	ID:       "id",
+	Parent:   "parent",
+	Trace:    "trace",
+	Service:  "service",
+	Start:    "start",
+	End:      "end",
+	Duration: "duration",
+	Status:   "status",
+}
+
+// ParseCSVColumns overrides DefaultCSVColumns with a mapping like
+// "name=task,start=began_at,duration=elapsed:ms". Mapping a field to
+// nothing, as in "parent=", ignores that column.
+func ParseCSVColumns(s string) (CSVColumns, error) {
+	cols := DefaultCSVColumns
+	for _, kv := range strings.Split(s, ",") {
+		if strings.TrimSpace(kv) == "" {
+			continue
+		}
+		field, header, ok := strings.Cut(kv, "=")
+		if !ok {
+			return cols, fmt.Errorf("column %q: want field=header", kv)
+		}
+		header = strings.TrimSpace(header)
+		switch strings.TrimSpace(field) {
+		case "name":
+			cols.Name = header
+		case "id":
+			cols.ID = header
+		case "parent":
+			cols.Parent = header
+		case "trace":
+			cols.Trace = header
+		case "service":
+			cols.Service = header
+		case "start":
+			cols.Start = header
+		case "end":
+			cols.End = header
+		case "status":
+			cols.Status = header
+		case "duration":
+			header, unit, ok := strings.Cut(header, ":")
+			cols.Duration = header
+			if ok {
+				d, err := time.ParseDuration("1" + unit)
+				if err != nil {
+					return cols, fmt.Errorf("duration unit %q: %w", unit, err)
+				}
+				cols.DurationUnit = d
+			}
+		default:
+			return cols, fmt.Errorf("unknown column %q (want name, id, parent, trace, service, start, end, duration, or status)", field)
+		}
+	}
+	if cols.Name == "" {
+		return cols, fmt.Errorf("name column is required")
+	}
+	return cols, nil
+}
+
+func (c CSV) comma() rune {
+	if c.Comma == 0 {
+		return ','
+	}
+	return c.Comma
+}
+
+func (c CSV) columns() CSVColumns {
+	if c.Columns.Name == "" {
+		return DefaultCSVColumns
+	}
+	return c.Columns
+}
+
+func (c CSV) Name() string {
+	if c.comma() == '\t' {
+		return "tsv"
+	}
+	return "csv"
+}
+
+// Sniff looks for a header row with the name column and a start, end, or
+// duration column.
+func (c CSV) Sniff(peek []byte) bool {
+	line, _, _ := bytes.Cut(peek, []byte("\n"))
+	header := map[string]bool{}
+	for _, h := range strings.Split(string(bytes.TrimSpace(line)), string(c.comma())) {
+		header[strings.TrimSpace(h)] = true
+	}
+	cols := c.columns()
+	return header[cols.Name] && (header[cols.Start] || header[cols.End] || header[cols.Duration])
+}
+
+func (c CSV) Decode(r io.Reader, t *Trace) error {
+	cols := c.columns()
+	unit := cols.DurationUnit
+	if unit == 0 {
+		unit = time.Second
+	}
+
+	cr := csv.NewReader(r)
+	cr.Comma = c.comma()
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	if c.comma() == '\t' {
+		cr.LazyQuotes = true
+	}
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+	index := map[string]int{}
+	for i, h := range header {
+		index[strings.TrimSpace(h)] = i
+	}
+	col := func(header string) int {
+		if i, ok := index[header]; ok && header != "" {
+			return i
+		}
+		return -1
+	}
+	name, id, parent, trace := col(cols.Name), col(cols.ID), col(cols.Parent), col(cols.Trace)
+	service, start, end, duration, status := col(cols.Service), col(cols.Start), col(cols.End), col(cols.Duration), col(cols.Status)
+	if name < 0 {
+		return fmt.Errorf("no %q column in header %q", cols.Name, strings.Join(header, string(c.comma())))
+	}
+	if end < 0 && duration < 0 {
+		return fmt.Errorf("header %q needs a %q or %q column", strings.Join(header, string(c.comma())), cols.End, cols.Duration)
+	}
+	mapped := map[int]bool{name: true, id: true, parent: true, trace: true, service: true, start: true, end: true, duration: true, status: true}
+
+	type row struct {
+		span   *Span
+		trace  string
+		parent string
+	}
+	rows := []row{}
+	ids := map[string]string{}
+	// Without a start column, rows run back to back from the epoch. The zero
+	// Time would read as no start at all.
+	next := time.Unix(0, 0).UTC()
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		field := func(i int) string {
+			if i < 0 || i >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[i])
+		}
+		if len(rec) == 1 && field(0) == "" {
+			continue
+		}
+
+		span := &Span{Name: field(name), SpanKind: KindInternal}
+		span.Status.Code = "Unset"
+		if start >= 0 {
+			if span.StartTime, err = parseCSVTime(field(start)); err != nil {
+				return fmt.Errorf("line %d: %s: %w", line, cols.Start, err)
+			}
+		} else {
+			span.StartTime = next
+		}
+		if v := field(end); v != "" {
+			if span.EndTime, err = parseCSVTime(v); err != nil {
+				return fmt.Errorf("line %d: %s: %w", line, cols.End, err)
+			}
+		} else {
+			d, err := parseCSVDuration(field(duration), unit)
+			if err != nil {
+				return fmt.Errorf("line %d: %s: %w", line, cols.Duration, err)
+			}
+			span.EndTime = span.StartTime.Add(d)
+		}
+		next = span.EndTime
+
+		if v := field(status); csvFailed(v) {
+			span.Status.Code = "Error"
+			span.Status.Description = v
+		}
+		svc := field(service)
+		if svc == "" {
+			svc = "csv"
+		}
+		span.Resource = []KeyValue{keyValue("service.name", svc)}
+
+		attrs := []KeyValue{}
+		for i, h := range header {
+			if v := field(i); !mapped[i] && v != "" {
+				attrs = append(attrs, keyValue(strings.TrimSpace(h), v))
+			}
+		}
+		if len(attrs) != 0 {
+			span.Attributes = rawJSON(attrs)
+		}
+
+		// Without an ID column, rows are known by name, and the first one
+		// with a name is the parent of rows that name it.
+		key := field(id)
+		if id < 0 {
+			key = span.Name
+		}
+		tkey := field(trace)
+		span.SpanContext.SpanID = stableID(fmt.Sprintf("row %s %d", tkey, line))
+		if _, ok := ids[tkey+" "+key]; !ok {
+			ids[tkey+" "+key] = span.SpanContext.SpanID
+		}
+		rows = append(rows, row{span: span, trace: tkey, parent: field(parent)})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	for _, row := range rows {
+		span := row.span
+		tid := strings.Repeat(stableID(row.trace+" "+rows[0].span.Name+" "+rows[0].span.StartTime.String()), 2)
+		if row.trace != "" {
+			tid = strings.Repeat(stableID("trace "+row.trace), 2)
+		}
+		span.SpanContext.TraceID = tid
+		span.Parent.TraceID = tid
+		switch pid, ok := ids[row.trace+" "+row.parent]; {
+		case row.parent == "":
+			span.Parent.SpanID = RootID
+		case ok:
+			span.Parent.SpanID = pid
+		default:
+			// Keep the reference, so it shows up as a missing parent.
+			span.Parent.SpanID = stableID("missing " + row.parent)
+		}
+		t.Add(span)
+	}
+	return nil
+}
+
+// parseCSVTime parses the timestamps scripts and databases tend to write.
+func parseCSVTime(s string) (time.Time, error) {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		// Guess the unit from the magnitude: anything since 1973 in
+		// seconds is more than 1e8, and so on down.
+		switch a := math.Abs(f); {
+		case a >= 1e17:
+			return time.Unix(0, int64(f)).UTC(), nil
+		case a >= 1e14:
+			return time.UnixMicro(int64(f)).UTC(), nil
+		case a >= 1e11:
+			return time.UnixMilli(int64(f)).UTC(), nil
+		default:
+			sec, frac := math.Modf(f)
+			return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+		}
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
+
+func parseCSVDuration(s string, unit time.Duration) (time.Duration, error) {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(f * float64(unit)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// csvFailed reports whether a status column value means the row failed:
+// a word like error or failed, false, or a non-zero exit code.
+func csvFailed(status string) bool {
+	switch strings.ToLower(status) {
+	case "", "0", "ok", "success", "succeeded", "pass", "passed", "true", "unset":
+		return false
+	case "error", "err", "fail", "failed", "failure", "false":
+		return true
+	}
+	_, err := strconv.Atoi(status)
+	return err == nil
+}
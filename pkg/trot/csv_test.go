@@ -0,0 +1,63 @@
+package trot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSV(t *testing.T) {
+	in := `name,parent,start,end,status,host
+deploy,,2024-01-01T00:00:00Z,2024-01-01T00:00:10Z,0,a
+build,deploy,2024-01-01T00:00:00Z,2024-01-01T00:00:04Z,0,a
+push,deploy,1704067204000,1704067210000,1,b
+`
+	tr, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := tr.Tree("root", RootID).Children
+	if len(root) != 1 || root[0].Span.Name != "deploy" {
+		t.Fatalf("roots = %s, want deploy", names(root))
+	}
+	if got, want := names(root[0].Children), "build,push"; got != want {
+		t.Fatalf("children = %s, want %s", got, want)
+	}
+	push := root[0].Children[1].Span
+	if got, want := push.Duration(), 6*time.Second; got != want {
+		t.Errorf("push took %v, want %v", got, want)
+	}
+	if !push.IsError() {
+		t.Errorf("push exited 1 but isn't an error")
+	}
+	if v, _ := push.Attr("host"); v != "b" {
+		t.Errorf("host = %q, want b", v)
+	}
+
+	cols, err := ParseCSVColumns("name=step,start=,duration=elapsed:ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err = ParseFormat(strings.NewReader("step\telapsed\nfetch\t250\ncompile\t1500\n"), "tsv")
+	if err == nil {
+		t.Errorf("tsv without a name column succeeded")
+	}
+	tr = NewTrace()
+	if err := (CSV{Comma: '\t', Columns: cols}).Decode(strings.NewReader("step\telapsed\nfetch\t250\ncompile\t1500\n"), tr); err != nil {
+		t.Fatal(err)
+	}
+	root = tr.Tree("root", RootID).Children
+	if got, want := names(root), "fetch,compile"; got != want {
+		t.Fatalf("roots = %s, want %s", got, want)
+	}
+	if got, want := root[1].Span.StartTime.Sub(root[0].Span.StartTime), 250*time.Millisecond; got != want {
+		t.Errorf("compile started %v after fetch, want %v", got, want)
+	}
+	if got, want := tr.Summarize().Duration, 1750*time.Millisecond; got != want {
+		t.Errorf("Summarize().Duration = %v, want %v", got, want)
+	}
+
+	if _, err := ParseCSVColumns("nope=x"); err == nil {
+		t.Errorf("unknown column succeeded")
+	}
+}
@@ -0,0 +1,241 @@
+package trot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// datadog is Datadog APM traces: the agent's list of traces (each a list of
+// spans, with times in ns), or a trace from the API or UI, whose spans are
+// keyed by ID with times in seconds. Spans are named by their resource.
+type datadog struct{}
+
+func (datadog) Name() string {
+	return "datadog"
+}
+
+func (datadog) Sniff(peek []byte) bool {
+	return bytes.Contains(peek, []byte(`"span_id"`)) && bytes.Contains(peek, []byte(`"resource"`))
+}
+
+type datadogSpan struct {
+	TraceID  json.Number        `json:"trace_id"`
+	SpanID   json.Number        `json:"span_id"`
+	ParentID json.Number        `json:"parent_id"`
+	Name     string             `json:"name"`
+	Resource string             `json:"resource"`
+	Service  string             `json:"service"`
+	Type     string             `json:"type"`
+	Start    json.Number        `json:"start"`
+	Duration json.Number        `json:"duration"`
+	Error    int                `json:"error"`
+	Meta     map[string]string  `json:"meta"`
+	Metrics  map[string]float64 `json:"metrics"`
+}
+
+func (datadog) Decode(r io.Reader, t *Trace) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	for i := 1; ; i++ {
+		var raw json.RawMessage
+		start := dec.InputOffset()
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("document %d: %w", i, valueOffset(start, err))
+		}
+
+		spans, err := datadogSpans(raw)
+		if err != nil {
+			return fmt.Errorf("document %d: %w", i, atOffset(start, err))
+		}
+		for _, s := range spans {
+			span, err := s.span()
+			if err != nil {
+				return fmt.Errorf("document %d: span %s: %w", i, s.SpanID, err)
+			}
+			t.Add(span)
+		}
+	}
+}
+
+// datadogSpans finds the spans in any of the shapes Datadog writes them in.
+func datadogSpans(raw json.RawMessage) ([]datadogSpan, error) {
+	var spans []datadogSpan
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		var traces [][]datadogSpan
+		if err := json.Unmarshal(raw, &traces); err == nil {
+			for _, trace := range traces {
+				spans = append(spans, trace...)
+			}
+			return spans, nil
+		}
+		err := json.Unmarshal(raw, &spans)
+		return spans, err
+	}
+
+	var doc struct {
+		Trace *struct {
+			Spans map[string]datadogSpan `json:"spans"`
+		} `json:"trace"`
+		Spans json.RawMessage `json:"spans"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Trace != nil {
+		keys := make([]string, 0, len(doc.Trace.Spans))
+		for k := range doc.Trace.Spans {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			spans = append(spans, doc.Trace.Spans[k])
+		}
+		return spans, nil
+	}
+	if len(doc.Spans) == 0 {
+		return nil, fmt.Errorf("no spans")
+	}
+	return datadogSpans(doc.Spans)
+}
+
+func (s datadogSpan) span() (*Span, error) {
+	tid, err := datadogID(s.TraceID)
+	if err != nil {
+		return nil, fmt.Errorf("trace_id: %w", err)
+	}
+	sid, err := datadogID(s.SpanID)
+	if err != nil {
+		return nil, fmt.Errorf("span_id: %w", err)
+	}
+	pid, err := datadogID(s.ParentID)
+	if err != nil {
+		return nil, fmt.Errorf("parent_id: %w", err)
+	}
+	// 128-bit trace IDs keep their high half in a tag.
+	hi := s.Meta["_dd.p.tid"]
+	if len(hi) != 16 {
+		hi = strings.Repeat("0", 16)
+	}
+
+	start, end, err := datadogTimes(s.Start, s.Duration)
+	if err != nil {
+		return nil, err
+	}
+
+	name := s.Resource
+	if name == "" {
+		name = s.Name
+	}
+	span := &Span{
+		Name:      name,
+		SpanKind:  kindOf(s.Meta["span.kind"]),
+		StartTime: start,
+		EndTime:   end,
+	}
+	span.SpanContext = SpanContext{TraceID: hi + tid, SpanID: sid}
+	span.Parent.TraceID, span.Parent.SpanID = hi+tid, pid
+
+	span.Status.Code = "Unset"
+	if s.Error != 0 {
+		span.Status.Code = "Error"
+		span.Status.Description = s.Meta["error.message"]
+		if span.Status.Description == "" {
+			span.Status.Description = s.Meta["error.msg"]
+		}
+	}
+
+	attrs := []KeyValue{keyValue("operation.name", s.Name)}
+	if s.Type != "" {
+		attrs = append(attrs, keyValue("span.type", s.Type))
+	}
+	keys := make([]string, 0, len(s.Meta)+len(s.Metrics))
+	for k := range s.Meta {
+		keys = append(keys, k)
+	}
+	for k := range s.Metrics {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.HasPrefix(k, "_") || k == "span.kind" {
+			// Datadog's bookkeeping, like _dd.p.tid and _sampling_priority_v1.
+			continue
+		}
+		if v, ok := s.Meta[k]; ok {
+			attrs = append(attrs, keyValue(k, v))
+		} else {
+			attrs = append(attrs, keyValue(k, s.Metrics[k]))
+		}
+	}
+	span.Attributes = rawJSON(attrs)
+	span.Resource = []KeyValue{keyValue("service.name", s.Service)}
+	if env := s.Meta["env"]; env != "" {
+		span.Resource = append(span.Resource, keyValue("deployment.environment", env))
+	}
+	return span, nil
+}
+
+// datadogID formats a decimal uint64 ID (or, from some exports, one that's
+// already hex) as 16 hex digits, with 0 meaning no parent.
+func datadogID(n json.Number) (string, error) {
+	if n == "" {
+		return RootID, nil
+	}
+	id, err := strconv.ParseUint(string(n), 10, 64)
+	if err != nil {
+		if _, herr := strconv.ParseUint(string(n), 16, 64); herr == nil && len(n) <= 16 {
+			return fmt.Sprintf("%016s", string(n)), nil
+		}
+		return "", err
+	}
+	return fmt.Sprintf("%016x", id), nil
+}
+
+// datadogTimes reads start and duration as integer ns, like the agent writes
+// them, or fractional seconds, like the UI does.
+func datadogTimes(start, duration json.Number) (time.Time, time.Time, error) {
+	if ns, err := strconv.ParseInt(string(start), 10, 64); err == nil && ns > 1e15 {
+		d, err := strconv.ParseInt(string(duration), 10, 64)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("duration: %w", err)
+		}
+		return time.Unix(0, ns).UTC(), time.Unix(0, ns+d).UTC(), nil
+	}
+	begin, err := unixSeconds(string(start))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("start: %w", err)
+	}
+	d, err := duration.Float64()
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("duration: %w", err)
+	}
+	return begin, begin.Add(time.Duration(d * float64(time.Second))), nil
+}
+
+// unixSeconds parses decimal seconds since the epoch like 1704067200.005
+// without losing nanoseconds to floating point.
+func unixSeconds(s string) (time.Time, error) {
+	sec, frac, _ := strings.Cut(s, ".")
+	whole, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(frac) > 9 {
+		frac = frac[:9]
+	}
+	ns, err := strconv.ParseInt((frac + "000000000")[:9], 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(whole, ns).UTC(), nil
+}
@@ -0,0 +1,46 @@
+package trot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDatadog(t *testing.T) {
+	agent := `[[
+{"trace_id":12345678901234567890,"span_id":1,"parent_id":0,"name":"http.request","resource":"GET /users","service":"web","type":"web","start":1704067200000000000,"duration":20000000,"meta":{"span.kind":"server","env":"prod","_dd.p.tid":"6592b20000000000"},"metrics":{"_sampling_priority_v1":1,"http.status_code":200}},
+{"trace_id":12345678901234567890,"span_id":2,"parent_id":1,"name":"postgres.query","resource":"SELECT 1","service":"db","start":1704067200005000000,"duration":10000000,"error":1,"meta":{"error.message":"timeout","_dd.p.tid":"6592b20000000000"}}
+]]`
+	ui := `{"trace":{"root_id":"1","spans":{
+"1":{"trace_id":"12345678901234567890","span_id":"1","parent_id":"0","name":"http.request","resource":"GET /users","service":"web","start":1704067200.0,"duration":0.02,"meta":{"_dd.p.tid":"6592b20000000000"}},
+"2":{"trace_id":"12345678901234567890","span_id":"2","parent_id":"1","name":"postgres.query","resource":"SELECT 1","service":"db","start":1704067200.005,"duration":0.01,"error":1,"meta":{"_dd.p.tid":"6592b20000000000"}}
+}}}`
+	for name, in := range map[string]string{"agent": agent, "ui": ui} {
+		t.Run(name, func(t *testing.T) {
+			tr, err := Parse(strings.NewReader(in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			root := tr.Tree("root", RootID).Children
+			if got, want := names(root), "GET /users"; got != want {
+				t.Fatalf("roots = %s, want %s", got, want)
+			}
+			if got, want := root[0].Span.SpanContext.TraceID, "6592b20000000000ab54a98ceb1f0ad2"; got != want {
+				t.Errorf("TraceID = %s, want %s", got, want)
+			}
+			query := root[0].Children[0].Span
+			if got, want := query.Duration(), 10*time.Millisecond; got != want {
+				t.Errorf("query took %v, want %v", got, want)
+			}
+			if got, want := query.StartTime.Sub(root[0].Span.StartTime), 5*time.Millisecond; got != want {
+				t.Errorf("query started %v in, want %v", got, want)
+			}
+			if !query.IsError() {
+				t.Errorf("query isn't an error")
+			}
+			if v, _ := query.Attr("operation.name"); v != "postgres.query" {
+				t.Errorf("operation.name = %q, want postgres.query", v)
+			}
+		})
+	}
+}
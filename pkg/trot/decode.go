@@ -0,0 +1,120 @@
+package trot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Decoder reads spans in one input format.
+type Decoder interface {
+	// Name identifies the format, e.g. for a --format flag.
+	Name() string
+
+	// Sniff reports whether peek, the first bytes of the input, look like this format.
+	Sniff(peek []byte) bool
+
+	// Decode adds every span in r to t.
+	Decode(r io.Reader, t *Trace) error
+}
+
+// sniffLen is how much input Detect gets to look at.
+const sniffLen = 8 << 10
+
+var (
+	decodersMu sync.RWMutex
+	decoders   []Decoder
+)
+
+// Register makes d available to Lookup and Detect.
+//
+// Decoders registered later are sniffed first, so embedders can override the builtins.
+func Register(d Decoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+
+	decoders = append([]Decoder{d}, decoders...)
+}
+
+// Decoders returns every registered decoder in sniffing order.
+func Decoders() []Decoder {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+
+	return append([]Decoder{}, decoders...)
+}
+
+// Lookup returns the decoder registered under name, or nil.
+func Lookup(name string) Decoder {
+	for _, d := range Decoders() {
+		if d.Name() == name {
+			return d
+		}
+	}
+	return nil
+}
+
+// Detect returns the first decoder that recognizes peek, falling back to stdouttrace.
+func Detect(peek []byte) Decoder {
+	for _, d := range Decoders() {
+		if d.Sniff(peek) {
+			return d
+		}
+	}
+	return Lookup("stdouttrace")
+}
+
+func init() {
+	Register(stdouttrace{})
+	Register(zipkin{})
+	Register(jaeger{})
+	Register(otlp{})
+	Register(gotest{})
+	Register(gha{})
+	Register(bep{})
+	Register(buildkit{})
+	Register(folded{})
+	Register(pprof{})
+	Register(strace{})
+	Register(honeycomb{})
+	Register(datadog{})
+	Register(otelcli{})
+	Register(CSV{})
+	Register(CSV{Comma: '\t'})
+}
+
+// ParseFormat decodes r with the decoder registered as format, or sniffs it if format is "".
+func ParseFormat(r io.Reader, format string) (*Trace, error) {
+	t := NewTrace()
+	if err := decode(r, format, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func decode(r io.Reader, format string, t *Trace) error {
+	br := bufio.NewReaderSize(r, sniffLen)
+
+	var d Decoder
+	sniffed := format == ""
+	if sniffed {
+		peek, err := br.Peek(sniffLen)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return err
+		}
+		d = Detect(peek)
+	} else {
+		d = Lookup(format)
+		if d == nil {
+			return fmt.Errorf("unknown format %q", format)
+		}
+	}
+
+	tr := &tracker{r: br}
+	if err := d.Decode(tr, t); err != nil {
+		return tr.parseError(d.Name(), sniffed, err)
+	}
+
+	return nil
+}
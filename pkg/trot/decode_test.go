@@ -0,0 +1,143 @@
+package trot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Each input is a GET / from web that calls a failing query 5ms in.
+var decodeInputs = map[string]string{
+	"otlp": `{"resourceSpans":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"web"}}]},"scopeSpans":[{"scope":{"name":"net/http"},"spans":[
+{"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","spanId":"00f067aa0ba902b7","name":"GET /","kind":2,"startTimeUnixNano":"1704067200000000000","endTimeUnixNano":"1704067200020000000","attributes":[{"key":"http.response.status_code","value":{"intValue":200}}]},
+{"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","spanId":"00f067aa0ba902b8","parentSpanId":"00f067aa0ba902b7","name":"query","kind":3,"startTimeUnixNano":"1704067200005000000","endTimeUnixNano":"1704067200015000000","attributes":[{"key":"db.system","value":{"stringValue":"postgresql"}}],"status":{"code":2,"message":"timeout"},"events":[{"timeUnixNano":"1704067200010000000","name":"retry"}]}
+]}]}]}`,
+	"jaeger": `{"data":[{"traceID":"4bf92f3577b34da6a3ce929d0e0e4736","spans":[
+{"traceID":"4bf92f3577b34da6a3ce929d0e0e4736","spanID":"00f067aa0ba902b7","operationName":"GET /","startTime":1704067200000000,"duration":20000,"tags":[{"key":"span.kind","type":"string","value":"server"},{"key":"http.response.status_code","type":"int64","value":200}],"processID":"p1"},
+{"traceID":"4bf92f3577b34da6a3ce929d0e0e4736","spanID":"00f067aa0ba902b8","operationName":"query","references":[{"refType":"CHILD_OF","traceID":"4bf92f3577b34da6a3ce929d0e0e4736","spanID":"00f067aa0ba902b7"}],"startTime":1704067200005000,"duration":10000,"tags":[{"key":"db.system","type":"string","value":"postgresql"},{"key":"error","type":"bool","value":true}],"logs":[{"timestamp":1704067200010000,"fields":[{"key":"event","type":"string","value":"retry"}]}],"processID":"p1"}
+],"processes":{"p1":{"serviceName":"web","tags":[]}}}]}`,
+	"zipkin": `[
+{"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","id":"00f067aa0ba902b7","name":"GET /","kind":"SERVER","timestamp":1704067200000000,"duration":20000,"localEndpoint":{"serviceName":"web"},"tags":{"http.response.status_code":"200"}},
+{"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","id":"00f067aa0ba902b8","parentId":"00f067aa0ba902b7","name":"query","kind":"CLIENT","timestamp":1704067200005000,"duration":10000,"localEndpoint":{"serviceName":"web"},"tags":{"db.system":"postgresql","error":"timeout"},"annotations":[{"timestamp":1704067200010000,"value":"retry"}]}
+]`,
+}
+
+func TestDecoders(t *testing.T) {
+	for format, in := range decodeInputs {
+		t.Run(format, func(t *testing.T) {
+			if got := Detect([]byte(in)).Name(); got != format {
+				t.Errorf("Detect() = %s, want %s", got, format)
+			}
+			tr, err := Parse(strings.NewReader(in))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			root := tr.Tree("root", RootID).Children
+			if got, want := names(root), "GET /"; got != want {
+				t.Fatalf("roots = %s, want %s", got, want)
+			}
+			get := root[0].Span
+			if got, want := get.SpanContext.TraceID, "4bf92f3577b34da6a3ce929d0e0e4736"; got != want {
+				t.Errorf("TraceID = %s, want %s", got, want)
+			}
+			if got, want := get.Duration(), 20*time.Millisecond; got != want {
+				t.Errorf("GET took %v, want %v", got, want)
+			}
+			if got, want := get.Service(), "web"; got != want {
+				t.Errorf("service = %q, want %q", got, want)
+			}
+			if got, want := get.SpanKind, KindServer; got != want {
+				t.Errorf("kind = %d, want %d", got, want)
+			}
+			if v, _ := get.Attr("http.response.status_code"); v != "200" {
+				t.Errorf("status code = %q, want 200", v)
+			}
+
+			if got, want := names(root[0].Children), "query"; got != want {
+				t.Fatalf("callees = %s, want %s", got, want)
+			}
+			query := root[0].Children[0].Span
+			if got, want := query.StartTime.Sub(get.StartTime), 5*time.Millisecond; got != want {
+				t.Errorf("query started %v in, want %v", got, want)
+			}
+			if !query.IsError() {
+				t.Errorf("query isn't an error")
+			}
+			if v, _ := query.Attr("db.system"); v != "postgresql" {
+				t.Errorf("db.system = %q, want postgresql", v)
+			}
+			events := query.DecodeEvents()
+			if len(events) != 1 || events[0].Name != "retry" || events[0].Time.Sub(get.StartTime) != 10*time.Millisecond {
+				t.Errorf("events = %+v, want a retry 10ms in", events)
+			}
+		})
+	}
+}
+
+func TestOTLPValues(t *testing.T) {
+	in := `{"resourceSpans":[{"resource":{"attributes":[{"key":"k8s","value":{"kvlistValue":{"values":[{"key":"pod","value":{"stringValue":"web-1"}}]}}}]},"scopeSpans":[{"spans":[
+{"traceId":"S/kvNXezTaajzpKdDg5HNg==","spanId":"APBnqgupArc=","name":"GET","startTimeUnixNano":1000,"endTimeUnixNano":"5000","attributes":[
+{"key":"n","value":{"intValue":"12345678901"}},
+{"key":"ratio","value":{"doubleValue":0.5}},
+{"key":"ok","value":{"boolValue":true}},
+{"key":"tags","value":{"arrayValue":{"values":[{"stringValue":"a"},{"intValue":2}]}}},
+{"key":"http","value":{"kvlistValue":{"values":[
+{"key":"request","value":{"kvlistValue":{"values":[{"key":"method","value":{"stringValue":"GET"}}]}}},
+{"key":"status_code","value":{"intValue":"404"}}
+]}}},
+{"key":"list","value":{"arrayValue":{"values":[{"kvlistValue":{"values":[{"key":"a","value":{"intValue":"1"}}]}}]}}}
+]}
+]}]}]}`
+	tr, err := ParseFormat(strings.NewReader(in), "otlp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	span, ok := tr.Spans["00f067aa0ba902b7"]
+	if !ok {
+		t.Fatalf("base64 SpanID wasn't decoded to hex: %v", tr.Spans)
+	}
+	if got, want := span.SpanContext.TraceID, "4bf92f3577b34da6a3ce929d0e0e4736"; got != want {
+		t.Errorf("TraceID = %s, want %s", got, want)
+	}
+	if got, want := span.Duration(), 4*time.Microsecond; got != want {
+		t.Errorf("Duration() = %v, want %v", got, want)
+	}
+	for key, want := range map[string]string{
+		"n":                   "12345678901",
+		"ratio":               "0.5",
+		"ok":                  "true",
+		"tags":                "[a 2]",
+		"http.request.method": "GET",
+		"http.status_code":    "404",
+		"list":                `[{"a":1}]`,
+	} {
+		if got, ok := span.Attr(key); !ok || got != want {
+			t.Errorf("%s = %q, %t, want %q", key, got, ok, want)
+		}
+	}
+	if _, ok := span.Attr("http"); ok {
+		t.Errorf("kvlistValue wasn't flattened")
+	}
+	if len(span.Resource) != 1 || span.Resource[0].Key != "k8s.pod" || span.Resource[0].Value.String() != "web-1" {
+		t.Errorf("Resource = %+v, want k8s.pod=web-1", span.Resource)
+	}
+	if got, want := span.Summary(), "GET → 404"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for format, in := range map[string]string{
+		"otlp":   `{"resourceSpans":[{"scopeSpans":[{"spans":[{"startTimeUnixNano":"soon"}]}]}]}`,
+		"jaeger": `{"data":[{"spans":[{"startTime":"soon"}]}]}`,
+		"zipkin": `[{"traceId":"1","timestamp":"soon"}]`,
+	} {
+		if _, err := ParseFormat(strings.NewReader(in), format); err == nil || !strings.Contains(err.Error(), format) {
+			t.Errorf("ParseFormat(%s) = %v, want an error naming the format", format, err)
+		}
+	}
+	if _, err := ParseFormat(strings.NewReader("{}"), "nope"); err == nil {
+		t.Error("ParseFormat with an unknown format succeeded")
+	}
+}
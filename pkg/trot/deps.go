@@ -0,0 +1,217 @@
+package trot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// peerKeys are checked in order to name the other side of a client span
+// that has no matching server span in the input.
+var peerKeys = []string{
+	"peer.service",
+	"server.address",
+	"net.peer.name",
+	"rpc.service",
+	"db.system",
+	"messaging.system",
+}
+
+type Edge struct {
+	From      string
+	To        string
+	Latencies []time.Duration
+}
+
+func (e *Edge) Percentile(p float64) time.Duration {
+	if len(e.Latencies) == 0 {
+		return 0
+	}
+	i := int(p * float64(len(e.Latencies)-1))
+	return e.Latencies[i]
+}
+
+type DepGraph struct {
+	Services []string
+	Edges    []*Edge
+}
+
+// Deps builds the service dependency graph from client/server span pairs and peer attributes.
+func (t *Trace) Deps() *DepGraph {
+	spans, children := t.Spans, t.Children
+
+	edges := map[[2]string]*Edge{}
+	services := map[string]struct{}{}
+
+	add := func(from, to string, dur time.Duration) {
+		services[from] = struct{}{}
+		services[to] = struct{}{}
+
+		k := [2]string{from, to}
+		e, ok := edges[k]
+		if !ok {
+			e = &Edge{From: from, To: to}
+			edges[k] = e
+		}
+		e.Latencies = append(e.Latencies, dur)
+	}
+
+	for _, span := range spans {
+		services[span.Service()] = struct{}{}
+
+		switch span.SpanKind {
+		case KindClient, KindProducer:
+			matched := false
+			for _, kid := range children[span.SpanContext.SpanID] {
+				if kid.SpanKind == KindServer || kid.SpanKind == KindConsumer {
+					matched = true
+				}
+			}
+			if matched {
+				// The server side records the edge.
+				continue
+			}
+			for _, key := range peerKeys {
+				if peer, ok := span.Attr(key); ok && peer != "" {
+					add(span.Service(), peer, span.EndTime.Sub(span.StartTime))
+					break
+				}
+			}
+		case KindServer, KindConsumer:
+			parent, ok := spans[span.Parent.SpanID]
+			if !ok {
+				continue
+			}
+			if from, to := parent.Service(), span.Service(); from != to || parent.SpanKind == KindClient || parent.SpanKind == KindProducer {
+				dur := span.EndTime.Sub(span.StartTime)
+				if parent.SpanKind == KindClient || parent.SpanKind == KindProducer {
+					dur = parent.EndTime.Sub(parent.StartTime)
+				}
+				add(from, to, dur)
+			}
+		}
+	}
+
+	g := &DepGraph{}
+	for svc := range services {
+		g.Services = append(g.Services, svc)
+	}
+	sort.Strings(g.Services)
+
+	for _, e := range edges {
+		sort.Slice(e.Latencies, func(i, j int) bool {
+			return e.Latencies[i] < e.Latencies[j]
+		})
+		g.Edges = append(g.Edges, e)
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From == g.Edges[j].From {
+			return g.Edges[i].To < g.Edges[j].To
+		}
+		return g.Edges[i].From < g.Edges[j].From
+	})
+
+	return g
+}
+
+func edgeLabel(e *Edge, opts Options) string {
+	return fmt.Sprintf("%d calls\np50 %s\np90 %s\np99 %s", len(e.Latencies), opts.duration(e.Percentile(0.5)), opts.duration(e.Percentile(0.9)), opts.duration(e.Percentile(0.99)))
+}
+
+// RenderDOT writes g as a graphviz digraph. Only opts.Precision applies.
+func RenderDOT(w io.Writer, g *DepGraph, opts Options) error {
+	fmt.Fprintln(w, "digraph services {")
+	fmt.Fprintln(w, "\tnode [shape=box];")
+	for _, svc := range g.Services {
+		fmt.Fprintf(w, "\t%q;\n", svc)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(w, "\t%q -> %q [label=%q];\n", e.From, e.To, edgeLabel(e, opts))
+	}
+	fmt.Fprintln(w, "}")
+	return nil
+}
+
+// RenderDepsHTML writes g as a page with a force-directed layout.
+func RenderDepsHTML(w io.Writer, g *DepGraph, opts Options) error {
+	type link struct {
+		Source int    `json:"source"`
+		Target int    `json:"target"`
+		Label  string `json:"label"`
+	}
+
+	index := map[string]int{}
+	for i, svc := range g.Services {
+		index[svc] = i
+	}
+	links := []link{}
+	for _, e := range g.Edges {
+		links = append(links, link{index[e.From], index[e.To], edgeLabel(e, opts)})
+	}
+
+	b, err := json.Marshal(map[string]any{
+		"nodes": g.Services,
+		"links": links,
+	})
+	if err != nil {
+		return err
+	}
+
+	writeHeader(w, opts)
+	fmt.Fprintf(w, depsBody, b)
+	writeFooter(w)
+	return nil
+}
+
+// A tiny force-directed layout so the output stays a single self-contained file.
+const depsBody = `
+<svg id="deps" width="100%%" height="800"><defs><marker id="arrow" viewBox="0 0 10 10" refX="20" refY="5" markerWidth="6" markerHeight="6" orient="auto"><path d="M0,0L10,5L0,10z"/></marker></defs></svg>
+<script>
+const graph = %s;
+const svg = document.getElementById("deps");
+const ns = "http://www.w3.org/2000/svg";
+const W = svg.clientWidth || 1200, H = 800;
+const nodes = graph.nodes.map((name, i) => ({name, x: W/2 + 200*Math.cos(i), y: H/2 + 200*Math.sin(i), vx: 0, vy: 0}));
+const lines = graph.links.map(l => {
+  const line = document.createElementNS(ns, "line");
+  line.setAttribute("stroke", "grey");
+  line.setAttribute("marker-end", "url(#arrow)");
+  const title = document.createElementNS(ns, "title");
+  title.textContent = l.label;
+  line.appendChild(title);
+  svg.appendChild(line);
+  return line;
+});
+const labels = nodes.map(n => {
+  const t = document.createElementNS(ns, "text");
+  t.textContent = n.name;
+  t.setAttribute("text-anchor", "middle");
+  svg.appendChild(t);
+  return t;
+});
+function tick() {
+  for (const a of nodes) for (const b of nodes) {
+    if (a === b) continue;
+    const dx = a.x - b.x, dy = a.y - b.y, d2 = Math.max(dx*dx + dy*dy, 1);
+    a.vx += 2000 * dx / d2; a.vy += 2000 * dy / d2;
+  }
+  for (const l of graph.links) {
+    const a = nodes[l.source], b = nodes[l.target];
+    const dx = b.x - a.x, dy = b.y - a.y;
+    a.vx += dx * 0.01; a.vy += dy * 0.01; b.vx -= dx * 0.01; b.vy -= dy * 0.01;
+  }
+  for (const n of nodes) {
+    n.vx += (W/2 - n.x) * 0.005; n.vy += (H/2 - n.y) * 0.005;
+    n.x += n.vx *= 0.5; n.y += n.vy *= 0.5;
+  }
+  graph.links.forEach((l, i) => {
+    const a = nodes[l.source], b = nodes[l.target];
+    lines[i].setAttribute("x1", a.x); lines[i].setAttribute("y1", a.y);
+    lines[i].setAttribute("x2", b.x); lines[i].setAttribute("y2", b.y);
+  });
+  nodes.forEach((n, i) => { labels[i].setAttribute("x", n.x); labels[i].setAttribute("y", n.y); });
+}
+for (let i = 0; i < 300; i++) tick();
+</script>`
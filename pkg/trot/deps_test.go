@@ -0,0 +1,65 @@
+package trot
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func serviceSpan(id, parent, service string, kind, start, end int) *Span {
+	s := span(id, parent, id, start, end)
+	s.SpanKind = kind
+	s.Resource = []KeyValue{keyValue("service.name", service)}
+	return s
+}
+
+func TestDeps(t *testing.T) {
+	tr := NewTrace()
+	for _, s := range []*Span{
+		serviceSpan("a", RootID, "web", KindServer, 0, 100),
+		// web calls api twice; the client spans' durations are the latency.
+		serviceSpan("b1", "a", "web", KindClient, 10, 20),
+		serviceSpan("c1", "b1", "api", KindServer, 12, 18),
+		serviceSpan("b2", "a", "web", KindClient, 30, 60),
+		serviceSpan("c2", "b2", "api", KindServer, 32, 58),
+		// Nothing in the input for postgres, so its peer attribute names it.
+		serviceSpan("d", "c2", "api", KindClient, 40, 45),
+		// Internal spans in the same service aren't calls.
+		serviceSpan("e", "c2", "api", KindInternal, 45, 50),
+		// A server span under another service's internal span still is.
+		serviceSpan("f", "e", "worker", KindServer, 46, 49),
+	} {
+		tr.Add(s)
+	}
+	tr.Spans["d"].Attributes = rawJSON([]KeyValue{keyValue("db.system", "postgresql")})
+
+	g := tr.Deps()
+	if got, want := strings.Join(g.Services, ","), "api,postgresql,web,worker"; got != want {
+		t.Errorf("Services = %s, want %s", got, want)
+	}
+	got := []string{}
+	for _, e := range g.Edges {
+		got = append(got, fmt.Sprintf("%s->%s %v", e.From, e.To, e.Latencies))
+	}
+	want := []string{
+		"api->postgresql [5ms]",
+		"api->worker [3ms]",
+		"web->api [10ms 30ms]",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Edges =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if e := g.Edges[2]; e.Percentile(0) != 10*time.Millisecond || e.Percentile(0.99) != 10*time.Millisecond || e.Percentile(1) != 30*time.Millisecond {
+		t.Errorf("Percentiles of %v are off", e.Latencies)
+	}
+
+	var buf bytes.Buffer
+	if err := RenderDOT(&buf, g, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"web" -> "api" [label="2 calls`) {
+		t.Errorf("RenderDOT() =\n%s\nwant a web -> api edge with 2 calls", buf.String())
+	}
+}
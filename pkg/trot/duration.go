@@ -0,0 +1,75 @@
+package trot
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+var durationUnits = []struct {
+	name string
+	size time.Duration
+}{
+	{"s", time.Second},
+	{"ms", time.Millisecond},
+	{"µs", time.Microsecond},
+	{"ns", time.Nanosecond},
+}
+
+// FormatDuration formats d with digits significant digits (3 if digits <= 0)
+// in the largest of s, ms, µs, or ns that keeps it at least 1, e.g. "1.23s" or "456µs".
+func FormatDuration(d time.Duration, digits int) string {
+	return string(appendDuration(nil, d, digits))
+}
+
+// appendDuration is FormatDuration without the allocations, for renderers.
+func appendDuration(b []byte, d time.Duration, digits int) []byte {
+	if digits <= 0 {
+		digits = 3
+	}
+	if d == 0 {
+		return append(b, "0s"...)
+	}
+	if d < 0 {
+		b = append(b, '-')
+		d = -d
+	}
+
+	i := 0
+	for i < len(durationUnits)-1 && d < durationUnits[i].size {
+		i++
+	}
+	v := float64(d) / float64(durationUnits[i].size)
+	prec := decimals(v, digits)
+
+	// Rounding can carry into the next unit, e.g. 999.9µs to 1000µs.
+	if p := math.Pow10(prec); i > 0 && math.Round(v*p)/p >= 1000 {
+		i--
+		v = float64(d) / float64(durationUnits[i].size)
+		prec = decimals(v, digits)
+	}
+
+	b = strconv.AppendFloat(b, v, 'f', prec, 64)
+	if prec > 0 {
+		for b[len(b)-1] == '0' {
+			b = b[:len(b)-1]
+		}
+		if b[len(b)-1] == '.' {
+			b = b[:len(b)-1]
+		}
+	}
+	return append(b, durationUnits[i].name...)
+}
+
+// decimals is how many decimal places v needs for digits significant digits.
+func decimals(v float64, digits int) int {
+	whole := 1
+	for x := v; x >= 10; x /= 10 {
+		whole++
+	}
+	return max(0, digits-whole)
+}
+
+func (o Options) duration(d time.Duration) string {
+	return FormatDuration(d, o.Precision)
+}
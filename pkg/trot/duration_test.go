@@ -0,0 +1,29 @@
+package trot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDuration(t *testing.T) {
+	for _, tc := range []struct {
+		d      time.Duration
+		digits int
+		want   string
+	}{
+		{0, 0, "0s"},
+		{1234567891 * time.Nanosecond, 0, "1.23s"},
+		{1234567891 * time.Nanosecond, 5, "1.2346s"},
+		{45 * time.Second, 0, "45s"},
+		{3600 * time.Second, 0, "3600s"},
+		{1500 * time.Microsecond, 0, "1.5ms"},
+		{999999 * time.Nanosecond, 0, "1ms"},
+		{999999 * time.Nanosecond, 6, "999.999µs"},
+		{42 * time.Nanosecond, 0, "42ns"},
+		{-2500 * time.Microsecond, 2, "-2.5ms"},
+	} {
+		if got := FormatDuration(tc.d, tc.digits); got != tc.want {
+			t.Errorf("FormatDuration(%d, %d) = %q, want %q", tc.d, tc.digits, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package trot
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrorChain is the path from a root down to a span that originated an error.
+type ErrorChain struct {
+	Chain      []*Span
+	Exceptions []Exception
+}
+
+type Exception struct {
+	Type       string
+	Message    string
+	Stacktrace string
+	Time       time.Time
+}
+
+// exceptions pulls "exception" events out of the stdouttrace Events list.
+func (s *Span) exceptions() []Exception {
+	excs := []Exception{}
+	// This runs for every rendered span, and most have no exceptions.
+	if !bytes.Contains(s.Events, []byte(`"exception"`)) {
+		return excs
+	}
+	for _, e := range s.DecodeEvents() {
+		if e.Name != "exception" {
+			continue
+		}
+		exc := Exception{Time: e.Time}
+		exc.Type, _ = lookup(e.Attributes, "exception.type")
+		exc.Message, _ = lookup(e.Attributes, "exception.message")
+		exc.Stacktrace, _ = lookup(e.Attributes, "exception.stacktrace")
+		excs = append(excs, exc)
+	}
+
+	return excs
+}
+
+// Errors returns a chain for every error span that has no error spans beneath it.
+func (t *Trace) Errors() []ErrorChain {
+	spans, children := t.Spans, t.Children
+
+	chains := []ErrorChain{}
+
+	for id, span := range spans {
+		if !span.IsError() {
+			continue
+		}
+		if hasErrorBelow(id, children) {
+			continue
+		}
+
+		chain := []*Span{}
+		seen := map[string]struct{}{}
+		for s := span; s != nil; s = spans[s.Parent.SpanID] {
+			if _, ok := seen[s.SpanContext.SpanID]; ok {
+				break
+			}
+			seen[s.SpanContext.SpanID] = struct{}{}
+			chain = append([]*Span{s}, chain...)
+		}
+
+		chains = append(chains, ErrorChain{
+			Chain:      chain,
+			Exceptions: span.exceptions(),
+		})
+	}
+
+	sort.Slice(chains, func(i, j int) bool {
+		a, b := chains[i].Chain[len(chains[i].Chain)-1], chains[j].Chain[len(chains[j].Chain)-1]
+		return a.StartTime.Before(b.StartTime)
+	})
+
+	return chains
+}
+
+func hasErrorBelow(id string, children map[string][]*Span) bool {
+	seen := map[string]bool{id: true}
+	stack := []string{id}
+	for len(stack) != 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, kid := range children[id] {
+			if kid.IsError() {
+				return true
+			}
+			if kid := kid.SpanContext.SpanID; !seen[kid] {
+				seen[kid] = true
+				stack = append(stack, kid)
+			}
+		}
+	}
+	return false
+}
+
+func writeErrors(w io.Writer, chains []ErrorChain) {
+	if len(chains) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, `<details open class="errors"><summary>%s</summary><ol>`, appendCount(nil, len(chains), "error"))
+	for _, c := range chains {
+		names := make([]string, len(c.Chain))
+		for i, s := range c.Chain {
+			names[i] = html.EscapeString(s.Name)
+		}
+		fmt.Fprintf(w, `<li><b>%s</b><ul>`, strings.Join(names, " &gt; "))
+
+		for _, s := range c.Chain {
+			if !s.IsError() {
+				continue
+			}
+			desc := s.Status.Description
+			if desc == "" {
+				desc = "(no description)"
+			}
+			fmt.Fprintf(w, `<li>%s: %s</li>`, html.EscapeString(s.Name), html.EscapeString(desc))
+		}
+		for _, exc := range c.Exceptions {
+			fmt.Fprintf(w, `<li>%s: %s`, html.EscapeString(exc.Type), html.EscapeString(exc.Message))
+			if exc.Stacktrace != "" {
+				fmt.Fprintf(w, `<details><summary>stacktrace</summary><pre>%s</pre></details>`, html.EscapeString(exc.Stacktrace))
+			}
+			fmt.Fprint(w, `</li>`)
+		}
+		fmt.Fprint(w, `</ul></li>`)
+	}
+	fmt.Fprintln(w, `</ol></details>`)
+}
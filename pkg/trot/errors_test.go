@@ -0,0 +1,79 @@
+package trot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	tr := testTrace()
+	fail := func(id, desc string) {
+		tr.Spans[id].Status.Code = "Error"
+		tr.Spans[id].Status.Description = desc
+	}
+	// a and c failed because e did, so only e's chain is reported; d failed
+	// on its own.
+	fail("a", "request failed")
+	fail("c", "query failed")
+	fail("e", "timeout")
+	fail("d", "")
+	tr.Spans["e"].Events = rawJSON([]Event{{
+		Name: "exception",
+		Time: epoch.Add(55),
+		Attributes: []KeyValue{
+			keyValue("exception.type", "TimeoutError"),
+			keyValue("exception.message", "took too long"),
+			keyValue("exception.stacktrace", "at query()"),
+		},
+	}, {
+		Name: "retry",
+	}})
+
+	chains := tr.Errors()
+	got := []string{}
+	for _, c := range chains {
+		names := []string{}
+		for _, s := range c.Chain {
+			names = append(names, s.Name)
+		}
+		got = append(got, strings.Join(names, " > "))
+	}
+	// By when the failing span started.
+	if want := "a > b > d,a > c > db.query"; strings.Join(got, ",") != want {
+		t.Errorf("Errors() = %s, want %s", strings.Join(got, ","), want)
+	}
+	if len(chains) != 2 {
+		t.Fatalf("got %d chains", len(chains))
+	}
+	if excs := chains[0].Exceptions; len(excs) != 0 {
+		t.Errorf("d has exceptions %+v", excs)
+	}
+	if excs := chains[1].Exceptions; len(excs) != 1 || excs[0].Type != "TimeoutError" || excs[0].Message != "took too long" || excs[0].Stacktrace != "at query()" {
+		t.Errorf("e's exceptions = %+v", excs)
+	}
+
+	var buf bytes.Buffer
+	writeErrors(&buf, chains)
+	for _, want := range []string{
+		"<summary>2 errors</summary>",
+		"<b>a &gt; c &gt; db.query</b>",
+		"<li>c: query failed</li>",
+		"<li>d: (no description)</li>",
+		"<li>TimeoutError: took too long",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("writeErrors() is missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	writeErrors(&buf, chains[:1])
+	if !strings.Contains(buf.String(), "<summary>1 error</summary>") {
+		t.Errorf("one error isn't summarized as %q", "1 error")
+	}
+
+	if got := testTrace().Errors(); len(got) != 0 {
+		t.Errorf("Errors() of a trace without errors = %d chains", len(got))
+	}
+}
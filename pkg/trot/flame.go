@@ -0,0 +1,174 @@
+package trot
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// Frame is a span name path merged across traces.
+type Frame struct {
+	Name     string
+	Count    int
+	Total    time.Duration
+	Children []*Frame
+}
+
+func (f *Frame) child(name string) *Frame {
+	for _, kid := range f.Children {
+		if kid.Name == name {
+			return kid
+		}
+	}
+	kid := &Frame{Name: name}
+	f.Children = append(f.Children, kid)
+	return kid
+}
+
+// add merges span and everything under it into f's children. Like buildTree,
+// it keeps its own stack, since recursive functions can nest spans very deeply.
+func (f *Frame) add(span *Span, children map[string][]*Span) {
+	type item struct {
+		parent *Frame
+		span   *Span
+	}
+
+	stack := []item{{f, span}}
+	for len(stack) != 0 {
+		it := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		frame := it.parent.child(it.span.Name)
+		frame.Count++
+		frame.Total += it.span.EndTime.Sub(it.span.StartTime)
+
+		kids := children[it.span.SpanContext.SpanID]
+		for i := len(kids) - 1; i >= 0; i-- {
+			stack = append(stack, item{frame, kids[i]})
+		}
+	}
+}
+
+// Flame merges every trace in t by span name path.
+func (t *Trace) Flame() *Frame {
+	spans, children := t.Spans, t.Children
+
+	root := &Frame{Name: "all"}
+
+	traces := map[string]struct{}{}
+	for parent, kids := range children {
+		if _, ok := spans[parent]; ok {
+			continue
+		}
+		for _, kid := range kids {
+			root.add(kid, children)
+			traces[kid.SpanContext.TraceID] = struct{}{}
+		}
+	}
+
+	root.Count = len(traces)
+	for _, kid := range root.Children {
+		root.Total += kid.Total
+	}
+
+	sortFrames(root)
+
+	return root
+}
+
+func sortFrames(root *Frame) {
+	stack := []*Frame{root}
+	for len(stack) != 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		slices.SortFunc(f.Children, func(a, b *Frame) int {
+			if a.Total == b.Total {
+				if a.Name < b.Name {
+					return -1
+				}
+				return 1
+			}
+			if a.Total > b.Total {
+				return -1
+			}
+			return 1
+		})
+		stack = append(stack, f.Children...)
+	}
+}
+
+// RenderFlame writes root as a page of nested, proportionally sized frames.
+func RenderFlame(w io.Writer, root *Frame, opts Options) error {
+	writeHeader(w, opts)
+	fmt.Fprint(w, `<div>`)
+	writeFrame(w, root, opts)
+	fmt.Fprintln(w, `</div>`)
+	writeFooter(w)
+
+	return nil
+}
+
+// writeFrame writes root and everything under it, without recursing.
+func writeFrame(w io.Writer, root *Frame, opts Options) {
+	type item struct {
+		f *Frame
+
+		// width is f's share of its parent, if nested.
+		width  float64
+		nested bool
+		close  bool
+	}
+
+	stack := []item{{f: root}}
+	for len(stack) != 0 {
+		it := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		f := it.f
+
+		if it.close {
+			if len(f.Children) != 0 {
+				fmt.Fprintln(w, `</div></details>`)
+			}
+			if it.nested {
+				fmt.Fprint(w, `</div>`)
+			}
+			continue
+		}
+
+		if it.nested {
+			fmt.Fprintf(w, `<div class="frame" style="width: %f%%">`, it.width)
+		}
+		stack = append(stack, item{f: f, nested: it.nested, close: true})
+
+		label := fmt.Sprintf("%s %s (%dx)", html.EscapeString(f.Name), opts.duration(f.Total), f.Count)
+
+		if len(f.Children) == 0 {
+			fmt.Fprintf(w, `<span title="%s">%s</span>`, label, label)
+			continue
+		}
+
+		// Concurrent children can add up to more than their parent.
+		total := f.Total
+		var sum time.Duration
+		for _, kid := range f.Children {
+			sum += kid.Total
+		}
+		if sum > total {
+			total = sum
+		}
+
+		fmt.Fprintf(w, `<details open><summary title="%s">%s</summary><div class="frames">`, label, label)
+		for i := len(f.Children) - 1; i >= 0; i-- {
+			kid := f.Children[i]
+			width := 0.0
+			if total != 0 {
+				width = 100.0 * float64(kid.Total) / float64(total)
+			}
+			stack = append(stack, item{f: kid, width: width, nested: true})
+		}
+	}
+}
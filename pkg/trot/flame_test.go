@@ -0,0 +1,68 @@
+package trot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlame(t *testing.T) {
+	tr := NewTrace()
+	for _, s := range []*Span{
+		span("a", RootID, "root", 0, 100),
+		span("b", "a", "query", 0, 10),
+		span("c", "a", "render", 10, 50),
+		span("d", "a", "query", 50, 70),
+	} {
+		tr.Add(s)
+	}
+
+	root := tr.Flame()
+	if root.Count != 1 || root.Total != 100*time.Millisecond || len(root.Children) != 1 {
+		t.Fatalf("root = %+v", root)
+	}
+	top := root.Children[0]
+	if got := []string{top.Children[0].Name, top.Children[1].Name}; len(top.Children) != 2 || got[0] != "render" || got[1] != "query" {
+		t.Fatalf("children = %+v, want render before query", top.Children)
+	}
+	// Both queries merge into one frame.
+	if q := top.Children[1]; q.Count != 2 || q.Total != 30*time.Millisecond {
+		t.Errorf("query = %+v", q)
+	}
+
+	var sb strings.Builder
+	if err := RenderFlame(&sb, root, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	page := sb.String()
+	for _, want := range []string{
+		`<div class="frame" style="width: 100.000000%"><details open><summary title="root 100ms (1x)">`,
+		`<div class="frame" style="width: 40.000000%"><span title="render 40ms (1x)">render 40ms (1x)</span></div>`,
+		`<span title="query 30ms (2x)">query 30ms (2x)</span></div></div></details>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page missing %s", want)
+		}
+	}
+}
+
+func TestFlameDeep(t *testing.T) {
+	const depth = 100000
+
+	root := chain(depth).Flame()
+	n := 0
+	for f := root; len(f.Children) != 0; f = f.Children[0] {
+		n++
+	}
+	if n != depth {
+		t.Errorf("got %d levels of frames, want %d", n, depth)
+	}
+
+	var sb strings.Builder
+	if err := RenderFlame(&sb, root, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(sb.String(), `</details>`); got != depth {
+		t.Errorf("closed %d frames, want %d", got, depth)
+	}
+}
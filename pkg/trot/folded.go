@@ -0,0 +1,143 @@
+package trot
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// folded is Brendan Gregg's folded stacks, as from stackcollapse-perf.pl:
+// "main;run;work 123" per line. It becomes a span per frame under "all",
+// each as long as its samples (a millisecond apiece) and with its callees
+// in name order, so the waterfall is a flame graph.
+type folded struct{}
+
+func (folded) Name() string {
+	return "folded"
+}
+
+func (folded) Sniff(peek []byte) bool {
+	line, _, _ := bytes.Cut(bytes.TrimLeft(peek, " \t\r\n"), []byte("\n"))
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] == '{' || line[0] == '[' || !bytes.Contains(line, []byte(";")) {
+		return false
+	}
+	i := bytes.LastIndexAny(line, " \t")
+	if i < 0 {
+		return false
+	}
+	_, err := strconv.ParseUint(string(line[i+1:]), 10, 64)
+	return err == nil
+}
+
+// frame is a stack prefix and how many samples it appears in.
+type frame struct {
+	name     string
+	samples  int64
+	self     int64
+	children map[string]*frame
+}
+
+func (f *frame) child(name string) *frame {
+	c, ok := f.children[name]
+	if !ok {
+		c = &frame{name: name, children: map[string]*frame{}}
+		f.children[name] = c
+	}
+	return c
+}
+
+// sampleTime is how long one sample is drawn.
+const sampleTime = time.Millisecond
+
+// maxSamples is the most samples that can be drawn sampleTime long each.
+const maxSamples = math.MaxInt64 / int64(sampleTime)
+
+func (folded) Decode(r io.Reader, t *Trace) error {
+	root := &frame{name: "all", children: map[string]*frame{}}
+	h := fnv.New128a()
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 16<<20)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		h.Write([]byte(text))
+		i := strings.LastIndexAny(text, " \t")
+		if i < 0 {
+			return fmt.Errorf("line %d: want stack and count, got %q", line, text)
+		}
+		n, err := strconv.ParseInt(text[i+1:], 10, 64)
+		if err != nil {
+			return fmt.Errorf("line %d: count: %w", line, err)
+		}
+		if n < 0 {
+			return fmt.Errorf("line %d: negative count %d", line, n)
+		}
+		// Every frame's samples are at most root's, so only check it.
+		if root.samples > maxSamples-n {
+			return fmt.Errorf("line %d: counts add up to more than %d samples", line, maxSamples)
+		}
+
+		f := root
+		f.samples += n
+		for _, name := range strings.Split(strings.TrimSpace(text[:i]), ";") {
+			f = f.child(name)
+			f.samples += n
+		}
+		f.self += n
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if root.samples == 0 {
+		return nil
+	}
+
+	root.spans(t, fmt.Sprintf("%x", h.Sum(nil)), "profile", sampleTime, func(f *frame) []KeyValue {
+		return []KeyValue{keyValue("samples", f.samples), keyValue("samples.self", f.self)}
+	})
+	return nil
+}
+
+// spans adds a span for f and each frame under it, weight long per sample,
+// laying out callees in name order from the start of their caller.
+func (f *frame) spans(t *Trace, tid, service string, weight time.Duration, attrs func(*frame) []KeyValue) {
+	resource := []KeyValue{keyValue("service.name", service)}
+	var add func(f *frame, path, parent string, start time.Time)
+	add = func(f *frame, path, parent string, start time.Time) {
+		span := &Span{
+			Name:      f.name,
+			SpanKind:  KindInternal,
+			StartTime: start,
+			EndTime:   start.Add(time.Duration(f.samples) * weight),
+			Resource:  resource,
+		}
+		span.SpanContext = SpanContext{TraceID: tid, SpanID: stableID(path)}
+		span.Parent.TraceID, span.Parent.SpanID = tid, parent
+		span.Status.Code = "Unset"
+		span.Attributes = rawJSON(attrs(f))
+		t.Add(span)
+
+		names := make([]string, 0, len(f.children))
+		for name := range f.children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			c := f.children[name]
+			add(c, path+";"+name, span.SpanContext.SpanID, start)
+			start = start.Add(time.Duration(c.samples) * weight)
+		}
+	}
+	add(f, f.name, RootID, time.Unix(0, 0).UTC())
+}
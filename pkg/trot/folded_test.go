@@ -0,0 +1,56 @@
+package trot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFolded(t *testing.T) {
+	in := `main;run;work 30
+main;run;wait 10
+main;init 5
+main 5
+`
+	tr, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := tr.Tree("root", RootID).Children
+	if len(root) != 1 || root[0].Span.Name != "all" {
+		t.Fatalf("roots = %s, want all", names(root))
+	}
+	main := root[0].Children[0]
+	if got, want := main.Span.Duration(), 50*time.Millisecond; got != want {
+		t.Errorf("main took %v, want %v", got, want)
+	}
+	if got, want := names(main.Children), "init,run"; got != want {
+		t.Fatalf("callees = %s, want %s", got, want)
+	}
+	run := main.Children[1]
+	if got, want := run.Span.StartTime.Sub(main.Span.StartTime), 5*time.Millisecond; got != want {
+		t.Errorf("run starts %v in, want %v", got, want)
+	}
+	if got, want := names(run.Children), "wait,work"; got != want {
+		t.Errorf("callees = %s, want %s", got, want)
+	}
+	if v, _ := main.Span.Attr("samples.self"); v != "5" {
+		t.Errorf("main self samples = %s, want 5", v)
+	}
+}
+
+func TestFoldedBadCounts(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"main;run -5\n", "line 1: negative count -5"},
+		{"main;run 9000000000000\nmain;init 9000000000000\n", "line 2: counts add up to more than"},
+		{"main;run 9223372036854775807\nmain;init 1\n", "line 1: counts add up to more than"},
+	} {
+		err := folded{}.Decode(strings.NewReader(tc.in), NewTrace())
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Decode(%q) = %v, want %q", tc.in, err, tc.want)
+		}
+	}
+	if (folded{}).Sniff([]byte("main;run -5\n")) {
+		t.Error("sniffed a negative count as folded")
+	}
+}
@@ -0,0 +1,71 @@
+package trot
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// gap is a stretch of a tree's timeline when no leaf span is running, drawn
+// only width long.
+type gap struct {
+	start, end time.Time
+	width      time.Duration
+}
+
+func (g gap) idle() time.Duration {
+	return g.end.Sub(g.start)
+}
+
+// idleGaps finds the gaps in root's timeline worth compressing: those longer
+// than a tenth of it. Each is drawn as long as a twentieth of the rest.
+func idleGaps(root *Node) []gap {
+	total := root.Duration()
+	if total <= 0 {
+		return nil
+	}
+
+	type interval struct{ start, end time.Time }
+	busy := []interval{}
+	root.Walk(func(n *Node, depth int) bool {
+		if depth != 0 && len(n.Children) == 0 {
+			busy = append(busy, interval{n.Span.StartTime, n.Span.EndTime})
+		}
+		return true
+	})
+	sort.Slice(busy, func(i, j int) bool {
+		return busy[i].start.Before(busy[j].start)
+	})
+
+	gaps := []gap{}
+	idle := time.Duration(0)
+	at := root.Span.StartTime
+	for _, b := range append(busy, interval{root.Span.EndTime, root.Span.EndTime}) {
+		if d := b.start.Sub(at); d > total/10 {
+			gaps = append(gaps, gap{start: at, end: b.start})
+			idle += d
+		}
+		if b.end.After(at) {
+			at = b.end
+		}
+	}
+
+	width := max((total-idle)/20, 1)
+	for i := range gaps {
+		gaps[i].width = width
+	}
+	return gaps
+}
+
+// appendGaps marks each compressed gap on the ruler.
+func (r *renderer) appendGaps(b []byte, total float64) []byte {
+	if r.opts.CSP {
+		return b
+	}
+	for _, g := range r.gaps {
+		left := 100 * r.x(g.start) / total
+		width := 100 * (r.x(g.end) - r.x(g.start)) / total
+		b = fmt.Appendf(b, `<span class="gap" style="left: %f%%; width: %f%%" title="%s idle">⋯</span>`, left, width, FormatDuration(g.idle(), r.opts.Precision))
+	}
+	return b
+}
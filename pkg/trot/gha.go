@@ -0,0 +1,179 @@
+package trot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"time"
+)
+
+// gha is the GitHub Actions API's list of a workflow run's jobs
+// (/repos/{owner}/{repo}/actions/runs/{run_id}/jobs): a span per job and
+// step, under one for the run.
+type gha struct{}
+
+func (gha) Name() string {
+	return "gha"
+}
+
+func (gha) Sniff(peek []byte) bool {
+	return bytes.Contains(peek, []byte(`"jobs"`)) && bytes.Contains(peek, []byte(`"run_id"`))
+}
+
+type ghaJobs struct {
+	Jobs []struct {
+		ID           int64     `json:"id"`
+		RunID        int64     `json:"run_id"`
+		RunAttempt   int       `json:"run_attempt"`
+		WorkflowName string    `json:"workflow_name"`
+		Name         string    `json:"name"`
+		Conclusion   string    `json:"conclusion"`
+		HTMLURL      string    `json:"html_url"`
+		RunnerName   string    `json:"runner_name"`
+		CreatedAt    time.Time `json:"created_at"`
+		StartedAt    time.Time `json:"started_at"`
+		CompletedAt  time.Time `json:"completed_at"`
+		Steps        []struct {
+			Name        string    `json:"name"`
+			Number      int       `json:"number"`
+			Conclusion  string    `json:"conclusion"`
+			StartedAt   time.Time `json:"started_at"`
+			CompletedAt time.Time `json:"completed_at"`
+		} `json:"steps"`
+	} `json:"jobs"`
+}
+
+func (gha) Decode(r io.Reader, t *Trace) error {
+	dec := json.NewDecoder(r)
+	for i := 1; ; i++ {
+		var doc ghaJobs
+		start := dec.InputOffset()
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("document %d: %w", i, valueOffset(start, err))
+		}
+
+		runs := map[int64]*Span{}
+		for _, job := range doc.Jobs {
+			tid := ghaTraceID(job.RunID, job.RunAttempt)
+			run, ok := runs[job.RunID]
+			if !ok {
+				run = &Span{Name: job.WorkflowName, SpanKind: KindInternal}
+				if run.Name == "" {
+					run.Name = fmt.Sprintf("run %d", job.RunID)
+				}
+				run.SpanContext = SpanContext{TraceID: tid, SpanID: stableID(fmt.Sprintf("run %d %d", job.RunID, job.RunAttempt))}
+				run.Parent.SpanID = RootID
+				run.Resource = []KeyValue{keyValue("service.name", run.Name)}
+				run.Status.Code = "Unset"
+				run.Attributes = rawJSON([]KeyValue{keyValue("gha.run_id", job.RunID), keyValue("gha.run_attempt", job.RunAttempt)})
+				runs[job.RunID] = run
+			}
+
+			// Jobs that never ran have nothing to show.
+			if job.StartedAt.IsZero() {
+				continue
+			}
+			end := job.CompletedAt
+			if end.IsZero() {
+				end = job.StartedAt
+			}
+			created := job.CreatedAt
+			if created.IsZero() || created.After(job.StartedAt) {
+				created = job.StartedAt
+			}
+			if run.StartTime.IsZero() || created.Before(run.StartTime) {
+				run.StartTime = created
+			}
+			if end.After(run.EndTime) {
+				run.EndTime = end
+			}
+			resource := run.Resource
+
+			span := &Span{
+				Name:      job.Name,
+				SpanKind:  KindInternal,
+				StartTime: created,
+				EndTime:   end,
+				Resource:  resource,
+			}
+			span.SpanContext = SpanContext{TraceID: tid, SpanID: stableID(fmt.Sprintf("job %d", job.ID))}
+			span.Parent.TraceID, span.Parent.SpanID = tid, run.SpanContext.SpanID
+			ghaStatus(span, job.Conclusion)
+			if span.IsError() {
+				run.Status.Code = "Error"
+			}
+			attrs := []KeyValue{keyValue("gha.job_id", job.ID), keyValue("gha.conclusion", job.Conclusion), keyValue("url.full", job.HTMLURL)}
+			if job.RunnerName != "" {
+				attrs = append(attrs, keyValue("gha.runner_name", job.RunnerName))
+			}
+			span.Attributes = rawJSON(attrs)
+			t.Add(span)
+
+			// Waiting for a runner is often most of a job's latency.
+			if job.StartedAt.After(created) {
+				queued := &Span{
+					Name:      "queued",
+					SpanKind:  KindInternal,
+					StartTime: created,
+					EndTime:   job.StartedAt,
+					Resource:  resource,
+				}
+				queued.SpanContext = SpanContext{TraceID: tid, SpanID: stableID(fmt.Sprintf("queued %d", job.ID))}
+				queued.Parent.TraceID, queued.Parent.SpanID = tid, span.SpanContext.SpanID
+				queued.Status.Code = "Unset"
+				t.Add(queued)
+			}
+
+			for _, step := range job.Steps {
+				if step.StartedAt.IsZero() {
+					continue
+				}
+				s := &Span{
+					Name:      step.Name,
+					SpanKind:  KindInternal,
+					StartTime: step.StartedAt,
+					EndTime:   step.CompletedAt,
+					Resource:  resource,
+				}
+				if s.EndTime.IsZero() {
+					s.EndTime = s.StartTime
+				}
+				s.SpanContext = SpanContext{TraceID: tid, SpanID: stableID(fmt.Sprintf("step %d %d", job.ID, step.Number))}
+				s.Parent.TraceID, s.Parent.SpanID = tid, span.SpanContext.SpanID
+				ghaStatus(s, step.Conclusion)
+				s.Attributes = rawJSON([]KeyValue{keyValue("gha.step_number", step.Number), keyValue("gha.conclusion", step.Conclusion)})
+				t.Add(s)
+			}
+		}
+
+		for _, run := range runs {
+			if !run.StartTime.IsZero() {
+				t.Add(run)
+			}
+		}
+	}
+}
+
+func ghaStatus(s *Span, conclusion string) {
+	s.Status.Code = "Unset"
+	switch conclusion {
+	case "failure", "timed_out":
+		s.Status.Code = "Error"
+		s.Status.Description = conclusion
+	case "success":
+		s.Status.Code = "Ok"
+	}
+}
+
+// ghaTraceID is a stable TraceID for an attempt at a workflow run.
+func ghaTraceID(run int64, attempt int) string {
+	h := fnv.New128a()
+	fmt.Fprintf(h, "run %d %d", run, attempt)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
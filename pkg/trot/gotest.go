@@ -0,0 +1,162 @@
+package trot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+	"time"
+)
+
+// gotest is the output of go test -json: a span per package, test, and
+// subtest, under one span for the whole run.
+type gotest struct{}
+
+func (gotest) Name() string {
+	return "gotest"
+}
+
+func (gotest) Sniff(peek []byte) bool {
+	peek = bytes.TrimLeft(peek, " \t\r\n")
+	return len(peek) != 0 && peek[0] == '{' && bytes.Contains(peek, []byte(`"Action":`)) && bytes.Contains(peek, []byte(`"Package":`))
+}
+
+type testEvent struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Output  string
+}
+
+// testRun is a package or test that has started.
+type testRun struct {
+	span   *Span
+	output []Event
+}
+
+func (gotest) Decode(r io.Reader, t *Trace) error {
+	root := &Span{Name: "go test", SpanKind: KindInternal}
+	root.SpanContext.SpanID = stableID(" ")
+	root.Parent.SpanID = RootID
+	root.Status.Code = "Unset"
+	root.Resource = []KeyValue{keyValue("service.name", "go test")}
+
+	runs := map[string]*testRun{}
+	order := []*testRun{}
+	// start finds or begins the span for e's package or test.
+	var start func(e testEvent) *testRun
+	start = func(e testEvent) *testRun {
+		key := e.Package + " " + e.Test
+		if run, ok := runs[key]; ok {
+			return run
+		}
+		span := &Span{
+			Name:      e.Package,
+			SpanKind:  KindInternal,
+			StartTime: e.Time,
+		}
+		span.SpanContext.SpanID = stableID(key)
+		span.Parent.SpanID = root.SpanContext.SpanID
+		if e.Test != "" {
+			span.Parent.SpanID = stableID(e.Package + " " + parentTest(e.Test))
+		}
+		span.Status.Code = "Unset"
+		attrs := []KeyValue{keyValue("go.package", e.Package)}
+		if e.Test != "" {
+			span.Name = e.Test[strings.LastIndex(e.Test, "/")+1:]
+			attrs = append(attrs, keyValue("go.test", e.Test))
+		}
+		span.Attributes = rawJSON(attrs)
+		span.Resource = []KeyValue{keyValue("service.name", e.Package)}
+
+		run := &testRun{span: span}
+		runs[key] = run
+		order = append(order, run)
+
+		// Make sure every ancestor exists, in case the input starts mid-run.
+		if e.Test != "" {
+			start(testEvent{Time: e.Time, Package: e.Package, Test: parentTest(e.Test)})
+		}
+		return run
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 16<<20)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		var e testEvent
+		// Build errors and the like aren't JSON; skip them.
+		if len(line) == 0 || line[0] != '{' || json.Unmarshal(line, &e) != nil || e.Package == "" {
+			continue
+		}
+
+		if root.StartTime.IsZero() || e.Time.Before(root.StartTime) {
+			root.StartTime = e.Time
+		}
+		if e.Time.After(root.EndTime) {
+			root.EndTime = e.Time
+		}
+
+		run := start(e)
+		switch e.Action {
+		case "output":
+			run.output = append(run.output, Event{
+				Name:       "log",
+				Time:       e.Time,
+				Attributes: []KeyValue{keyValue("message", strings.TrimRight(e.Output, "\n"))},
+			})
+		case "pause", "cont":
+			run.span.Events = rawJSON(append(run.span.DecodeEvents(), Event{Name: e.Action, Time: e.Time}))
+		case "pass", "fail", "skip":
+			run.span.EndTime = e.Time
+			run.span.Attributes = rawJSON(append(run.span.Attrs(), keyValue("go.test.result", e.Action)))
+			if e.Action == "fail" {
+				run.span.Status.Code = "Error"
+				root.Status.Code = "Error"
+			}
+			// Output is noise unless something went wrong.
+			if e.Action != "pass" && len(run.output) != 0 {
+				run.span.Events = rawJSON(append(run.span.DecodeEvents(), run.output...))
+			}
+			run.output = nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if len(order) == 0 {
+		return nil
+	}
+
+	// The same run always gets the same TraceID.
+	h := fnv.New128a()
+	h.Write([]byte(root.StartTime.Format(time.RFC3339Nano) + " " + order[0].span.Name))
+	tid := fmt.Sprintf("%x", h.Sum(nil))
+	root.SpanContext.TraceID = tid
+	t.Add(root)
+
+	for _, run := range order {
+		span := run.span
+		// Tests that never finished, e.g. from a panic or timeout, end
+		// with the run.
+		if span.EndTime.IsZero() {
+			span.EndTime = root.EndTime
+		}
+		span.SpanContext.TraceID = tid
+		span.Parent.TraceID = tid
+		t.Add(span)
+	}
+	return nil
+}
+
+// parentTest is the test that test is a subtest of, or "" for the package.
+func parentTest(test string) string {
+	if i := strings.LastIndex(test, "/"); i >= 0 {
+		return test[:i]
+	}
+	return ""
+}
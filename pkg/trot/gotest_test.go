@@ -0,0 +1,48 @@
+package trot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGoTest(t *testing.T) {
+	in := `{"Time":"2024-01-01T00:00:00Z","Action":"start","Package":"example.com/p"}
+{"Time":"2024-01-01T00:00:01Z","Action":"run","Package":"example.com/p","Test":"TestA"}
+{"Time":"2024-01-01T00:00:01Z","Action":"run","Package":"example.com/p","Test":"TestA/sub"}
+{"Time":"2024-01-01T00:00:02Z","Action":"output","Package":"example.com/p","Test":"TestA/sub","Output":"    a_test.go:9: boom\n"}
+{"Time":"2024-01-01T00:00:02Z","Action":"fail","Package":"example.com/p","Test":"TestA/sub"}
+{"Time":"2024-01-01T00:00:03Z","Action":"fail","Package":"example.com/p","Test":"TestA"}
+# example.com/q
+q.go:1: syntax error
+{"Time":"2024-01-01T00:00:04Z","Action":"fail","Package":"example.com/p"}
+`
+	tr, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := tr.Tree("root", RootID).Children
+	if len(root) != 1 || root[0].Span.Name != "go test" {
+		t.Fatalf("roots = %s, want go test", names(root))
+	}
+	pkg := root[0].Children
+	if got, want := names(pkg), "example.com/p"; got != want {
+		t.Fatalf("packages = %s, want %s", got, want)
+	}
+	if got, want := names(pkg[0].Children), "TestA"; got != want {
+		t.Fatalf("tests = %s, want %s", got, want)
+	}
+	sub := pkg[0].Children[0].Children
+	if got, want := names(sub), "sub"; got != want {
+		t.Fatalf("subtests = %s, want %s", got, want)
+	}
+	if d := sub[0].Span.Duration().Seconds(); d != 1 {
+		t.Errorf("sub took %vs, want 1s", d)
+	}
+	if !sub[0].Span.IsError() {
+		t.Errorf("failed subtest isn't an error")
+	}
+	if logs := sub[0].Span.logs(); len(logs) != 1 {
+		t.Errorf("failed subtest has %d log lines, want 1", len(logs))
+	}
+}
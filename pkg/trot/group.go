@@ -0,0 +1,85 @@
+package trot
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// GroupBy returns a copy of the tree under n where spans whose key differs
+// from their parent's are gathered under a synthetic span named for the key,
+// one per distinct value, e.g. to put a worker pool's spans in a lane per
+// worker. Spans with an empty key stay where they are.
+func (n *Node) GroupBy(key func(*Span) string) *Node {
+	out := &Node{Span: n.Span}
+
+	type frame struct{ in, out *Node }
+	stack := []frame{{n, out}}
+	for len(stack) != 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		parent := key(f.in.Span)
+		groups := map[string]*Node{}
+		for _, kid := range f.in.Children {
+			k := &Node{Span: kid.Span}
+			stack = append(stack, frame{kid, k})
+
+			v := key(kid.Span)
+			if v == "" || v == parent {
+				f.out.Children = append(f.out.Children, k)
+				continue
+			}
+			g, ok := groups[v]
+			if !ok {
+				g = &Node{Span: &Span{Name: v, StartTime: kid.Span.StartTime, EndTime: kid.Span.EndTime}}
+				groups[v] = g
+				f.out.Children = append(f.out.Children, g)
+			}
+			if kid.Span.EndTime.After(g.Span.EndTime) {
+				g.Span.EndTime = kid.Span.EndTime
+			}
+			g.Children = append(g.Children, k)
+		}
+	}
+	return out
+}
+
+var groupByRE = regexp.MustCompile(`^(attr|resource)\((.+)\)$`)
+
+// ParseGroupBy parses a key for GroupBy: attr(key) or resource(key) for an
+// attribute, or service or scope. Keys name their value, e.g. "thread.id=3".
+func ParseGroupBy(s string) (func(*Span) string, error) {
+	switch s {
+	case "service":
+		return func(s *Span) string {
+			return "service=" + s.Service()
+		}, nil
+	case "scope":
+		return func(s *Span) string {
+			if scope := s.Scope(); scope != "" {
+				return "scope=" + scope
+			}
+			return ""
+		}, nil
+	}
+
+	m := groupByRE.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("group by %q: want attr(key), resource(key), service, or scope", s)
+	}
+	key := m[2]
+	if m[1] == "resource" {
+		return func(s *Span) string {
+			if v, ok := lookup(s.Resource, key); ok {
+				return key + "=" + v
+			}
+			return ""
+		}, nil
+	}
+	return func(s *Span) string {
+		if v, ok := s.Attr(key); ok {
+			return key + "=" + v
+		}
+		return ""
+	}, nil
+}
@@ -0,0 +1,163 @@
+package trot
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Handler serves an index of the traces in store at "/" and each trace at "/<TraceID>".
+//
+// To mount it elsewhere, strip the prefix:
+//
+//	mux.Handle("/debug/traces/", http.StripPrefix("/debug/traces", trot.Handler(store)))
+func Handler(store Store) http.Handler {
+	return &handler{store: store}
+}
+
+// BaselineHandler is Handler, but rendering pages with opts, and POSTing to
+// "/<TraceID>/baseline" makes that trace the baseline for its name, and the
+// index and the other traces with that name show how they compare to it.
+func BaselineHandler(store Store, baselines *Baselines, opts Options) http.Handler {
+	return &handler{store: store, baselines: baselines, opts: opts}
+}
+
+type handler struct {
+	store     Store
+	baselines *Baselines
+	opts      Options
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if id, ok := strings.CutSuffix(strings.Trim(r.URL.Path, "/"), "/baseline"); ok && h.baselines != nil && r.Method == http.MethodPost {
+		h.setBaseline(w, r, id)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.Trim(r.URL.Path, "/")
+	if id == "" {
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			// Relative links in the index need a trailing slash.
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+		h.index(w)
+		return
+	}
+
+	t, ok := h.store.Get(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	opts := h.opts
+	if h.baselines != nil {
+		if base, ok := h.baselines.Get(t.Summarize().Name); ok {
+			opts.Baseline = base
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := RenderHTML(w, t, opts); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *handler) setBaseline(w http.ResponseWriter, r *http.Request, id string) {
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request", http.StatusForbidden)
+		return
+	}
+	t, ok := h.store.Get(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if err := h.baselines.Set(t); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Back to the index, relative to "/<TraceID>/baseline".
+	http.Redirect(w, r, "../", http.StatusSeeOther)
+}
+
+// sameOrigin reports whether r came from one of our own pages, or from
+// outside a browser, rather than from another site's form.
+func sameOrigin(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "same-origin", "none":
+		return true
+	case "":
+		// Older browsers only send Origin.
+	default:
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+func (h *handler) index(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	renderIndex(w, h.store.List(), func(s Summary) string { return s.TraceID }, h.baselines, h.opts)
+}
+
+// RenderIndex writes a page listing summaries, linking each to
+// "./<PageName><ext>".
+func RenderIndex(w io.Writer, summaries []Summary, ext string) error {
+	return renderIndex(w, summaries, func(s Summary) string { return PageName(s.TraceID) + ext }, nil, Options{})
+}
+
+// renderIndex is RenderIndex, linking each trace to "./<page(s)>", with a
+// column comparing each trace to its baseline, and a button to make it the
+// baseline, if baselines is set.
+func renderIndex(w io.Writer, summaries []Summary, page func(Summary) string, baselines *Baselines, opts Options) error {
+	opts.Title = "traces"
+	writeHeader(w, opts)
+	fmt.Fprint(w, `<table><tr><th>name</th><th>trace</th><th>start</th><th>duration</th><th>spans</th><th>errors</th><th>database</th>`)
+	if baselines != nil {
+		fmt.Fprint(w, `<th>vs baseline</th>`)
+	}
+	fmt.Fprint(w, `</tr>`)
+	for _, s := range summaries {
+		fmt.Fprintf(w, `<tr><td><a href="./%s">%s</a></td><td>%s</td><td>%s</td><td>%s</td><td>%d</td><td>%d</td><td>%s</td>`,
+			url.PathEscape(page(s)), html.EscapeString(s.Name), html.EscapeString(s.TraceID),
+			s.Start.Format(time.RFC3339), opts.duration(s.Duration), s.Spans, s.Errors, opts.duration(s.Database))
+		if baselines != nil {
+			writeBaselineCell(w, s, baselines)
+		}
+		fmt.Fprintln(w, `</tr>`)
+	}
+	fmt.Fprint(w, `</table>`)
+	writeFooter(w)
+	return nil
+}
+
+func writeBaselineCell(w io.Writer, s Summary, baselines *Baselines) {
+	if baselines.isBaseline(s) {
+		fmt.Fprint(w, `<td>baseline</td>`)
+		return
+	}
+	d, ok := baselines.Compare(s)
+	if d.Regressed {
+		fmt.Fprint(w, `<td class="regression">`)
+	} else {
+		fmt.Fprint(w, `<td>`)
+	}
+	if ok {
+		fmt.Fprintf(w, `%s `, html.EscapeString(d.String()))
+	}
+	fmt.Fprintf(w, `<form method="post" action="./%s/baseline"><button>make baseline</button></form></td>`, url.PathEscape(s.TraceID))
+}
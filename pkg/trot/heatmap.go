@@ -0,0 +1,89 @@
+package trot
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"time"
+)
+
+// heatmapBuckets is how many columns a Heatmap divides a trace's time into.
+const heatmapBuckets = 60
+
+// Heatmap counts how many spans each service started in each of equal
+// slices of a trace, which makes retry storms and thundering herds stand out.
+type Heatmap struct {
+	Start    time.Time
+	Bucket   time.Duration
+	Services []string
+	// Counts are by service, then bucket.
+	Counts [][]int
+	Max    int
+}
+
+// Heatmap buckets the start times of t's spans by service.
+func (t *Trace) Heatmap(buckets int) Heatmap {
+	h := Heatmap{}
+	var end time.Time
+	for _, span := range t.Spans {
+		if span.StartTime.IsZero() {
+			continue
+		}
+		if h.Start.IsZero() || span.StartTime.Before(h.Start) {
+			h.Start = span.StartTime
+		}
+		if span.StartTime.After(end) {
+			end = span.StartTime
+		}
+	}
+	// Round up, so the last start lands in the last bucket.
+	h.Bucket = end.Sub(h.Start)/time.Duration(buckets) + 1
+
+	rows := map[string][]int{}
+	for _, span := range t.Spans {
+		// Spans without a start time have nowhere to go.
+		if span.StartTime.IsZero() {
+			continue
+		}
+		svc := span.Service()
+		row, ok := rows[svc]
+		if !ok {
+			row = make([]int, buckets)
+			rows[svc] = row
+			h.Services = append(h.Services, svc)
+		}
+		// Sub saturates for spans centuries apart, which can land past the end.
+		i := min(max(int(span.StartTime.Sub(h.Start)/h.Bucket), 0), buckets-1)
+		row[i]++
+		h.Max = max(h.Max, row[i])
+	}
+	sort.Strings(h.Services)
+	for _, svc := range h.Services {
+		h.Counts = append(h.Counts, rows[svc])
+	}
+	return h
+}
+
+func writeHeatmap(w io.Writer, h Heatmap, opts Options) {
+	if h.Max < 2 {
+		// Nothing ever started at once.
+		return
+	}
+
+	fmt.Fprintf(w, `<details class="heatmap"><summary>span starts by service (busiest: %d in %s)</summary><table>`, h.Max, opts.duration(h.Bucket))
+	for i, svc := range h.Services {
+		row := h.Counts[i]
+		fmt.Fprintf(w, `<tr><th>%s</th><td><svg viewBox="0 0 %d 1" preserveAspectRatio="none">`, html.EscapeString(svc), len(row))
+		for j, n := range row {
+			if n == 0 {
+				continue
+			}
+			at := time.Duration(j) * h.Bucket
+			fmt.Fprintf(w, `<rect x="%d" width="1" height="1" fill-opacity="%.2f"><title>%d spans started at +%s to +%s</title></rect>`,
+				j, 0.15+0.85*float64(n)/float64(h.Max), n, opts.duration(at), opts.duration(at+h.Bucket))
+		}
+		fmt.Fprint(w, `</svg></td></tr>`)
+	}
+	fmt.Fprintln(w, `</table></details>`)
+}
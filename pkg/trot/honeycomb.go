@@ -0,0 +1,153 @@
+package trot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// honeycomb is events exported from Honeycomb: a JSON list of them, one per
+// line, or wrapped as {"time": ..., "data": {...}} like the events API.
+// Span events (meta.annotation_type span_event) go on their spans.
+type honeycomb struct{}
+
+func (honeycomb) Name() string {
+	return "honeycomb"
+}
+
+func (honeycomb) Sniff(peek []byte) bool {
+	return bytes.Contains(peek, []byte(`"trace.trace_id"`))
+}
+
+// honeycombFields are the columns that become parts of a Span rather than
+// attributes.
+var honeycombFields = map[string]bool{
+	"trace.trace_id": true, "trace.span_id": true, "trace.parent_id": true,
+	"name": true, "service.name": true, "service_name": true, "duration_ms": true,
+	"Timestamp": true, "timestamp": true, "time": true, "span.kind": true,
+	"error": true, "status_code": true, "status_message": true,
+	"otel.status_code": true, "otel.status_description": true,
+	"meta.annotation_type": true,
+}
+
+func (honeycomb) Decode(r io.Reader, t *Trace) error {
+	events := map[string][]Event{}
+	spans := []*Span{}
+
+	dec := json.NewDecoder(r)
+	for i := 1; ; i++ {
+		var raw json.RawMessage
+		start := dec.InputOffset()
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("document %d: %w", i, valueOffset(start, err))
+		}
+
+		var rows []map[string]any
+		if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+			if err := json.Unmarshal(raw, &rows); err != nil {
+				return fmt.Errorf("document %d: %w", i, atOffset(start, err))
+			}
+		} else {
+			var row map[string]any
+			if err := json.Unmarshal(raw, &row); err != nil {
+				return fmt.Errorf("document %d: %w", i, atOffset(start, err))
+			}
+			rows = append(rows, row)
+		}
+
+		for _, row := range rows {
+			if data, ok := row["data"].(map[string]any); ok {
+				if _, ok := data["time"]; !ok {
+					data["time"] = row["time"]
+				}
+				row = data
+			}
+			str := func(key string) string {
+				s, _ := row[key].(string)
+				return s
+			}
+
+			tid, sid, parent := str("trace.trace_id"), str("trace.span_id"), str("trace.parent_id")
+			if tid == "" {
+				continue
+			}
+			ts := str("Timestamp")
+			for _, key := range []string{"timestamp", "time"} {
+				if ts == "" {
+					ts = str(key)
+				}
+			}
+			when, err := time.Parse(time.RFC3339Nano, ts)
+			if err != nil {
+				return fmt.Errorf("document %d: trace %s span %s: timestamp: %w", i, tid, sid, err)
+			}
+			ms, _ := row["duration_ms"].(float64)
+
+			attrs := []KeyValue{}
+			keys := make([]string, 0, len(row))
+			for k := range row {
+				if !honeycombFields[k] && row[k] != nil {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				attrs = append(attrs, keyValue(k, row[k]))
+			}
+
+			if str("meta.annotation_type") == "span_event" {
+				events[tid+" "+parent] = append(events[tid+" "+parent], Event{Name: str("name"), Time: when, Attributes: attrs})
+				continue
+			} else if str("meta.annotation_type") != "" {
+				// Links have nothing to show on their own.
+				continue
+			}
+
+			span := &Span{
+				Name:      str("name"),
+				SpanKind:  kindOf(str("span.kind")),
+				StartTime: when,
+				EndTime:   when.Add(time.Duration(ms * float64(time.Millisecond))),
+			}
+			span.SpanContext = SpanContext{TraceID: tid, SpanID: sid}
+			span.Parent.TraceID, span.Parent.SpanID = tid, parent
+			if parent == "" {
+				span.Parent.SpanID = RootID
+			}
+			span.Status.Code = "Unset"
+			code, _ := row["status_code"].(float64)
+			if row["error"] == true || code == 2 || strings.EqualFold(str("otel.status_code"), "error") {
+				span.Status.Code = "Error"
+			}
+			span.Status.Description = str("status_message") + str("otel.status_description")
+			service := str("service.name")
+			if service == "" {
+				service = str("service_name")
+			}
+			span.Resource = []KeyValue{keyValue("service.name", service)}
+			if len(attrs) != 0 {
+				span.Attributes = rawJSON(attrs)
+			}
+			spans = append(spans, span)
+		}
+	}
+
+	for _, span := range spans {
+		if evs := events[span.SpanContext.TraceID+" "+span.SpanContext.SpanID]; len(evs) != 0 {
+			sort.SliceStable(evs, func(i, j int) bool {
+				return evs[i].Time.Before(evs[j].Time)
+			})
+			span.Events = rawJSON(evs)
+		}
+		t.Add(span)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package trot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHoneycomb(t *testing.T) {
+	in := `[
+{"Timestamp":"2024-01-01T00:00:00Z","trace.trace_id":"t1","trace.span_id":"a","name":"GET /","service.name":"web","duration_ms":20,"span.kind":"server","http.route":"/"},
+{"Timestamp":"2024-01-01T00:00:00.005Z","trace.trace_id":"t1","trace.span_id":"b","trace.parent_id":"a","name":"query","service.name":"db","duration_ms":10.5,"error":true,"status_message":"timeout"},
+{"Timestamp":"2024-01-01T00:00:00.008Z","trace.trace_id":"t1","trace.parent_id":"b","name":"retry","meta.annotation_type":"span_event","attempt":2}
+]`
+	tr, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := tr.Tree("root", RootID).Children
+	if got, want := names(root), "GET /"; got != want {
+		t.Fatalf("roots = %s, want %s", got, want)
+	}
+	get := root[0].Span
+	if get.SpanKind != KindServer {
+		t.Errorf("kind = %d, want server", get.SpanKind)
+	}
+	if v, _ := get.Attr("http.route"); v != "/" {
+		t.Errorf("http.route = %q, want /", v)
+	}
+	query := root[0].Children[0].Span
+	if got, want := query.Duration(), 10500*time.Microsecond; got != want {
+		t.Errorf("query took %v, want %v", got, want)
+	}
+	if !query.IsError() || query.Status.Description != "timeout" {
+		t.Errorf("query status = %+v, want a timeout error", query.Status)
+	}
+	if events := query.DecodeEvents(); len(events) != 1 || events[0].Name != "retry" {
+		t.Errorf("query events = %+v, want retry", events)
+	}
+}
@@ -0,0 +1,1112 @@
+package trot
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type Options struct {
+	// Title is the page title. If empty, RenderHTML names the page after the
+	// trace (see rootName) and Page calls it "trot".
+	Title string
+
+	// Refresh, if set, makes the page reload itself this often.
+	Refresh time.Duration
+
+	// Theme is "light" (the default) or "dark".
+	Theme string
+
+	// Expand is how many levels below the root start out expanded.
+	Expand int
+
+	// Filter, if set, hides spans that neither match it nor have a descendant that does.
+	Filter func(*Node) bool
+
+	// Select, if set, hides spans that it neither selects nor selects a
+	// descendant of, like Filter.
+	Select *Selector
+
+	// Highlight outlines the spans it selects.
+	Highlight *Selector
+
+	// Colors are tried in order and the first rule matching a span sets its background.
+	Colors []ColorRule
+
+	// Compact trades exact span widths (rounded to 0.1%) for much smaller markup:
+	// spans get shared classes instead of inline styles, and no newlines.
+	Compact bool
+
+	// Precision is how many significant digits durations get, 3 if zero.
+	Precision int
+
+	// Location, if set, shows wall-clock times in that zone instead of
+	// offsets from the start of the trace, e.g. +1.2s.
+	Location *time.Location
+
+	// CodeURL, if set, links spans with code.* attributes to their source,
+	// with CodeRoot trimmed from the .Path it's given; see CodeLocation.URL.
+	CodeURL  *LinkTemplate
+	CodeRoot string
+
+	// GroupBy, if set, gathers spans into lanes by key; see Node.GroupBy.
+	GroupBy func(*Span) string
+
+	// Name, if set, is what to call each span instead of its name; see
+	// ParseNameTemplate.
+	Name *template.Template
+
+	// Scopes badges each span with the instrumentation library that created it.
+	Scopes bool
+
+	// Notes, keyed by SpanID, are shown on their spans; see Notes.
+	Notes map[string]string
+
+	// CSP avoids inline style attributes, so pages work under a strict
+	// Content-Security-Policy. Like Compact, it rounds span widths to 0.1%.
+	CSP bool
+
+	// Nonce, if set, is added to the page's <style> and <script> elements,
+	// for a Content-Security-Policy that allows them by nonce.
+	Nonce string
+
+	// NoScript leaves out the page's script, and with it the toolbar.
+	NoScript bool
+
+	// Links add links to each trace and span in other tools.
+	Links []LinkTemplate
+
+	// Scale is "linear" (the default) or "log", which draws spans as wide as
+	// the log of their duration, in units of the shortest span.
+	Scale string
+
+	// CompressGaps draws long stretches when no span is doing anything
+	// (longer than a tenth of the trace) much shorter, marked on the ruler.
+	CompressGaps bool
+
+	// Breakdown lists attribute keys (like http.status_code) to break the
+	// spans down by, with how many had each value and how long they took.
+	Breakdown []string
+
+	// Baseline, if set, is a trace to compare this one to by span name path;
+	// see Baselines.
+	Baseline *Trace
+
+	// Nav links the page to others, like the rest of a site, as
+	// {name, href} pairs in the order they're drawn.
+	Nav [][2]string
+}
+
+// ColorRule colors spans whose name matches Name and that took longer than Over.
+type ColorRule struct {
+	Name  *regexp.Regexp
+	Over  time.Duration
+	Color string
+}
+
+// ParseColorRule parses "regexp=color", e.g. `db\..*=orange`, optionally with
+// a threshold, e.g. `db\..*>100ms=orange` for only the slow ones.
+func ParseColorRule(s string) (ColorRule, error) {
+	i := strings.LastIndex(s, "=")
+	if i < 0 {
+		return ColorRule{}, fmt.Errorf("color rule %q: want regexp[>duration]=color", s)
+	}
+	rule := ColorRule{Color: s[i+1:]}
+	expr := s[:i]
+	if j := strings.LastIndex(expr, ">"); j >= 0 {
+		if d, err := time.ParseDuration(expr[j+1:]); err == nil {
+			expr, rule.Over = expr[:j], d
+		}
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return ColorRule{}, fmt.Errorf("color rule %q: %w", s, err)
+	}
+	rule.Name = re
+	return rule, nil
+}
+
+// RenderHTML writes t as a page of nested, collapsible spans.
+func RenderHTML(w io.Writer, t *Trace, opts Options) error {
+	r := newRenderer(w, opts)
+
+	name := rootName(t)
+	if opts.Title == "" {
+		opts.Title = name
+	}
+	writeHeader(w, opts)
+
+	writeWarnings(w, t.Warnings())
+	writeBaseline(w, t, opts)
+	writeErrors(w, t.Errors())
+	writeResources(w, t.Resources())
+	writeHeatmap(w, t.Heatmap(heatmapBuckets), opts)
+	writeBreakdowns(w, t, opts)
+
+	root := t.Tree(name, RootID)
+	// A trace of nothing but orphans is all unparented.
+	if len(root.Children) != 0 || len(t.Spans) == 0 {
+		sections := root.Sections()
+		for i, section := range sections {
+			if len(sections) > 1 {
+				section.Span.Name = sectionName(section, root, opts)
+				if i != 0 {
+					fmt.Fprint(w, `<hr class="section">`)
+				}
+			}
+			r.tree(section)
+		}
+	}
+	r.unparented(t)
+
+	r.margins()
+	writeScript(w, opts)
+	writeFooter(w)
+	return nil
+}
+
+// rootName describes t for its page: by its earliest top-level span, that
+// span's service, and its TraceID, or by how many traces it holds.
+func rootName(t *Trace) string {
+	tid := ""
+	for _, span := range t.Spans {
+		if tid == "" {
+			tid = span.SpanContext.TraceID
+		} else if span.SpanContext.TraceID != tid {
+			return fmt.Sprintf("%d traces", len(t.Split()))
+		}
+	}
+	if tid == "" {
+		return "trot"
+	}
+	s := t.Summarize()
+	name := s.Name
+	if s.Service != "" && s.Service != "unknown" {
+		name = s.Service + ": " + name
+	}
+	return name + " · " + tid
+}
+
+// sectionName names one of root's sections after its first span, and says
+// how long after the start of root it begins.
+func sectionName(section, root *Node, opts Options) string {
+	first := section.Children[0].Span
+	name := first.Name
+	if svc := first.Service(); svc != "unknown" {
+		name = svc + ": " + name
+	}
+	if n := len(section.Children); n > 1 {
+		name += fmt.Sprintf(" and %d more", n-1)
+	}
+	return name + " · +" + opts.duration(section.Span.StartTime.Sub(root.Span.StartTime))
+}
+
+// Page renders traces onto one HTML page as they arrive, one tree per trace.
+type Page struct {
+	r       *renderer
+	started bool
+}
+
+// NewPage returns a Page that writes to w.
+func NewPage(w io.Writer, opts Options) *Page {
+	return &Page{r: newRenderer(w, opts)}
+}
+
+// Add writes t's tree, labeled with its TraceID.
+func (p *Page) Add(t *Trace) error {
+	if !p.started {
+		writeHeader(p.r.w, p.r.opts)
+		p.started = true
+	}
+
+	writeWarnings(p.r.w, t.Warnings())
+	writeErrors(p.r.w, t.Errors())
+	writeResources(p.r.w, t.Resources())
+	writeHeatmap(p.r.w, t.Heatmap(heatmapBuckets), p.r.opts)
+	writeBreakdowns(p.r.w, t, p.r.opts)
+
+	if root := t.Tree(rootName(t), RootID); len(root.Children) != 0 {
+		p.r.tree(root)
+	}
+	p.r.unparented(t)
+	return nil
+}
+
+// unparented writes the spans in t whose parent is missing, after the rest,
+// each under a "Missing span" standing in for its parent.
+func (r *renderer) unparented(t *Trace) {
+	missing, spans := []string{}, 0
+	for _, id := range t.Missing() {
+		if id != RootID {
+			missing = append(missing, id)
+			spans += len(t.Children[id])
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	fmt.Fprintf(r.w, `<details open class="unparented"><summary>%d unparented spans, whose parents are missing</summary>`, spans)
+	for _, id := range missing {
+		r.tree(t.Tree("Missing span", id))
+	}
+	fmt.Fprintln(r.w, `</details>`)
+}
+
+// Close finishes the page.
+func (p *Page) Close() error {
+	if !p.started {
+		writeHeader(p.r.w, p.r.opts)
+	}
+	p.r.margins()
+	writeScript(p.r.w, p.r.opts)
+	writeFooter(p.r.w)
+	return nil
+}
+
+// nonce is the attribute to add to <style> and <script> elements.
+func (o Options) nonce() string {
+	if o.Nonce == "" {
+		return ""
+	}
+	return ` nonce="` + html.EscapeString(o.Nonce) + `"`
+}
+
+func writeHeader(w io.Writer, opts Options) {
+	title := opts.Title
+	if title == "" {
+		title = "trot"
+	}
+	// Pages are mostly opened from disk, with no Content-Type to say they're
+	// UTF-8, and names and titles aren't all ASCII.
+	fmt.Fprintf(w, "\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>", html.EscapeString(title))
+	if opts.Refresh > 0 {
+		fmt.Fprintf(w, `<meta http-equiv="refresh" content="%d">`, int(opts.Refresh.Seconds()+0.5))
+	}
+	fmt.Fprint(w, strings.Replace(style, "<style>", "<style"+opts.nonce()+">", 1))
+	if opts.Theme == "dark" {
+		fmt.Fprint(w, "\n</head>\n<body class=\"dark\">")
+	} else {
+		fmt.Fprint(w, "\n</head>\n<body>")
+	}
+	if len(opts.Nav) != 0 {
+		fmt.Fprint(w, "\n<nav>")
+		for _, link := range opts.Nav {
+			fmt.Fprintf(w, `<a href="%s">%s</a> `, html.EscapeString(link[1]), html.EscapeString(link[0]))
+		}
+		fmt.Fprint(w, "</nav>")
+	}
+}
+
+func writeFooter(w io.Writer) {
+	fmt.Fprint(w, footer)
+}
+
+type renderer struct {
+	w    io.Writer
+	opts Options
+
+	// start is when the tree being rendered starts, for relative times.
+	start time.Time
+
+	// total is how long the tree being rendered takes, for percentages.
+	total time.Duration
+
+	// gaps are the idle stretches of the tree being rendered that
+	// CompressGaps draws shorter; see x.
+	gaps []gap
+
+	// unit, for a Log scale, is the duration that spans are drawn as wide as
+	// the log of how many of it they take; see pads.
+	unit time.Duration
+
+	buf []byte
+
+	namer *namer
+
+	// descendants are shown on collapsed parents.
+	descendants map[*Node]Descendants
+
+	// handoffs are the messages passed between spans in the tree, keyed by
+	// consumer, and produced marks their producers, which get anchors.
+	handoffs map[*Span]Handoff
+	produced map[*Span]bool
+
+	// left and right record which margin classes Compact used, in 0.1% steps.
+	left, right [1001]bool
+
+	// colored records which color rules CSP used classes for.
+	colored []bool
+
+	// highlighted are the spans Highlight selects in the tree being rendered.
+	highlighted map[*Span]bool
+}
+
+func spanSet(spans []*Span) map[*Span]bool {
+	set := make(map[*Span]bool, len(spans))
+	for _, span := range spans {
+		set[span] = true
+	}
+	return set
+}
+
+func newRenderer(w io.Writer, opts Options) *renderer {
+	r := &renderer{w: w, opts: opts}
+	if opts.Name != nil {
+		r.namer = newNamer(opts.Name)
+	}
+	return r
+}
+
+// bucket rounds a fraction to the nearest 0.1%.
+func bucket(f float64) int {
+	return min(max(int(math.Round(f*1000)), 0), 1000)
+}
+
+// margins defines the margin classes that Compact spans used.
+// Browsers apply a <style> anywhere in the page, so it can come last.
+func (r *renderer) margins() {
+	if !r.opts.Compact && !r.opts.CSP {
+		return
+	}
+	fmt.Fprintf(r.w, "<style%s>div>div{margin-top:1px}p{border:1px solid;margin:1px 0 0;padding:3px;white-space:nowrap}body.dark p{border-color:#555}", r.opts.nonce())
+	for i, used := range r.colored {
+		if used {
+			fmt.Fprintf(r.w, ".c%d{background-color:%s}", i, cssValue(r.opts.Colors[i].Color))
+		}
+	}
+	for i, used := range r.left {
+		if used {
+			fmt.Fprintf(r.w, ".l%d{margin-left:%g%%}", i, float64(i)/10)
+		}
+	}
+	for i, used := range r.right {
+		if used {
+			fmt.Fprintf(r.w, ".r%d{margin-right:%g%%}", i, float64(i)/10)
+		}
+	}
+	fmt.Fprint(r.w, "</style>")
+}
+
+// eol ends a span's markup.
+func (r *renderer) eol() {
+	if !r.opts.Compact {
+		fmt.Fprintln(r.w)
+	}
+}
+
+func (r *renderer) tree(root *Node) {
+	if r.opts.Filter != nil || r.opts.Select != nil {
+		pred := r.opts.Filter
+		if r.opts.Select != nil {
+			selected := spanSet(root.Select(r.opts.Select))
+			pred = func(n *Node) bool {
+				return selected[n.Span] && (r.opts.Filter == nil || r.opts.Filter(n))
+			}
+		}
+		filtered := root.Filter(pred)
+		if filtered == nil {
+			filtered = &Node{Span: root.Span}
+		}
+		root = filtered
+	}
+	if r.opts.GroupBy != nil {
+		root = root.GroupBy(r.opts.GroupBy)
+	}
+
+	r.start = root.Span.StartTime
+	r.total = root.Span.Duration()
+	r.gaps = nil
+	if r.opts.CompressGaps {
+		r.gaps = idleGaps(root)
+	}
+	r.unit = 0
+	if r.opts.Scale == "log" {
+		r.unit = shortest(root)
+	}
+	r.highlighted = nil
+	if r.opts.Highlight != nil {
+		r.highlighted = spanSet(root.Select(r.opts.Highlight))
+	}
+	r.descendants = root.Descendants()
+	r.traceLinks(root)
+	r.handoffs = root.Handoffs()
+	r.produced = map[*Span]bool{}
+	for _, h := range r.handoffs {
+		r.produced[h.Producer] = true
+	}
+	r.ruler(root)
+	r.span(nil, root, 0)
+}
+
+// rulerTicks matches the div.ruler span.tN rules in style.
+const rulerTicks = 5
+
+// ruler labels evenly spaced times across root.
+func (r *renderer) ruler(root *Node) {
+	fmt.Fprint(r.w, `<div class="ruler">`)
+	total := r.x(root.Span.EndTime)
+	r.buf = r.appendGaps(r.buf[:0], total)
+	r.w.Write(r.buf)
+	for i := 0; i <= rulerTicks; i++ {
+		at := r.at(total * float64(i) / rulerTicks)
+		if i == rulerTicks {
+			fmt.Fprintf(r.w, `<span class="last">%s</span>`, r.appendAt(nil, at))
+		} else {
+			fmt.Fprintf(r.w, `<span class="t%d">%s</span>`, i, r.appendAt(nil, at))
+		}
+	}
+	fmt.Fprint(r.w, `</div>`)
+	r.eol()
+}
+
+// traceLinks writes Links for every trace in the tree.
+func (r *renderer) traceLinks(root *Node) {
+	if len(r.opts.Links) == 0 {
+		return
+	}
+	seen := map[string]bool{}
+	b := append(r.buf[:0], `<div class="links">`...)
+	for _, kid := range root.Children {
+		tid := kid.Span.SpanContext.TraceID
+		if seen[tid] {
+			continue
+		}
+		seen[tid] = true
+		b = append(b, html.EscapeString(tid)...)
+		for _, l := range r.opts.Links {
+			b = appendLink(b, l.Name, l.Trace(tid))
+		}
+		b = append(b, ' ')
+	}
+	b = append(b, `</div>`...)
+	r.w.Write(b)
+	r.buf = b
+}
+
+// appendAt formats t per opts.Location.
+func (r *renderer) appendAt(b []byte, t time.Time) []byte {
+	if r.opts.Location != nil {
+		return t.In(r.opts.Location).AppendFormat(b, "15:04:05.000")
+	}
+	return appendDuration(append(b, '+'), t.Sub(r.start), r.opts.Precision)
+}
+
+// appendTitle adds a tooltip with node's start and end times.
+func (r *renderer) appendTitle(b []byte, node *Node) []byte {
+	if r.opts.Compact {
+		return b
+	}
+	b = append(b, ` title="`...)
+	if r.opts.Location != nil {
+		b = node.Span.StartTime.In(r.opts.Location).AppendFormat(b, "2006-01-02 15:04:05.000 MST")
+	} else {
+		b = r.appendAt(b, node.Span.StartTime)
+	}
+	b = append(b, " to "...)
+	b = r.appendAt(b, node.Span.EndTime)
+	// These often say how the trace was sampled, or for which tenant.
+	if ts := node.Span.SpanContext.TraceState; ts != "" {
+		b = append(b, "&#10;tracestate: "...)
+		b = append(b, html.EscapeString(ts)...)
+	}
+	for i, kv := range node.Span.Baggage() {
+		if i == 0 {
+			b = append(b, "&#10;baggage: "...)
+		} else {
+			b = append(b, ", "...)
+		}
+		b = append(b, html.EscapeString(kv.Key)...)
+		b = append(b, '=')
+		b = append(b, html.EscapeString(kv.Value.String())...)
+	}
+	return append(b, '"')
+}
+
+// crossesProcess reports whether span's parent is in another process, e.g.
+// a server handling a call from a client whose spans came from another file.
+func crossesProcess(span *Span) bool {
+	return span.Parent.Remote && span.Parent.SpanID != RootID
+}
+
+// style returns the inline style for node's label, if any.
+func (r *renderer) style(node *Node) string {
+	if r.opts.CSP {
+		// open gives the node a class instead.
+		return ""
+	}
+	if i := r.color(node); i >= 0 {
+		return fmt.Sprintf(` style="background-color: %s"`, html.EscapeString(r.opts.Colors[i].Color))
+	}
+	return ""
+}
+
+// color returns the index of the first color rule for node, or -1.
+func (r *renderer) color(node *Node) int {
+	for i, rule := range r.opts.Colors {
+		if (rule.Over == 0 || node.Span.Duration() > rule.Over) && rule.Name.MatchString(node.Span.Name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// cssValue drops anything from s that could escape a CSS declaration.
+func cssValue(s string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`;{}<>"'\`, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// maxDepth caps how deeply spans nest on the page. Browsers stop nesting
+// elements somewhere past 512 levels, and each span takes two.
+const maxDepth = 200
+
+// span writes node and everything under it. It keeps its own stack rather
+// than recursing, since traces of recursive functions can be very deep.
+func (r *renderer) span(parent, node *Node, depth int) {
+	type frame struct {
+		parent, node *Node
+		depth        int
+		close        bool
+	}
+
+	stack := []frame{{parent: parent, node: node, depth: depth}}
+	for len(stack) != 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if f.close {
+			fmt.Fprint(r.w, `</details></div>`)
+			r.eol()
+			continue
+		}
+
+		if !r.open(f.parent, f.node, f.depth) {
+			continue
+		}
+
+		stack = append(stack, frame{node: f.node, close: true})
+		for i := len(f.node.Children) - 1; i >= 0; i-- {
+			stack = append(stack, frame{parent: f.node, node: f.node.Children[i], depth: f.depth + 1})
+		}
+	}
+}
+
+// open writes node's label, and reports whether its children should follow.
+// If not, it has already closed node.
+func (r *renderer) open(parent, node *Node, depth int) bool {
+	w := r.w
+
+	// Server spans handling a call and consumers of a message get drawn
+	// differently, to pair them up with the other side.
+	cls := ""
+	if parent != nil && parent.Callee() == node {
+		cls = "callee"
+	} else if _, ok := r.handoffs[node.Span]; ok {
+		cls = "consumer"
+	} else if parent != nil && node.Span.SpanContext.SpanID == "" {
+		cls = "group"
+	}
+	if parent != nil && crossesProcess(node.Span) {
+		cls = strings.TrimSpace(cls + " boundary")
+	}
+	if r.highlighted[node.Span] {
+		cls = strings.TrimSpace(cls + " highlight")
+	}
+	if r.opts.CSP {
+		if i := r.color(node); i >= 0 {
+			if r.colored == nil {
+				r.colored = make([]bool, len(r.opts.Colors))
+			}
+			r.colored[i] = true
+			cls = strings.TrimSpace(cls + " c" + strconv.Itoa(i))
+		}
+	}
+	class := ""
+	if cls != "" {
+		class = ` class="` + cls + `"`
+	}
+
+	if parent == nil {
+		// The page's script keys saved state by trace.
+		if len(node.Children) != 0 {
+			id := html.EscapeString(node.Children[0].Span.SpanContext.TraceID)
+			fmt.Fprintf(w, `<div class="trace" role="tree" aria-label="trace %s" data-trace="%s">`, id, id)
+		} else {
+			fmt.Fprint(w, `<div role="tree">`)
+		}
+	} else {
+		leftpad, rightpad := r.pads(parent, node)
+
+		if r.opts.Compact || r.opts.CSP {
+			l, rr := bucket(leftpad), bucket(rightpad)
+			r.left[l], r.right[rr] = true, true
+			if r.opts.CSP && !r.opts.Compact {
+				if len(node.Children) == 0 {
+					fmt.Fprintf(w, `<div class="l%d r%d">`, l, rr)
+				} else {
+					fmt.Fprintf(w, `<div class="parent l%d r%d">`, l, rr)
+				}
+			} else if len(node.Children) == 0 {
+				// Leaves are a single element.
+				if cls != "" {
+					r.label(fmt.Sprintf(`<p class="l%d r%d %s"`, l, rr, cls), node, depth, `</p>`)
+				} else {
+					r.label(fmt.Sprintf(`<p class="l%d r%d"`, l, rr), node, depth, `</p>`)
+				}
+				r.events(node)
+				return false
+			}
+			fmt.Fprintf(w, `<div class="parent l%d r%d">`, l, rr)
+		} else if len(node.Children) == 0 {
+			fmt.Fprintf(w, `<div style="margin: 1px %f%% 0 %f%%">`, 100.0*rightpad, 100.0*leftpad)
+		} else {
+			fmt.Fprintf(w, `<div class="parent" style="margin: 1px %f%% 0 %f%%">`, 100.0*rightpad, 100.0*leftpad)
+		}
+	}
+
+	if len(node.Children) == 0 {
+		r.label(`<span`+class, node, depth, `</span>`)
+		r.events(node)
+		fmt.Fprint(w, `</div>`)
+		r.eol()
+		return false
+	}
+
+	if depth >= maxDepth {
+		hidden := -1
+		node.Walk(func(*Node, int) bool {
+			hidden++
+			return true
+		})
+		r.label(`<span`+class, node, depth, fmt.Sprintf(` (%d nested spans not shown)</span>`, hidden))
+		r.events(node)
+		fmt.Fprint(w, `</div>`)
+		r.eol()
+		return false
+	}
+
+	// Default to root being open.
+	if depth <= r.opts.Expand {
+		r.label(`<details open><summary aria-expanded="true"`+class, node, depth, `</summary>`)
+	} else {
+		r.label(`<details><summary aria-expanded="false"`+class, node, depth, `</summary>`)
+	}
+	r.events(node)
+	return true
+}
+
+// events writes the events worth seeing without digging under node's label.
+func (r *renderer) events(node *Node) {
+	r.exceptions(node)
+	r.logs(node)
+}
+
+// exceptions writes node's exception events, with their stacktraces collapsed.
+func (r *renderer) exceptions(node *Node) {
+	for _, exc := range node.Span.exceptions() {
+		typ := exc.Type
+		if typ == "" {
+			typ = "exception"
+		}
+		b := append(r.buf[:0], `<details class="exception"><summary>`...)
+		b = append(b, html.EscapeString(typ)...)
+		if exc.Message != "" {
+			b = append(b, ": "...)
+			b = append(b, html.EscapeString(exc.Message)...)
+		}
+		if !exc.Time.IsZero() {
+			b = append(b, ` <time>`...)
+			b = append(r.appendAt(b, exc.Time), `</time>`...)
+		}
+		b = append(b, `</summary>`...)
+		if exc.Stacktrace != "" {
+			b = append(b, `<pre>`...)
+			b = append(b, html.EscapeString(exc.Stacktrace)...)
+			b = append(b, `</pre>`...)
+		}
+		b = append(b, `</details>`...)
+		r.w.Write(b)
+		r.buf = b
+	}
+}
+
+// logs writes node's log events, collapsed into one block.
+func (r *renderer) logs(node *Node) {
+	logs := node.Span.logs()
+	if len(logs) == 0 {
+		return
+	}
+
+	b := fmt.Appendf(r.buf[:0], `<details class="logs"><summary>%d logs</summary><pre>`, len(logs))
+	for _, l := range logs {
+		b = r.appendAt(b, l.Time)
+		for _, kv := range l.Attributes {
+			if kv.Key == "severity" || kv.Key == "message" {
+				if v := kv.Value.String(); v != "" {
+					b = append(b, ' ')
+					b = append(b, html.EscapeString(v)...)
+				}
+				continue
+			}
+			b = append(b, ' ')
+			b = append(b, html.EscapeString(kv.Key+"="+kv.Value.String())...)
+		}
+		b = append(b, '\n')
+	}
+	b = append(b, `</pre></details>`...)
+	r.w.Write(b)
+	r.buf = b
+}
+
+// label writes node's name, duration, summary, and tooltip between open and close.
+// It runs once per span, so it builds the markup in a reused buffer.
+func (r *renderer) label(open string, node *Node, depth int, close string) {
+	b := append(r.buf[:0], open...)
+	b = append(b, r.style(node)...)
+	b = append(b, ` role="treeitem" aria-level="`...)
+	b = strconv.AppendInt(b, int64(depth+1), 10)
+	b = append(b, '"')
+	// The page's script keys open spans and notes by span ID.
+	if id := node.Span.SpanContext.SpanID; id != "" {
+		b = append(b, ` data-id="`...)
+		b = append(b, html.EscapeString(id)...)
+		b = append(b, '"')
+	}
+	if r.produced[node.Span] {
+		b = append(b, ` id="span-`...)
+		b = append(b, html.EscapeString(node.Span.SpanContext.SpanID)...)
+		b = append(b, '"')
+	}
+	b = r.appendTitle(b, node)
+	b = append(b, '>')
+	name := node.Span.Name
+	// Synthetic spans, like the root and -group-by lanes, keep their names.
+	if r.namer != nil && node.Span.SpanContext.TraceID != "" {
+		name = r.namer.name(node.Span)
+	}
+	b = append(b, html.EscapeString(name)...)
+	b = append(b, ' ')
+	b = appendDuration(b, node.Span.Duration(), r.opts.Precision)
+	// Screen readers can't see how wide a span is, so say it.
+	if r.total > 0 {
+		b = append(b, `<span class="sr">, `...)
+		b = strconv.AppendFloat(b, 100*float64(node.Span.Duration())/float64(r.total), 'f', 1, 64)
+		b = append(b, `% of trace</span>`...)
+	}
+	if crossesProcess(node.Span) {
+		b = append(b, ` <small class="remote" title="parent span is in another process">remote</small>`...)
+	}
+	if d, ok := r.descendants[node]; ok {
+		b = append(b, ` <small class="badge">`...)
+		b = appendCount(b, d.Spans, "span")
+		b = append(b, ", "...)
+		b = appendDuration(b, d.Time, r.opts.Precision)
+		if d.Errors != 0 {
+			b = append(b, ", "...)
+			b = appendCount(b, d.Errors, "error")
+		}
+		b = append(b, `</small>`...)
+	}
+	if r.opts.Scopes {
+		if scope := node.Span.Scope(); scope != "" {
+			b = append(b, ` <small class="scope">`...)
+			b = append(b, html.EscapeString(scope)...)
+			b = append(b, `</small>`...)
+		}
+	}
+	summary := node.Span.Summary()
+	network, call := node.Network()
+	handoff, consumed := r.handoffs[node.Span]
+	if summary != "" || call || consumed {
+		b = append(b, ` <small>`...)
+		b = append(b, html.EscapeString(summary)...)
+		sep := summary != ""
+		if call {
+			if sep {
+				b = append(b, ", "...)
+			}
+			b = append(b, "network + queue "...)
+			b = appendDuration(b, network, r.opts.Precision)
+			sep = true
+		}
+		if consumed {
+			if sep {
+				b = append(b, ", "...)
+			}
+			b = append(b, "queued "...)
+			b = appendDuration(b, handoff.Queued(), r.opts.Precision)
+			b = append(b, ` after <a href="#span-`...)
+			b = append(b, html.EscapeString(handoff.Producer.SpanContext.SpanID)...)
+			b = append(b, `">`...)
+			b = append(b, html.EscapeString(handoff.Producer.Name)...)
+			b = append(b, `</a>`...)
+		}
+		b = append(b, `</small>`...)
+	}
+	if r.opts.CodeURL != nil {
+		if loc, ok := node.Span.Code(); ok {
+			b = append(b, ` <a class="code" target="_blank" rel="noopener" href="`...)
+			b = append(b, html.EscapeString(loc.URL(*r.opts.CodeURL, r.opts.CodeRoot))...)
+			b = append(b, `">`...)
+			b = append(b, html.EscapeString(loc.String())...)
+			b = append(b, `</a>`...)
+		}
+	}
+	// The synthetic root gets its links from traceLinks.
+	if node.Span.SpanContext.TraceID != "" {
+		for _, l := range r.opts.Links {
+			b = appendLink(b, l.Name, l.Span(node.Span))
+		}
+	}
+	b = append(b, close...)
+	r.w.Write(b)
+	r.buf = b
+}
+
+// appendCount appends "n things", or "1 thing".
+func appendCount(b []byte, n int, thing string) []byte {
+	b = strconv.AppendInt(b, int64(n), 10)
+	b = append(b, ' ')
+	b = append(b, thing...)
+	if n != 1 {
+		b = append(b, 's')
+	}
+	return b
+}
+
+func appendLink(b []byte, name, href string) []byte {
+	b = append(b, ` <a class="link" target="_blank" rel="noopener" href="`...)
+	b = append(b, html.EscapeString(href)...)
+	b = append(b, `">`...)
+	b = append(b, html.EscapeString(name)...)
+	return append(b, ` ↗</a>`...)
+}
+
+const style = `
+<style>
+summary {
+  border: 1px solid;
+  display: block;
+  white-space: nowrap;
+  padding: 3px;
+}
+span {
+  border: 1px solid;
+  display: block;
+  white-space: nowrap;
+  padding: 3px;
+}
+body {
+	width: 100%;
+	margin: 0px;
+}
+nav {
+	padding: 3px;
+}
+div.parent:hover {
+	outline: 1.5px solid lightgrey;
+}
+div.frames {
+	display: flex;
+}
+div.frame {
+	overflow: hidden;
+}
+body.dark {
+	background-color: #1e1e1e;
+	color: #d4d4d4;
+}
+body.dark details.errors {
+	color: #f48771;
+}
+body.dark details.warnings {
+	color: #dcb67a;
+}
+body.dark span, body.dark summary {
+	border-color: #555;
+}
+details.errors {
+	color: darkred;
+	margin-bottom: 1em;
+}
+details.warnings {
+	color: #8a6d00;
+	margin-bottom: 1em;
+}
+small {
+	opacity: 0.7;
+}
+details[open] > summary > small.badge {
+	display: none;
+}
+small.badge {
+	float: right;
+}
+div.toolbar {
+	position: fixed;
+	top: 4px;
+	right: 4px;
+	z-index: 1;
+}
+mark.note {
+	padding: 0 3px;
+	white-space: pre-wrap;
+}
+.match {
+	outline: 2px solid gold;
+}
+small.scope, small.remote {
+	border: 1px solid;
+	border-radius: 3px;
+	padding: 0 3px;
+}
+small.remote {
+	border-style: dashed;
+}
+a.code, a.link {
+	font-size: smaller;
+}
+details.resources table {
+	display: inline-table;
+	vertical-align: top;
+	margin: 0 1em 1em 0;
+	text-align: left;
+}
+details.unparented {
+	margin-top: 1em;
+}
+details.resources caption {
+	font-weight: bold;
+	text-align: left;
+}
+details.heatmap th {
+	text-align: left;
+	font-weight: normal;
+	padding-right: 1em;
+}
+details.heatmap svg {
+	display: block;
+	width: 600px;
+	height: 12px;
+	fill: currentColor;
+}
+details.breakdown td, details.breakdown th {
+	padding: 0 1em 0 0;
+	text-align: left;
+}
+details.breakdown .num {
+	text-align: right;
+}
+details.baseline td, details.baseline th {
+	padding: 0 1em 0 0;
+	text-align: left;
+}
+details.baseline .num {
+	text-align: right;
+}
+details.baseline tr.slower, td.regression {
+	color: #c00;
+}
+td form {
+	display: inline;
+}
+details.breakdown svg {
+	display: block;
+	width: 300px;
+	height: 10px;
+	fill: currentColor;
+}
+div.links {
+	margin-bottom: 0.5em;
+}
+.callee {
+	border-style: dashed;
+}
+.consumer {
+	border-style: dotted;
+}
+.group {
+	border-style: double;
+	font-style: italic;
+}
+.highlight {
+	outline: 2px solid #f90;
+}
+.boundary {
+	border-top: 2px dashed;
+	margin-top: 3px;
+}
+:target {
+	outline: 2px solid orange;
+}
+details.exception {
+	color: darkred;
+	white-space: normal;
+}
+details.exception summary {
+	border: none;
+	white-space: normal;
+}
+details.exception pre {
+	overflow-x: auto;
+	margin: 0 0 0 1em;
+}
+span.sr {
+	position: absolute;
+	width: 1px;
+	height: 1px;
+	overflow: hidden;
+	clip: rect(0 0 0 0);
+	border: 0;
+	padding: 0;
+}
+body.dark details.exception {
+	color: #f48771;
+}
+div.ruler span.gap {
+	border: none;
+	text-align: center;
+	background: repeating-linear-gradient(135deg, transparent 0 3px, #9994 3px 6px);
+}
+hr.section {
+	border: none;
+	border-top: 1px dashed #999;
+	margin: 1em 0;
+}
+div.ruler {
+	position: relative;
+	height: 1.5em;
+}
+div.ruler span {
+	position: absolute;
+	border: none;
+	border-left: 1px solid;
+	padding: 0 3px;
+}
+div.ruler span.t0 { left: 0; }
+div.ruler span.t1 { left: 20%; }
+div.ruler span.t2 { left: 40%; }
+div.ruler span.t3 { left: 60%; }
+div.ruler span.t4 { left: 80%; }
+div.ruler span.last {
+	right: 0;
+	border-left: none;
+	border-right: 1px solid;
+}
+</style>`
+
+const footer = `
+    </body>
+</html>
+`
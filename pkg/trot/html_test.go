@@ -0,0 +1,696 @@
+package trot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseColorRule(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		name  string
+		over  time.Duration
+		color string
+	}{
+		{in: `db\..*=orange`, name: `db\..*`, color: "orange"},
+		{in: `db\..*>100ms=#f80`, name: `db\..*`, over: 100 * time.Millisecond, color: "#f80"},
+		{in: `>1s=red`, over: time.Second, color: "red"},
+		{in: `a>b=red`, name: `a>b`, color: "red"},
+	} {
+		rule, err := ParseColorRule(tc.in)
+		if err != nil {
+			t.Fatalf("ParseColorRule(%q): %v", tc.in, err)
+		}
+		if rule.Name.String() != tc.name || rule.Over != tc.over || rule.Color != tc.color {
+			t.Errorf("ParseColorRule(%q) = %q, %v, %q, want %q, %v, %q", tc.in, rule.Name, rule.Over, rule.Color, tc.name, tc.over, tc.color)
+		}
+	}
+}
+
+func TestCSP(t *testing.T) {
+	rule, err := ParseColorRule(`.*=red;}body{x`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := RenderHTML(&buf, synthetic(100), Options{CSP: true, Nonce: "n0nce", Colors: []ColorRule{rule}}); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	if strings.Contains(page, "style=") {
+		t.Errorf("page has inline style attributes")
+	}
+	if want := strings.Count(page, "<style") + strings.Count(page, "<script"); strings.Count(page, `nonce="n0nce"`) != want {
+		t.Errorf("not every <style> and <script> has the nonce")
+	}
+	if !strings.Contains(page, ".c0{background-color:redbodyx}") {
+		t.Errorf("color rule class missing or unsanitized")
+	}
+}
+
+func TestRemoteParent(t *testing.T) {
+	in := `{"resourceSpans":[{"scopeSpans":[{"spans":[
+{"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","spanId":"00f067aa0ba902b7","name":"GET","kind":3,"startTimeUnixNano":"1000","endTimeUnixNano":"5000"},
+{"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","spanId":"00f067aa0ba902b8","parentSpanId":"00f067aa0ba902b7","flags":769,"name":"handle","kind":2,"startTimeUnixNano":"2000","endTimeUnixNano":"4000"}
+]}]}]}`
+	tr, err := ParseFormat(strings.NewReader(in), "otlp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tr.Spans["00f067aa0ba902b8"].Parent.Remote || tr.Spans["00f067aa0ba902b7"].Parent.Remote {
+		t.Fatalf("only handle's parent should be remote")
+	}
+
+	var buf bytes.Buffer
+	if err := RenderHTML(&buf, tr, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(buf.String(), `class="remote"`); got != 1 {
+		t.Errorf("%d remote markers, want 1", got)
+	}
+	if !strings.Contains(buf.String(), `<span class="callee boundary"`) {
+		t.Errorf("handle isn't marked as across a process boundary")
+	}
+
+	buf.Reset()
+	if err := EncodeOTLP(&buf, tr); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ParseFormat(&buf, "otlp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.Spans["00f067aa0ba902b8"].Parent.Remote {
+		t.Errorf("remote parent lost converting to OTLP")
+	}
+}
+
+func TestRootName(t *testing.T) {
+	tr := NewTrace()
+	for _, s := range []*Span{
+		span("b", RootID, "late", 10, 20),
+		span("a", RootID, "GET /", 0, 20),
+		span("c", "a", "query", 5, 10),
+	} {
+		s.SpanContext.TraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		s.Resource = []KeyValue{keyValue("service.name", "web")}
+		tr.Add(s)
+	}
+	want := "web: GET / · 4bf92f3577b34da6a3ce929d0e0e4736"
+	if got := rootName(tr); got != want {
+		t.Errorf("rootName() = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := RenderHTML(&buf, tr, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<title>"+want+"</title>") {
+		t.Errorf("page not titled %q", want)
+	}
+	if !strings.Contains(buf.String(), `<meta charset="utf-8">`) {
+		t.Errorf("page doesn't say it's UTF-8")
+	}
+
+	store := NewMemStore()
+	store.Add(tr)
+	rec := httptest.NewRecorder()
+	Handler(store).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/4bf92f3577b34da6a3ce929d0e0e4736", nil))
+	if !strings.Contains(rec.Body.String(), "<title>"+want+"</title>") {
+		t.Errorf("served page not titled %q", want)
+	}
+
+	other := span("d", RootID, "other", 0, 1)
+	other.SpanContext.TraceID = "0af7651916cd43dd8448eb211c80319c"
+	tr.Add(other)
+	if got, want := rootName(tr), "2 traces"; got != want {
+		t.Errorf("rootName() = %q, want %q", got, want)
+	}
+}
+
+func TestCompressGaps(t *testing.T) {
+	tr := NewTrace()
+	tr.Add(span("a", RootID, "job", 0, 602000))
+	tr.Add(span("b", "a", "one", 0, 1000))
+	tr.Add(span("c", "a", "two", 601000, 602000))
+
+	root := tr.Tree("root", RootID)
+	r := newRenderer(nil, Options{CompressGaps: true})
+	r.start = root.Span.StartTime
+	r.gaps = idleGaps(root)
+	if len(r.gaps) != 1 || r.gaps[0].idle() != 600*time.Second {
+		t.Fatalf("gaps = %v, want one of 600s", r.gaps)
+	}
+	if got, want := r.x(epoch.Add(602*time.Second)), float64(2100*time.Millisecond); got != want {
+		t.Errorf("x(end) = %v, want %v", got, want)
+	}
+	for _, ms := range []int{0, 500, 1000, 300000, 601000, 602000} {
+		at := epoch.Add(time.Duration(ms) * time.Millisecond)
+		// Inside the gap, a nanosecond drawn is many idle ones.
+		if got := r.at(r.x(at)); got.Sub(at).Abs() > time.Millisecond {
+			t.Errorf("at(x(%dms)) = %s", ms, got.Sub(epoch))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := RenderHTML(&buf, tr, Options{CompressGaps: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `title="600s idle"`) {
+		t.Errorf("gap not marked:\n%s", buf.String())
+	}
+}
+
+func TestLogScale(t *testing.T) {
+	tr := NewTrace()
+	tr.Add(span("a", RootID, "job", 0, 10000))
+	tr.Add(span("b", "a", "slow", 0, 9000))
+	tr.Add(span("c", "a", "fast", 9998, 9999))
+
+	root := tr.Tree("root", RootID)
+	job := root.Children[0]
+	slow, fast := job.Children[0], job.Children[1]
+
+	r := newRenderer(nil, Options{Scale: "log"})
+	r.start = root.Span.StartTime
+	r.unit = shortest(root)
+	if r.unit != time.Millisecond {
+		t.Fatalf("shortest() = %s, want 1ms", r.unit)
+	}
+
+	// A millisecond at the end of ten seconds gets 7.5% of the width, not 0.01%.
+	left, right := r.pads(job, fast)
+	if width := 1 - left - right; width < 0.07 {
+		t.Errorf("fast span drawn %.3f of the trace", width)
+	}
+	if right < 0 || right > 0.01 {
+		t.Errorf("fast span drawn %.3f from the end", right)
+	}
+	if left, right := r.pads(job, slow); left != 0 || right > 0.02 {
+		t.Errorf("slow span pads = %.3f, %.3f", left, right)
+	}
+
+	// On a linear scale, it's a sliver.
+	r.unit = 0
+	if left, right := r.pads(job, fast); 1-left-right > 0.001 {
+		t.Errorf("linear fast span drawn %.4f of the trace", 1-left-right)
+	}
+}
+
+func TestRenderCanvas(t *testing.T) {
+	// b and c overlap, so c goes below b's subtree; e fits after b.
+	tr := NewTrace()
+	for _, s := range []*Span{
+		span("a", RootID, "a", 0, 100),
+		span("b", "a", "b", 0, 40),
+		span("d", "b", "d", 0, 10),
+		span("c", "a", "c", 20, 60),
+		span("e", "a", "e", 50, 90),
+	} {
+		tr.Add(s)
+	}
+
+	rows := map[string]int{}
+	height := layout(tr.Roots()[0], 0, func(n *Node, row int) {
+		rows[n.Span.Name] = row
+	})
+	if got, want := fmt.Sprint(rows), "map[a:0 b:1 c:3 d:2 e:1]"; got != want {
+		t.Errorf("rows = %s, want %s", got, want)
+	}
+	if height != 4 {
+		t.Errorf("height = %d, want 4", height)
+	}
+
+	var buf bytes.Buffer
+	if err := RenderCanvas(&buf, tr, Options{Nonce: "n0nce"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`<canvas>`, `"total":100000000`, `<script nonce="n0nce">`, `<style nonce="n0nce">`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+}
+
+func TestPartition(t *testing.T) {
+	cells := partition(testTrace().Tree("root", RootID))
+	got := []string{}
+	for _, c := range cells {
+		got = append(got, fmt.Sprintf("%s %d %.2f-%.2f", c.node.Span.Name, c.depth, c.x0, c.x1))
+	}
+	// b and c (30ms and 60ms) fill a's 100ms with time to spare.
+	want := []string{
+		"root 0 0.00-1.00",
+		"a 1 0.00-1.00",
+		"b 2 0.00-0.30",
+		"d 3 0.00-0.10",
+		"c 2 0.30-0.90",
+		"db.query 3 0.30-0.40",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("partition:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	for _, render := range []func(io.Writer, *Trace, Options) error{RenderIcicle, RenderSunburst} {
+		var buf bytes.Buffer
+		if err := render(&buf, testTrace(), Options{}); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Count(buf.String(), "<title>"); got != 7 {
+			t.Errorf("%d titles, want 7 (the page's and one per cell)", got)
+		}
+	}
+}
+
+func TestRenderTable(t *testing.T) {
+	tr := testTrace()
+	tr.Spans["e"].Status.Code, tr.Spans["e"].Status.Description = "Error", "timeout"
+
+	var buf bytes.Buffer
+	if err := RenderTable(&buf, tr, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if n := strings.Count(got, "<tr"); n != 6 {
+		t.Errorf("%d rows, want a header and 5 spans", n)
+	}
+	// a's children cover [10, 90] of its 100ms.
+	for _, want := range []string{
+		`<td data-sort="a">a</td><td data-sort="unknown">unknown</td><td class="num" data-sort="0">+0s</td><td class="num" data-sort="100000000">100ms</td><td class="num" data-sort="20000000">20ms</td>`,
+		`<tr class="error"><td data-sort="db.query">db.query</td>`,
+		`Error: timeout</td>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+	if strings.Contains(got, "<th>trace</th>") {
+		t.Errorf("trace column for a single trace")
+	}
+}
+
+func TestHeatmap(t *testing.T) {
+	// Starts at 0, 30, 10, 20, and 50ms.
+	h := testTrace().Heatmap(4)
+	if got, want := fmt.Sprint(h.Services, h.Counts, h.Max), "[unknown] [[2 1 1 1]] 2"; got != want {
+		t.Errorf("Heatmap(4) = %s, want %s", got, want)
+	}
+
+	var buf bytes.Buffer
+	writeHeatmap(&buf, h, Options{})
+	if got := strings.Count(buf.String(), "<rect"); got != 4 {
+		t.Errorf("%d cells, want 4", got)
+	}
+	if !strings.Contains(buf.String(), `fill-opacity="1.00"><title>2 spans started at +0s to +12.5ms</title>`) {
+		t.Errorf("busiest cell missing:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	writeHeatmap(&buf, NewTrace().Heatmap(4), Options{})
+	if buf.Len() != 0 {
+		t.Errorf("heatmap of an empty trace: %s", buf.String())
+	}
+}
+
+func TestHeatmapOddTimes(t *testing.T) {
+	tr := testTrace()
+	zero := span("z", "a", "zero", 0, 0)
+	zero.StartTime = time.Time{}
+	tr.Add(zero)
+	far := span("f", "a", "far", 0, 0)
+	far.StartTime = time.Unix(1<<40, 0)
+	tr.Add(far)
+
+	// Without a start time, z is left out; f saturates into the last bucket.
+	h := tr.Heatmap(4)
+	if got, want := fmt.Sprint(h.Counts), "[[5 0 0 1]]"; got != want {
+		t.Errorf("Heatmap(4) = %s, want %s", got, want)
+	}
+}
+
+func TestBreakdown(t *testing.T) {
+	tr := testTrace()
+	for id, code := range map[string]any{"b": "200", "d": "200", "c": float64(500), "e": float64(500)} {
+		tr.Spans[id].Attributes = rawJSON([]KeyValue{keyValue("http.status_code", code)})
+	}
+
+	b := tr.Breakdown("http.status_code")
+	if got, want := fmt.Sprint(b.Values), "[{500 2 70ms} {200 2 40ms}]"; got != want {
+		t.Errorf("Breakdown() = %s, want %s", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := RenderHTML(&buf, tr, Options{Breakdown: []string{"http.status_code", "db.system"}}); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if n := strings.Count(got, `<details open class="breakdown">`); n != 1 {
+		t.Errorf("%d breakdowns, want 1 (no span has db.system)", n)
+	}
+	if want := `<td>200</td><td class="num">2</td><td class="num">40ms</td><td><svg viewBox="0 0 1 1" preserveAspectRatio="none"><rect width="0.571" height="1"/>`; !strings.Contains(got, want) {
+		t.Errorf("missing %s", want)
+	}
+}
+
+func TestSelectAndHighlight(t *testing.T) {
+	sel, err := ParseSelector("a/c/**")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hl, err := ParseSelector("**/d")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := RenderHTML(&buf, testTrace(), Options{Select: sel}); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if !strings.Contains(got, `data-id="e"`) || strings.Contains(got, `data-id="b"`) || strings.Contains(got, `data-id="d"`) {
+		t.Errorf("Select(a/c/**) should keep only a, c, and db.query:\n%s", got)
+	}
+
+	buf.Reset()
+	if err := RenderHTML(&buf, testTrace(), Options{Highlight: hl}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(buf.String(), `class="highlight"`); got != 1 {
+		t.Errorf("%d highlighted spans, want 1", got)
+	}
+}
+
+func TestTraceStateAndBaggage(t *testing.T) {
+	tr := testTrace()
+	b := tr.Spans["b"]
+	b.SpanContext.TraceState = "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE"
+	b.Attributes = rawJSON([]KeyValue{keyValue("baggage.tenant", "acme"), keyValue("http.route", "/"), keyValue("baggage.user", "<jon>")})
+
+	var buf bytes.Buffer
+	if err := RenderHTML(&buf, tr, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	want := `title="+10ms to +40ms&#10;tracestate: rojo=00f067aa0ba902b7,congo=t61rcWkgMzE&#10;baggage: tenant=acme, user=&lt;jon&gt;"`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("missing %s", want)
+	}
+}
+
+func TestUnparented(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		trace func() *Trace
+		roots bool
+	}{{
+		name: "only orphans",
+		trace: func() *Trace {
+			tr := NewTrace()
+			tr.Add(span("f", "x", "orphan", 40, 70))
+			return tr
+		},
+	}, {
+		name: "orphans and roots",
+		trace: func() *Trace {
+			tr := testTrace()
+			tr.Add(span("f", "x", "orphan", 40, 70))
+			return tr
+		},
+		roots: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := RenderHTML(&buf, tc.trace(), Options{}); err != nil {
+				t.Fatal(err)
+			}
+			got := buf.String()
+			if !strings.HasPrefix(got, "\n<html>") {
+				t.Errorf("page doesn't start with its header:\n%.200s", got)
+			}
+			unparented := strings.Index(got, `<details open class="unparented"><summary>1 unparented spans`)
+			if unparented < strings.Index(got, "<body") {
+				t.Fatalf("no unparented section in the body:\n%s", got)
+			}
+			if orphan := strings.Index(got, `data-id="f"`); orphan < unparented {
+				t.Errorf("orphan isn't in the unparented section")
+			}
+			if a := strings.Index(got, `data-id="a"`); tc.roots && (a < 0 || a > unparented) {
+				t.Errorf("roots aren't before the unparented section")
+			}
+		})
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	if got := testTrace().Warnings(); len(got) != 0 {
+		t.Errorf("Warnings() = %q, want none", got)
+	}
+
+	tr := testTrace()
+	tr.Add(span("d", "b", "d", 20, 30))
+	tr.Add(span("f", "x", "orphan", 40, 70))
+	tr.Add(span("g", "a", "late", 90, 120))
+	tr.Spans["a"].ChildSpanCount = 5
+	tr.Spans["e"].DroppedAttributes = 3
+	want := []string{
+		"1 span with a missing parent (under 1 missing span)",
+		"1 span replaced by a later one with the same SpanID",
+		"1 span starting before or ending after their parent, maybe from clock skew between hosts",
+		"1 span with fewer children than they reported, which the exporter may have dropped",
+		"1 span over their SDK's limits on attributes, events, or links",
+	}
+	if got := tr.Warnings(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Warnings() = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := RenderHTML(&buf, tr, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if i := strings.Index(got, `<details class="warnings"><summary>5 warnings</summary>`); i < 0 || i > strings.Index(got, `role="tree"`) {
+		t.Errorf("no warnings panel above the tree")
+	}
+}
+
+func TestWarningsSplit(t *testing.T) {
+	tr := NewTrace()
+	for _, tid := range []string{"1", "2"} {
+		root := span(tid+"a", RootID, "root", 0, 10)
+		root.SpanContext.TraceID = tid
+		tr.Add(root)
+	}
+	again := span("1a", RootID, "root", 0, 20)
+	again.SpanContext.TraceID = "1"
+	tr.Add(again)
+
+	traces := tr.Split()
+	want := []string{"1 span replaced by a later one with the same SpanID"}
+	if got := traces["1"].Warnings(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("trace 1 Warnings() = %q, want %q", got, want)
+	}
+	if got := traces["2"].Warnings(); len(got) != 0 {
+		t.Errorf("trace 2 Warnings() = %q, want none", got)
+	}
+
+	var buf bytes.Buffer
+	if err := RenderHTML(&buf, traces["1"], Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `<summary>1 warning</summary>`) {
+		t.Errorf("one warning isn't summarized as %q", "1 warning")
+	}
+}
+
+func TestRenderArchive(t *testing.T) {
+	metas := []Meta{
+		{TraceID: "1", Name: "build", Service: "ci", Start: epoch, Duration: time.Minute, Spans: 10, Errors: 2, Page: "1.html"},
+		{Name: "old report", Start: epoch.Add(-time.Hour), Page: "old & gone.html"},
+	}
+	var buf bytes.Buffer
+	if err := RenderArchive(&buf, metas, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`<tr class="error"><td class="num" data-sort="1704067200">2024-01-01 00:00:00</td><td data-sort="build"><a href="1.html">build</a></td><td data-sort="ci">ci</td><td class="num" data-sort="60000000000">60s</td><td class="num" data-sort="10">10</td><td class="num" data-sort="2">2</td></tr>`,
+		`<a href="old &amp; gone.html">old report</a></td><td data-sort=""></td>`,
+		`document.querySelector('table.sortable')`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+}
+
+func TestAggregates(t *testing.T) {
+	other := NewTrace()
+	for _, s := range []*Span{
+		span("a", RootID, "a", 0, 300),
+		span("b", "a", "b", 0, 200),
+		span("f", "b", "b", 0, 100),
+	} {
+		other.Add(s)
+	}
+	other.Spans["f"].Status.Code = "Error"
+
+	aggs := Aggregates(map[string]*Trace{"1": testTrace(), "2": other})
+	if got, want := fmt.Sprint(aggs[:2]), "[{unknown a 2 2 0 400ms 100ms 100ms 300ms} {unknown b 3 2 1 330ms 100ms 100ms 200ms}]"; got != want {
+		t.Errorf("Aggregates() = %s, want %s", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := RenderAggregates(&buf, aggs, Options{Nav: [][2]string{{"traces", "index.html"}}}); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`<nav><a href="index.html">traces</a> </nav>`,
+		`<tr class="error"><td data-sort="unknown">unknown</td><td data-sort="b">b</td><td class="num" data-sort="3">3</td><td class="num" data-sort="2">2</td><td class="num" data-sort="1">1</td><td class="num" data-sort="330000000">330ms</td>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+}
+
+func TestBaselines(t *testing.T) {
+	withTraceID := func(tr *Trace, tid string) *Trace {
+		for _, span := range tr.Spans {
+			span.SpanContext.TraceID = tid
+		}
+		return tr
+	}
+	base := withTraceID(testTrace(), "00000000000000000000000000000001")
+	slow := withTraceID(testTrace(), "00000000000000000000000000000002")
+	slow.Spans["a"].EndTime = epoch.Add(200 * time.Millisecond)
+
+	dir := t.TempDir()
+	b, err := LoadBaselines(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Set(base); err != nil {
+		t.Fatal(err)
+	}
+	// Baselines outlive the process that set them.
+	if b, err = LoadBaselines(dir); err != nil {
+		t.Fatal(err)
+	}
+	d, ok := b.Compare(slow.Summarize())
+	if !ok || !d.Regressed || d.Baseline != base.Summarize().TraceID || d.String() != "+100ms (+100%)" {
+		t.Errorf("Compare() = %+v, %v", d, ok)
+	}
+	if _, ok := b.Compare(base.Summarize()); ok {
+		t.Errorf("baseline compared to itself")
+	}
+
+	var buf bytes.Buffer
+	if err := RenderHTML(&buf, slow, Options{Baseline: base}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<summary>+100ms (+100%) vs the baseline, 00000000</summary>`,
+		`<tr class="slower"><td>a</td><td class="num">100ms</td><td class="num">200ms</td>`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+
+	store := NewMemStore()
+	store.Add(base)
+	store.Add(slow)
+	h := BaselineHandler(store, b, Options{})
+
+	// Other sites can't make their own form set baselines.
+	for header, value := range map[string]string{
+		"Sec-Fetch-Site": "cross-site",
+		"Origin":         "https://evil.example",
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/00000000000000000000000000000002/baseline", nil)
+		req.Header.Set(header, value)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("POST baseline with %s: %s: %d, want %d", header, value, rec.Code, http.StatusForbidden)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/00000000000000000000000000000002/baseline", nil)
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	req.Header.Set("Origin", "http://"+req.Host)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("POST baseline: %d %s", rec.Code, rec.Body)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := rec.Body.String(); !strings.Contains(got, `<td>baseline</td>`) || !strings.Contains(got, `<td>-100ms (-50%) <form method="post" action="./00000000000000000000000000000001/baseline">`) {
+		t.Errorf("index doesn't show the new baseline:\n%s", got)
+	}
+}
+
+func TestTenants(t *testing.T) {
+	store := NewMemStore()
+	for tid, team := range map[string]string{
+		"00000000000000000000000000000001": "a",
+		"00000000000000000000000000000002": "b",
+		"00000000000000000000000000000003": "c/d",
+		"00000000000000000000000000000004": "..",
+	} {
+		tr := testTrace()
+		for _, span := range tr.Spans {
+			span.SpanContext.TraceID = tid
+			span.Resource = []KeyValue{keyValue("team", team)}
+		}
+		store.Add(tr)
+	}
+
+	ts := &Tenants{Key: "team", Tokens: map[string]string{"b": "secret"}}
+	h := ts.Handler(store)
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.SetBasicAuth("", token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if got := get("/", "").Body.String(); !strings.Contains(got, `<a href="./a/">a</a></td>`) || !strings.Contains(got, `<a href="./b/">b</a> (token required)</td>`) {
+		t.Errorf("index doesn't list both groups:\n%s", got)
+	}
+	for _, tc := range []struct {
+		path, token string
+		code        int
+	}{
+		{"/a", "", http.StatusMovedPermanently},
+		{"/a/", "", http.StatusOK},
+		{"/a/00000000000000000000000000000001", "", http.StatusOK},
+		// Other groups' traces aren't there.
+		{"/a/00000000000000000000000000000002", "", http.StatusNotFound},
+		{"/b/", "", http.StatusUnauthorized},
+		{"/b/", "wrong", http.StatusUnauthorized},
+		{"/b/00000000000000000000000000000002", "secret", http.StatusOK},
+		{"/c%2Fd/00000000000000000000000000000003", "", http.StatusOK},
+		{"/%2E%2E/00000000000000000000000000000004", "", http.StatusOK},
+	} {
+		if got := get(tc.path, tc.token).Code; got != tc.code {
+			t.Errorf("GET %s: %d, want %d", tc.path, got, tc.code)
+		}
+	}
+	if got := get("/", "").Body.String(); !strings.Contains(got, `<a href="./c%2Fd/">c/d</a>`) || !strings.Contains(got, `<a href="./%2E%2E/">..</a>`) {
+		t.Errorf("index doesn't escape groups:\n%s", got)
+	}
+	if got := get("/a/", "").Body.String(); strings.Contains(got, "00000000000000000000000000000002") {
+		t.Errorf("a's index lists b's trace:\n%s", got)
+	}
+}
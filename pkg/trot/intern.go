@@ -0,0 +1,102 @@
+package trot
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// interner dedupes the strings and resources that repeat on every span,
+// so a million spans from one service share a single copy of each, and
+// compacts the JSON that spans keep.
+type interner struct {
+	strs      map[string]string
+	resources map[string][]KeyValue
+
+	// buf is scratch space for compacting JSON.
+	buf bytes.Buffer
+}
+
+func newInterner() *interner {
+	return &interner{
+		strs:      map[string]string{},
+		resources: map[string][]KeyValue{},
+	}
+}
+
+func (in *interner) str(s string) string {
+	if v, ok := in.strs[s]; ok {
+		return v
+	}
+	in.strs[s] = s
+	return s
+}
+
+// resource returns a shared copy of r, which callers must not modify.
+func (in *interner) resource(r []KeyValue) []KeyValue {
+	if len(r) == 0 {
+		return r
+	}
+
+	key := resourceKey(r)
+	if v, ok := in.resources[key]; ok {
+		return v
+	}
+
+	shared := make([]KeyValue, len(r))
+	for i, kv := range r {
+		shared[i] = KeyValue{Key: in.str(kv.Key), Value: kv.Value}
+		if s, ok := kv.Value.Value.(string); ok {
+			shared[i].Value.Value = in.str(s)
+		}
+		shared[i].Value.Type = in.str(kv.Value.Type)
+	}
+	in.resources[key] = shared
+	return shared
+}
+
+// resourceKey identifies a resource by its contents.
+func resourceKey(r []KeyValue) string {
+	var sb strings.Builder
+	for _, kv := range r {
+		sb.WriteString(kv.Key)
+		sb.WriteByte(0)
+		sb.WriteString(kv.Value.Type)
+		sb.WriteByte(0)
+		sb.WriteString(kv.Value.String())
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
+
+// span interns the fields of s that tend to repeat across spans.
+func (in *interner) span(s *Span) {
+	s.Name = in.str(s.Name)
+	s.SpanContext.TraceID = in.str(s.SpanContext.TraceID)
+	s.SpanContext.TraceFlags = in.str(s.SpanContext.TraceFlags)
+	s.SpanContext.TraceState = in.str(s.SpanContext.TraceState)
+	s.Parent.TraceID = in.str(s.Parent.TraceID)
+	s.Parent.TraceFlags = in.str(s.Parent.TraceFlags)
+	s.Parent.TraceState = in.str(s.Parent.TraceState)
+	s.Status.Code = in.str(s.Status.Code)
+	s.Resource = in.resource(s.Resource)
+	s.InstrumentationLibrary.Name = in.str(s.InstrumentationLibrary.Name)
+	s.InstrumentationLibrary.Version = in.str(s.InstrumentationLibrary.Version)
+	s.InstrumentationLibrary.SchemaURL = in.str(s.InstrumentationLibrary.SchemaURL)
+	s.Attributes = in.json(s.Attributes)
+	s.Events = in.json(s.Events)
+	s.Links = in.json(s.Links)
+}
+
+// json returns m without insignificant whitespace, which indented inputs
+// like stdouttrace's are mostly made of, in a slice just big enough for it.
+func (in *interner) json(m json.RawMessage) json.RawMessage {
+	if len(m) == 0 {
+		return m
+	}
+	in.buf.Reset()
+	if err := json.Compact(&in.buf, m); err != nil || in.buf.Len() == len(m) {
+		return m
+	}
+	return bytes.Clone(in.buf.Bytes())
+}
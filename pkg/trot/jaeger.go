@@ -0,0 +1,182 @@
+package trot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// jaeger is the JSON returned by Jaeger's /api/traces and "Download JSON" in its UI.
+type jaeger struct{}
+
+func (jaeger) Name() string {
+	return "jaeger"
+}
+
+func (jaeger) Sniff(peek []byte) bool {
+	return bytes.Contains(peek, []byte(`"operationName"`)) || bytes.Contains(peek, []byte(`"processes"`))
+}
+
+type jaegerTrace struct {
+	TraceID string `json:"traceID"`
+	Spans   []struct {
+		TraceID       string `json:"traceID"`
+		SpanID        string `json:"spanID"`
+		OperationName string `json:"operationName"`
+		References    []struct {
+			RefType string `json:"refType"`
+			TraceID string `json:"traceID"`
+			SpanID  string `json:"spanID"`
+		} `json:"references"`
+		StartTime int64       `json:"startTime"`
+		Duration  int64       `json:"duration"`
+		Tags      []jaegerTag `json:"tags"`
+		Logs      []struct {
+			Timestamp int64       `json:"timestamp"`
+			Fields    []jaegerTag `json:"fields"`
+		} `json:"logs"`
+		ProcessID string `json:"processID"`
+	} `json:"spans"`
+	Processes map[string]struct {
+		ServiceName string      `json:"serviceName"`
+		Tags        []jaegerTag `json:"tags"`
+	} `json:"processes"`
+}
+
+type jaegerTag struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+func kindOf(s string) int {
+	switch strings.ToLower(s) {
+	case "internal":
+		return KindInternal
+	case "server":
+		return KindServer
+	case "client":
+		return KindClient
+	case "producer":
+		return KindProducer
+	case "consumer":
+		return KindConsumer
+	}
+	return KindUnspecified
+}
+
+func micros(us int64) time.Time {
+	return time.UnixMicro(us).UTC()
+}
+
+func (jaeger) Decode(r io.Reader, t *Trace) error {
+	dec := json.NewDecoder(r)
+	for i := 1; ; i++ {
+		var doc struct {
+			Data []jaegerTrace `json:"data"`
+			jaegerTrace
+		}
+		start := dec.InputOffset()
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("document %d: %w", i, valueOffset(start, err))
+		}
+
+		traces := doc.Data
+		if len(doc.Spans) != 0 {
+			traces = append(traces, doc.jaegerTrace)
+		}
+
+		for _, jt := range traces {
+			for _, s := range jt.Spans {
+				span := &Span{
+					Name:      s.OperationName,
+					StartTime: micros(s.StartTime),
+					EndTime:   micros(s.StartTime + s.Duration),
+				}
+				span.SpanContext = SpanContext{
+					TraceID: s.TraceID,
+					SpanID:  s.SpanID,
+				}
+				span.Parent.TraceID = s.TraceID
+				span.Parent.SpanID = RootID
+				for _, ref := range s.References {
+					if ref.RefType == "CHILD_OF" || ref.RefType == "FOLLOWS_FROM" {
+						span.Parent.TraceID = ref.TraceID
+						span.Parent.SpanID = ref.SpanID
+						break
+					}
+				}
+
+				span.Status.Code = "Unset"
+				attrs := []KeyValue{}
+				for _, tag := range s.Tags {
+					switch tag.Key {
+					case "span.kind":
+						span.SpanKind = kindOf(fmt.Sprint(tag.Value))
+						continue
+					case "error":
+						if tag.Value == true {
+							span.Status.Code = "Error"
+						}
+					case "otel.status_code":
+						switch fmt.Sprint(tag.Value) {
+						case "ERROR":
+							span.Status.Code = "Error"
+						case "OK":
+							span.Status.Code = "Ok"
+						}
+						continue
+					case "otel.status_description":
+						span.Status.Description = fmt.Sprint(tag.Value)
+						continue
+					case "otel.library.name", "otel.scope.name":
+						span.InstrumentationLibrary.Name = fmt.Sprint(tag.Value)
+						continue
+					case "otel.library.version", "otel.scope.version":
+						span.InstrumentationLibrary.Version = fmt.Sprint(tag.Value)
+						continue
+					}
+					attrs = append(attrs, keyValue(tag.Key, tag.Value))
+				}
+				span.Attributes = rawJSON(attrs)
+
+				if len(s.Logs) != 0 {
+					events := []Event{}
+					for _, l := range s.Logs {
+						name := "log"
+						attrs := []KeyValue{}
+						for _, f := range l.Fields {
+							if f.Key == "event" {
+								name = fmt.Sprint(f.Value)
+								continue
+							}
+							attrs = append(attrs, keyValue(f.Key, f.Value))
+						}
+						events = append(events, Event{
+							Name:       name,
+							Time:       micros(l.Timestamp),
+							Attributes: attrs,
+						})
+					}
+					span.Events = rawJSON(events)
+				}
+
+				if p, ok := jt.Processes[s.ProcessID]; ok {
+					span.Resource = append(span.Resource, KeyValue{Key: "service.name", Value: valueOf(p.ServiceName)})
+					for _, tag := range p.Tags {
+						span.Resource = append(span.Resource, KeyValue{Key: tag.Key, Value: valueOf(tag.Value)})
+					}
+				}
+
+				t.Add(span)
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package trot
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// LinkTemplate links traces and spans to another tool, like Jaeger or Tempo.
+type LinkTemplate struct {
+	// Name is what links say they open, the template's host by default.
+	Name string
+
+	tmpl *template.Template
+}
+
+// LinkData is what a LinkTemplate can refer to. SpanID and the rest are
+// empty when linking to a whole trace, and Path, Line, and Function are only
+// set for links to source code. Strings are escaped so they can go anywhere
+// in a URL, e.g. "GET /users" as GET%20%2Fusers, except for the slashes
+// between Path's directories.
+type LinkData struct {
+	TraceID  string
+	SpanID   string
+	Name     string
+	Service  string
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+
+	Path     string
+	Line     int
+	Function string
+}
+
+// ParseLinkTemplate parses a text/template for a URL, optionally prefixed
+// with "name=" to label its links, e.g.
+// "tempo=https://grafana/explore?traceID={{.TraceID}}".
+func ParseLinkTemplate(s string) (LinkTemplate, error) {
+	var l LinkTemplate
+	// The URL itself probably has an = in it, so only look before the scheme.
+	if i, j := strings.Index(s, "="), strings.Index(s, "://"); i > 0 && (j < 0 || i < j) {
+		l.Name, s = s[:i], s[i+1:]
+	}
+	tmpl, err := template.New("link").Option("missingkey=error").Parse(s)
+	if err != nil {
+		return LinkTemplate{}, fmt.Errorf("link template %q: %w", s, err)
+	}
+	l.tmpl = tmpl
+	if l.Name == "" {
+		if u, err := url.Parse(s); err == nil && u.Host != "" {
+			l.Name = u.Hostname()
+		} else {
+			l.Name = "link"
+		}
+	}
+	// Catch references to fields that don't exist now rather than per span.
+	if _, err := l.execute(LinkData{}); err != nil {
+		return LinkTemplate{}, fmt.Errorf("link template %q: %w", s, err)
+	}
+	return l, nil
+}
+
+func (l LinkTemplate) execute(data LinkData) (string, error) {
+	data.TraceID = urlEscape(data.TraceID)
+	data.SpanID = urlEscape(data.SpanID)
+	data.Name = urlEscape(data.Name)
+	data.Service = urlEscape(data.Service)
+	data.Function = urlEscape(data.Function)
+	dirs := strings.Split(data.Path, "/")
+	for i, dir := range dirs {
+		dirs[i] = urlEscape(dir)
+	}
+	data.Path = strings.Join(dirs, "/")
+
+	var sb strings.Builder
+	if err := l.tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// urlEscape escapes s for a URL path segment or query value alike.
+func urlEscape(s string) string {
+	// QueryEscape writes spaces as +, which only means a space in queries,
+	// and escapes any + that was already there.
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// Trace returns the link for the trace with traceID.
+func (l LinkTemplate) Trace(traceID string) string {
+	u, _ := l.execute(LinkData{TraceID: traceID})
+	return u
+}
+
+// Span returns the link for span.
+func (l LinkTemplate) Span(span *Span) string {
+	u, _ := l.execute(LinkData{
+		TraceID:  span.SpanContext.TraceID,
+		SpanID:   span.SpanContext.SpanID,
+		Name:     span.Name,
+		Service:  span.Service(),
+		Start:    span.StartTime,
+		End:      span.EndTime,
+		Duration: span.Duration(),
+	})
+	return u
+}
@@ -0,0 +1,49 @@
+package trot
+
+import "testing"
+
+func TestParseLinkTemplate(t *testing.T) {
+	span := span("b", "a", "b", 10, 40)
+	span.SpanContext.TraceID = "t"
+	span.Name = "GET /users?id=1"
+	span.Resource = []KeyValue{keyValue("service.name", "web & api")}
+
+	for _, tc := range []struct {
+		in, name, trace, span string
+	}{{
+		in:    "https://grafana.example/explore?traceID={{.TraceID}}",
+		name:  "grafana.example",
+		trace: "https://grafana.example/explore?traceID=t",
+		span:  "https://grafana.example/explore?traceID=t",
+	}, {
+		in:    "jaeger=http://localhost:16686/trace/{{.TraceID}}?uiFind={{.SpanID}}",
+		name:  "jaeger",
+		trace: "http://localhost:16686/trace/t?uiFind=",
+		span:  "http://localhost:16686/trace/t?uiFind=b",
+	}, {
+		in:    "https://logs.example/search?q=service%3A{{.Service}}+{{.Name}}&from={{.Start.Unix}}",
+		name:  "logs.example",
+		trace: "https://logs.example/search?q=service%3A+&from=-62135596800",
+		span:  "https://logs.example/search?q=service%3Aweb%20%26%20api+GET%20%2Fusers%3Fid%3D1&from=1704067200",
+	}} {
+		l, err := ParseLinkTemplate(tc.in)
+		if err != nil {
+			t.Fatalf("ParseLinkTemplate(%q): %v", tc.in, err)
+		}
+		if l.Name != tc.name {
+			t.Errorf("%q: Name = %q, want %q", tc.in, l.Name, tc.name)
+		}
+		if got := l.Trace("t"); got != tc.trace {
+			t.Errorf("%q: Trace() = %q, want %q", tc.in, got, tc.trace)
+		}
+		if got := l.Span(span); got != tc.span {
+			t.Errorf("%q: Span() = %q, want %q", tc.in, got, tc.span)
+		}
+	}
+
+	for _, bad := range []string{"{{.Nope}}", "{{"} {
+		if _, err := ParseLinkTemplate(bad); err == nil {
+			t.Errorf("ParseLinkTemplate(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package trot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Log is a structured log line written during a span.
+type Log struct {
+	TraceID, SpanID string
+
+	Time       time.Time
+	Severity   string
+	Message    string
+	Attributes []KeyValue
+}
+
+// ParseLogs reads OTLP/JSON logs, or JSON lines with trace_id and span_id
+// fields (as most structured loggers can be configured to write). Lines
+// that don't name a span are skipped.
+func ParseLogs(r io.Reader) ([]Log, error) {
+	br := bufio.NewReaderSize(r, sniffLen)
+	peek, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+
+	tr := &tracker{r: br}
+	format, decode := "json logs", decodeJSONLogs
+	if bytes.Contains(peek, []byte(`"resourceLogs"`)) {
+		format, decode = "otlp logs", decodeOTLPLogs
+	}
+	logs, err := decode(tr)
+	if err != nil {
+		return nil, tr.parseError(format, false, err)
+	}
+	return logs, nil
+}
+
+// Fields that loggers commonly use, most common first.
+var (
+	traceIDKeys  = []string{"trace_id", "traceId", "traceID"}
+	spanIDKeys   = []string{"span_id", "spanId", "spanID"}
+	timeKeys     = []string{"time", "timestamp", "ts"}
+	severityKeys = []string{"level", "severity", "severity_text"}
+	messageKeys  = []string{"msg", "message", "body"}
+)
+
+func decodeJSONLogs(r io.Reader) ([]Log, error) {
+	logs := []Log{}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	for i := 1; ; i++ {
+		var line map[string]any
+		start := dec.InputOffset()
+		if err := dec.Decode(&line); err != nil {
+			if errors.Is(err, io.EOF) {
+				return logs, nil
+			}
+			return nil, fmt.Errorf("log %d: %w", i, valueOffset(start, err))
+		}
+
+		l := Log{
+			TraceID:  take(line, traceIDKeys),
+			SpanID:   take(line, spanIDKeys),
+			Severity: take(line, severityKeys),
+			Message:  take(line, messageKeys),
+		}
+		if l.SpanID == "" {
+			continue
+		}
+		l.Time = logTime(take(line, timeKeys))
+
+		keys := make([]string, 0, len(line))
+		for k := range line {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v := line[k]
+			if n, ok := v.(json.Number); ok {
+				v, _ = n.Float64()
+			}
+			l.Attributes = append(l.Attributes, keyValue(k, v))
+		}
+
+		logs = append(logs, l)
+	}
+}
+
+// take removes and returns the first of keys present in line.
+func take(line map[string]any, keys []string) string {
+	for _, k := range keys {
+		if v, ok := line[k]; ok {
+			delete(line, k)
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// logTime parses RFC 3339 times and Unix timestamps in seconds, millis,
+// micros, or nanos, guessing the unit from the magnitude.
+func logTime(s string) time.Time {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t
+	}
+	var f float64
+	if _, err := fmt.Sscan(s, &f); err != nil {
+		return time.Time{}
+	}
+	switch {
+	case f > 1e17:
+		return time.Unix(0, int64(f)).UTC()
+	case f > 1e14:
+		return time.UnixMicro(int64(f)).UTC()
+	case f > 1e11:
+		return time.UnixMilli(int64(f)).UTC()
+	}
+	return time.Unix(0, int64(f*1e9)).UTC()
+}
+
+type otlpLogsRequest struct {
+	ResourceLogs []struct {
+		ScopeLogs []struct {
+			LogRecords []struct {
+				TimeUnixNano         unixNano       `json:"timeUnixNano"`
+				ObservedTimeUnixNano unixNano       `json:"observedTimeUnixNano"`
+				SeverityText         string         `json:"severityText"`
+				Body                 otlpAnyValue   `json:"body"`
+				Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+				TraceID              string         `json:"traceId"`
+				SpanID               string         `json:"spanId"`
+			} `json:"logRecords"`
+		} `json:"scopeLogs"`
+	} `json:"resourceLogs"`
+}
+
+func decodeOTLPLogs(r io.Reader) ([]Log, error) {
+	logs := []Log{}
+	dec := json.NewDecoder(r)
+	for i := 1; ; i++ {
+		var req otlpLogsRequest
+		start := dec.InputOffset()
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return logs, nil
+			}
+			return nil, fmt.Errorf("request %d: %w", i, valueOffset(start, err))
+		}
+
+		for _, rl := range req.ResourceLogs {
+			for _, sl := range rl.ScopeLogs {
+				for _, lr := range sl.LogRecords {
+					if lr.SpanID == "" {
+						continue
+					}
+					l := Log{
+						TraceID:    otlpID(lr.TraceID),
+						SpanID:     otlpID(lr.SpanID),
+						Time:       time.Time(lr.TimeUnixNano),
+						Severity:   lr.SeverityText,
+						Attributes: otlpAttributes(lr.Attributes),
+					}
+					if l.Time.IsZero() {
+						l.Time = time.Time(lr.ObservedTimeUnixNano)
+					}
+					if body := lr.Body.value(); body != nil {
+						l.Message = fmt.Sprint(body)
+					}
+					logs = append(logs, l)
+				}
+			}
+		}
+	}
+}
+
+// AttachLogs adds each log to its span in t as a "log" event, and returns
+// how many of them matched a span.
+func (t *Trace) AttachLogs(logs []Log) int {
+	bySpan := map[*Span][]Log{}
+	for _, l := range logs {
+		span, ok := t.Spans[l.SpanID]
+		if !ok || l.TraceID != "" && l.TraceID != span.SpanContext.TraceID {
+			continue
+		}
+		bySpan[span] = append(bySpan[span], l)
+	}
+
+	n := 0
+	for span, logs := range bySpan {
+		events := span.DecodeEvents()
+		for _, l := range logs {
+			attrs := []KeyValue{keyValue("severity", l.Severity), keyValue("message", l.Message)}
+			events = append(events, Event{
+				Name:       "log",
+				Time:       l.Time,
+				Attributes: append(attrs, l.Attributes...),
+			})
+		}
+		sort.SliceStable(events, func(i, j int) bool {
+			return events[i].Time.Before(events[j].Time)
+		})
+		span.Events = rawJSON(events)
+		n += len(logs)
+	}
+	return n
+}
+
+// logs pulls "log" events, as added by AttachLogs, out of the span.
+func (s *Span) logs() []Event {
+	// This runs for every rendered span, and most have no logs.
+	if !bytes.Contains(s.Events, []byte(`"log"`)) {
+		return nil
+	}
+	logs := []Event{}
+	for _, e := range s.DecodeEvents() {
+		if e.Name == "log" {
+			logs = append(logs, e)
+		}
+	}
+	return logs
+}
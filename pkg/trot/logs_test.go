@@ -0,0 +1,54 @@
+package trot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAttachLogs(t *testing.T) {
+	for _, tc := range []struct {
+		name, input string
+	}{{
+		name: "json lines",
+		input: `{"time":"2024-01-01T00:00:00.02Z","level":"INFO","msg":"hello","trace_id":"t","span_id":"b","n":1}
+{"msg":"no span"}
+{"ts":1704067200.03,"level":"WARN","msg":"other trace","trace_id":"u","span_id":"b"}
+`,
+	}, {
+		name: "otlp",
+		input: `{"resourceLogs":[{"scopeLogs":[{"logRecords":[
+{"timeUnixNano":"1704067200020000000","severityText":"INFO","body":{"stringValue":"hello"},"traceId":"t","spanId":"b","attributes":[{"key":"n","value":{"intValue":"1"}}]},
+{"timeUnixNano":"1704067200030000000","body":{"stringValue":"no span"}}
+]}]}]}`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			logs, err := ParseLogs(strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tr := testTrace()
+			for _, s := range tr.Spans {
+				s.SpanContext.TraceID = "t"
+			}
+			if got := tr.AttachLogs(logs); got != 1 {
+				t.Errorf("AttachLogs() = %d, want 1", got)
+			}
+
+			got := tr.Spans["b"].logs()
+			if len(got) != 1 {
+				t.Fatalf("logs() = %v, want 1 log", got)
+			}
+			if want := epoch.Add(20 * time.Millisecond); !got[0].Time.Equal(want) {
+				t.Errorf("Time = %v, want %v", got[0].Time, want)
+			}
+			if msg, _ := lookup(got[0].Attributes, "message"); msg != "hello" {
+				t.Errorf("message = %q, want hello", msg)
+			}
+			if n, _ := lookup(got[0].Attributes, "n"); n != "1" {
+				t.Errorf("n = %q, want 1", n)
+			}
+		})
+	}
+}
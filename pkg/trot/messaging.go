@@ -0,0 +1,61 @@
+package trot
+
+import "time"
+
+// Handoff is a message passing from a producer span to a consumer span.
+type Handoff struct {
+	Producer, Consumer *Span
+}
+
+// Queued is how long the message waited between being published and being
+// consumed.
+func (h Handoff) Queued() time.Duration {
+	// Clocks on different hosts can disagree.
+	return max(0, h.Consumer.StartTime.Sub(h.Producer.EndTime))
+}
+
+// Handoffs finds every consumer span under n that consumed from a producer
+// span also under n, keyed by the consumer. Producers are found through
+// span links, or as the consumer's parent.
+func (n *Node) Handoffs() map[*Span]Handoff {
+	var consumers []*Node
+	parents := map[*Node]*Node{}
+	n.Walk(func(node *Node, _ int) bool {
+		for _, kid := range node.Children {
+			if kid.Span.SpanKind == KindConsumer {
+				consumers = append(consumers, kid)
+				parents[kid] = node
+			}
+		}
+		return true
+	})
+	// Most traces have no messaging at all.
+	if len(consumers) == 0 {
+		return nil
+	}
+
+	producers := map[string]*Span{}
+	n.Walk(func(node *Node, _ int) bool {
+		if node.Span.SpanKind == KindProducer {
+			producers[node.Span.SpanContext.SpanID] = node.Span
+		}
+		return true
+	})
+
+	handoffs := map[*Span]Handoff{}
+	for _, c := range consumers {
+		for _, l := range decodeAs[link](c.Span.Links) {
+			if p, ok := producers[l.SpanContext.SpanID]; ok {
+				handoffs[c.Span] = Handoff{Producer: p, Consumer: c.Span}
+				break
+			}
+		}
+		if _, ok := handoffs[c.Span]; ok {
+			continue
+		}
+		if p := parents[c]; p.Span.SpanKind == KindProducer {
+			handoffs[c.Span] = Handoff{Producer: p.Span, Consumer: c.Span}
+		}
+	}
+	return handoffs
+}
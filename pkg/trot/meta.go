@@ -0,0 +1,96 @@
+package trot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"time"
+)
+
+// Meta is what a dashboard might want to know about a trace, to index pages
+// without parsing them.
+type Meta struct {
+	TraceID  string        `json:"trace_id"`
+	Name     string        `json:"name"`
+	Service  string        `json:"service"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration_ns"`
+	Spans    int           `json:"spans"`
+	Errors   int           `json:"errors"`
+	Services []string      `json:"services"`
+
+	// CriticalPath is the chain of spans, from the root down, that the end
+	// of the trace waited on.
+	CriticalPath []MetaSpan `json:"critical_path"`
+
+	Warnings []string `json:"warnings,omitempty"`
+
+	// Page, if set, is where the trace is rendered, relative to the Meta.
+	Page string `json:"page,omitempty"`
+}
+
+// MetaSpan is a span on a Meta's CriticalPath.
+type MetaSpan struct {
+	SpanID   string        `json:"span_id"`
+	Name     string        `json:"name"`
+	Service  string        `json:"service"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Metas describes each trace in t, oldest first.
+func (t *Trace) Metas() []Meta {
+	metas := []Meta{}
+	for _, tt := range t.Split() {
+		s := tt.Summarize()
+		m := Meta{
+			TraceID:      s.TraceID,
+			Name:         s.Name,
+			Service:      s.Service,
+			Start:        s.Start,
+			Duration:     s.Duration,
+			Spans:        s.Spans,
+			Errors:       s.Errors,
+			Services:     []string{},
+			CriticalPath: []MetaSpan{},
+			Warnings:     tt.Warnings(),
+		}
+
+		services := map[string]bool{}
+		for _, span := range tt.Spans {
+			if svc := span.Service(); !services[svc] {
+				services[svc] = true
+				m.Services = append(m.Services, svc)
+			}
+		}
+		sort.Strings(m.Services)
+
+		// Skip the synthetic root.
+		for _, n := range tt.Tree("", RootID).CriticalPath()[1:] {
+			m.CriticalPath = append(m.CriticalPath, MetaSpan{
+				SpanID:   n.Span.SpanContext.SpanID,
+				Name:     n.Span.Name,
+				Service:  n.Span.Service(),
+				Duration: n.Span.Duration(),
+			})
+		}
+		metas = append(metas, m)
+	}
+	sort.Slice(metas, func(i, j int) bool {
+		if !metas[i].Start.Equal(metas[j].Start) {
+			return metas[i].Start.Before(metas[j].Start)
+		}
+		return metas[i].TraceID < metas[j].TraceID
+	})
+	return metas
+}
+
+// PageName is what to call the file for the page of the trace with traceID,
+// without an extension: the TraceID if it's hex, as real ones are, or else a
+// hash of it, since any input can claim any TraceID, like "../x".
+func PageName(traceID string) string {
+	if traceID != "" && isHexID(traceID, len(traceID)) {
+		return traceID
+	}
+	sum := sha256.Sum256([]byte(traceID))
+	return "trace-" + hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,58 @@
+package trot
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// ParseNameTemplate parses a text/template for what to call spans, e.g.
+// `{{.Name}} {{attr "http.route"}}`. It is executed with the *Span, and can
+// look up span and resource attributes with attr and resource, and abbreviate
+// digests with short.
+func ParseNameTemplate(s string) (*template.Template, error) {
+	tmpl, err := template.New("name").Funcs(nameFuncs(nil)).Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("name template %q: %w", s, err)
+	}
+	return tmpl, nil
+}
+
+// nameFuncs looks up attributes of *cur, the span being named.
+func nameFuncs(cur **Span) template.FuncMap {
+	return template.FuncMap{
+		"attr": func(key string) string {
+			v, _ := (*cur).Attr(key)
+			return v
+		},
+		"resource": func(key string) string {
+			v, _ := lookup((*cur).Resource, key)
+			return v
+		},
+		"short": shortDigest,
+	}
+}
+
+// namer executes a name template, which needs its own copy per renderer
+// since the functions refer to the span being named.
+type namer struct {
+	tmpl *template.Template
+	cur  *Span
+	sb   strings.Builder
+}
+
+func newNamer(tmpl *template.Template) *namer {
+	n := &namer{}
+	n.tmpl = template.Must(tmpl.Clone()).Funcs(nameFuncs(&n.cur))
+	return n
+}
+
+// name returns what to call s, falling back to its Name if the template fails.
+func (n *namer) name(s *Span) string {
+	n.cur = s
+	n.sb.Reset()
+	if err := n.tmpl.Execute(&n.sb, s); err != nil {
+		return s.Name
+	}
+	return strings.TrimSpace(n.sb.String())
+}
@@ -0,0 +1,50 @@
+package trot
+
+import "testing"
+
+func TestNameTemplate(t *testing.T) {
+	tmpl, err := ParseNameTemplate(`{{.Name}} {{attr "http.route"}} {{resource "service.name"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := newNamer(tmpl)
+
+	s := &Span{
+		Name:       "HTTP GET",
+		Attributes: rawJSON([]KeyValue{keyValue("http.route", "/users/:id")}),
+		Resource:   []KeyValue{keyValue("service.name", "api")},
+	}
+	if got, want := n.name(s), "HTTP GET /users/:id api"; got != want {
+		t.Errorf("name() = %q, want %q", got, want)
+	}
+	if got, want := n.name(&Span{Name: "bare"}), "bare"; got != want {
+		t.Errorf("name() = %q, want %q", got, want)
+	}
+
+	if _, err := ParseNameTemplate(`{{nope "x"}}`); err == nil {
+		t.Error("ParseNameTemplate with an unknown function succeeded")
+	}
+}
+
+func TestProfile(t *testing.T) {
+	tmpl, err := Profile("crane")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := newNamer(tmpl)
+
+	s := &Span{
+		Name:       "push",
+		Attributes: rawJSON([]KeyValue{keyValue("image.ref", "ghcr.io/org/app:v1"), keyValue("image.digest", "sha256:0123456789abcdef0123")}),
+	}
+	if got, want := n.name(s), "push ghcr.io/org/app:v1 0123456789ab"; got != want {
+		t.Errorf("name() = %q, want %q", got, want)
+	}
+	if got, want := n.name(&Span{Name: "bare"}), "bare"; got != want {
+		t.Errorf("name() = %q, want %q", got, want)
+	}
+
+	if _, err := Profile("nope"); err == nil {
+		t.Error("Profile of an unknown tool succeeded")
+	}
+}
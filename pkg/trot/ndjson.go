@@ -0,0 +1,103 @@
+package trot
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// kindNames are OTLP's span kinds, lowercased, by SpanKind.
+var kindNames = []string{"unspecified", "internal", "server", "client", "producer", "consumer"}
+
+// flatSpan is a span with nothing nested that jq would have to dig for:
+// attributes and resources are plain objects, and times are both RFC 3339
+// and nanoseconds.
+type flatSpan struct {
+	TraceID       string         `json:"trace_id"`
+	SpanID        string         `json:"span_id"`
+	ParentSpanID  string         `json:"parent_span_id,omitempty"`
+	Name          string         `json:"name"`
+	Kind          string         `json:"kind"`
+	Service       string         `json:"service"`
+	Start         time.Time      `json:"start"`
+	End           time.Time      `json:"end"`
+	StartUnixNano int64          `json:"start_unix_nano"`
+	DurationNS    int64          `json:"duration_ns"`
+	Status        string         `json:"status"`
+	StatusMessage string         `json:"status_message,omitempty"`
+	Scope         string         `json:"scope,omitempty"`
+	Attributes    map[string]any `json:"attributes,omitempty"`
+	Resource      map[string]any `json:"resource,omitempty"`
+	Events        []flatEvent    `json:"events,omitempty"`
+	Links         []flatLink     `json:"links,omitempty"`
+}
+
+type flatEvent struct {
+	Name       string         `json:"name"`
+	Time       time.Time      `json:"time"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+type flatLink struct {
+	TraceID    string         `json:"trace_id"`
+	SpanID     string         `json:"span_id"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+func flatAttrs(kvs []KeyValue) map[string]any {
+	if len(kvs) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(kvs))
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value.Value
+	}
+	return m
+}
+
+func flatten(span *Span) flatSpan {
+	f := flatSpan{
+		TraceID:       span.SpanContext.TraceID,
+		SpanID:        span.SpanContext.SpanID,
+		Name:          span.Name,
+		Kind:          kindNames[KindUnspecified],
+		Service:       span.Service(),
+		Start:         span.StartTime,
+		End:           span.EndTime,
+		StartUnixNano: span.StartTime.UnixNano(),
+		DurationNS:    int64(span.Duration()),
+		Status:        span.Status.Code,
+		StatusMessage: span.Status.Description,
+		Scope:         span.Scope(),
+		Attributes:    flatAttrs(span.Attrs()),
+		Resource:      flatAttrs(span.Resource),
+	}
+	if span.Parent.SpanID != RootID {
+		f.ParentSpanID = span.Parent.SpanID
+	}
+	if span.SpanKind >= 0 && span.SpanKind < len(kindNames) {
+		f.Kind = kindNames[span.SpanKind]
+	}
+	if f.Status == "" {
+		f.Status = "Unset"
+	}
+	for _, ev := range span.DecodeEvents() {
+		f.Events = append(f.Events, flatEvent{Name: ev.Name, Time: ev.Time, Attributes: flatAttrs(ev.Attributes)})
+	}
+	for _, l := range decodeAs[link](span.Links) {
+		f.Links = append(f.Links, flatLink{TraceID: l.SpanContext.TraceID, SpanID: l.SpanContext.SpanID, Attributes: flatAttrs(l.Attributes)})
+	}
+	return f
+}
+
+// EncodeNDJSON writes every span in t as a flat JSON object, one per line,
+// ordered by start time, for piping into jq and the like.
+func EncodeNDJSON(w io.Writer, t *Trace) error {
+	enc := json.NewEncoder(w)
+	for _, span := range t.Sorted() {
+		if err := enc.Encode(flatten(span)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package trot
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEncodeNDJSON(t *testing.T) {
+	input := `{"Timestamp":"2024-01-01T00:00:00Z","trace.trace_id":"t1","trace.span_id":"a","name":"GET /","service.name":"web","duration_ms":20,"span.kind":"server","http.status_code":200}
+{"Timestamp":"2024-01-01T00:00:00.005Z","trace.trace_id":"t1","trace.span_id":"b","trace.parent_id":"a","name":"SELECT","service.name":"db","duration_ms":10,"error":true}`
+	tr, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeNDJSON(&buf, tr); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+
+	// The same name find -json, alerts, and meta.json use.
+	if !strings.Contains(lines[0], `"duration_ns":20000000`) {
+		t.Errorf("durations aren't duration_ns:\n%s", lines[0])
+	}
+
+	var spans []flatSpan
+	for _, line := range lines {
+		var s flatSpan
+		if err := json.Unmarshal([]byte(line), &s); err != nil {
+			t.Fatal(err)
+		}
+		spans = append(spans, s)
+	}
+	root, child := spans[0], spans[1]
+	if root.ParentSpanID != "" || root.Kind != "server" || root.Service != "web" {
+		t.Errorf("root: %+v", root)
+	}
+	if root.DurationNS != 20e6 || root.Attributes["http.status_code"] != 200.0 {
+		t.Errorf("root: %+v", root)
+	}
+	if child.ParentSpanID != "a" || child.Status != "Error" || child.Resource["service.name"] != "db" {
+		t.Errorf("child: %+v", child)
+	}
+}
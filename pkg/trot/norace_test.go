@@ -0,0 +1,5 @@
+//go:build !race
+
+package trot
+
+const raceEnabled = false
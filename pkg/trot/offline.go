@@ -0,0 +1,53 @@
+package trot
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+)
+
+var (
+	externalRE = regexp.MustCompile(`(?i)<(?:script|img|link|iframe|frame|audio|video|source|track|embed|object|input)\b[^>]*\s(?:src|href|data|srcset|poster)\s*=\s*["']?\s*(?:[a-z][a-z0-9+.-]*:)?//`)
+	cssURLRE   = regexp.MustCompile(`(?i)url\(\s*["']?\s*(?:[a-z][a-z0-9+.-]*:)?//|@import`)
+	inlineRE   = regexp.MustCompile(`(?is)<(script|style)\b[^>]*>(.*?)</(?:script|style)>`)
+)
+
+// SealOffline checks that page, a rendered HTML page, loads nothing from
+// anywhere else and that its inline CSS and JavaScript add up to at most
+// budget bytes. If so, it returns page with the SHA-256 of everything else
+// in a footer, so archived copies can be checked:
+//
+//	grep -v '^<footer class="sha256">' page.html | sha256sum
+func SealOffline(page []byte, budget int) ([]byte, error) {
+	if m := externalRE.Find(page); m != nil {
+		return nil, fmt.Errorf("page loads an external resource: %.80s", m)
+	}
+	if m := cssURLRE.Find(page); m != nil {
+		return nil, fmt.Errorf("page's CSS loads an external resource: %.80s", m)
+	}
+	inline := 0
+	for _, m := range inlineRE.FindAllSubmatch(page, -1) {
+		inline += len(m[2])
+	}
+	if inline > budget {
+		return nil, fmt.Errorf("inline CSS and JavaScript is %d bytes, over the budget of %d", inline, budget)
+	}
+
+	end := bytes.LastIndex(page, []byte("</body>"))
+	if end < 0 {
+		return nil, fmt.Errorf("page has no </body>")
+	}
+	// Put the footer on its own line so it's easy to strip for checking.
+	if end == 0 || page[end-1] != '\n' {
+		page = append(page[:end:end], append([]byte{'\n'}, page[end:]...)...)
+		end++
+	}
+	sum := sha256.Sum256(page)
+	footer := fmt.Sprintf("<footer class=\"sha256\">sha256:%x</footer>\n", sum)
+
+	out := make([]byte, 0, len(page)+len(footer))
+	out = append(out, page[:end]...)
+	out = append(out, footer...)
+	return append(out, page[end:]...), nil
+}
@@ -0,0 +1,46 @@
+package trot
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSealOffline(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderHTML(&buf, testTrace(), Options{}); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := SealOffline(buf.Bytes(), 64<<10)
+	if err != nil {
+		t.Fatalf("SealOffline(rendered page): %v", err)
+	}
+
+	// Stripping the footer line gives back what was hashed.
+	lines := strings.SplitAfter(string(sealed), "\n")
+	var rest strings.Builder
+	var footer string
+	for _, l := range lines {
+		if strings.HasPrefix(l, `<footer class="sha256">`) {
+			footer = l
+			continue
+		}
+		rest.WriteString(l)
+	}
+	if want := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(rest.String()))); !strings.Contains(footer, want) {
+		t.Errorf("footer = %q, want it to contain %s", footer, want)
+	}
+
+	for _, bad := range []string{
+		`<html><head><script src="https://cdn.example/x.js"></script></head><body></body></html>`,
+		`<html><head><link rel="stylesheet" href="//cdn.example/x.css"></head><body></body></html>`,
+		`<html><head><style>body { background: url('http://example.com/x.png') }</style></head><body></body></html>`,
+		`<html><head><style>` + strings.Repeat("x", 100) + `</style></head><body></body></html>`,
+	} {
+		if _, err := SealOffline([]byte(bad), 64); err == nil {
+			t.Errorf("SealOffline(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package trot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// otelcli is the span files otel-cli server json writes, one per span
+// (<dir>/<trace>/<span>/span.json), so shell scripts instrumented with
+// otel-cli can be traced without a collector.
+type otelcli struct{}
+
+func (otelcli) Name() string {
+	return "otelcli"
+}
+
+func (otelcli) Sniff(peek []byte) bool {
+	return bytes.Contains(peek, []byte(`"parent_span_id"`)) && bytes.Contains(peek, []byte(`"elapsed_ms"`))
+}
+
+type otelcliSpan struct {
+	TraceID    string            `json:"trace_id"`
+	SpanID     string            `json:"span_id"`
+	Parent     string            `json:"parent_span_id"`
+	Library    string            `json:"library"`
+	Name       string            `json:"name"`
+	Kind       string            `json:"kind"`
+	Start      time.Time         `json:"start"`
+	End        time.Time         `json:"end"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+func (otelcli) Decode(r io.Reader, t *Trace) error {
+	dec := json.NewDecoder(r)
+	for i := 1; ; i++ {
+		var s otelcliSpan
+		start := dec.InputOffset()
+		if err := dec.Decode(&s); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("span %d: %w", i, valueOffset(start, err))
+		}
+
+		span := &Span{
+			Name:      s.Name,
+			SpanKind:  kindOf(s.Kind),
+			StartTime: s.Start,
+			EndTime:   s.End,
+		}
+		span.SpanContext = SpanContext{TraceID: s.TraceID, SpanID: s.SpanID}
+		span.Parent.TraceID, span.Parent.SpanID = s.TraceID, s.Parent
+		if s.Parent == "" {
+			span.Parent.SpanID = RootID
+		}
+		span.Status.Code = "Unset"
+
+		keys := make([]string, 0, len(s.Attributes))
+		for k := range s.Attributes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		attrs := make([]KeyValue, 0, len(keys))
+		for _, k := range keys {
+			attrs = append(attrs, keyValue(k, s.Attributes[k]))
+		}
+		span.Attributes = rawJSON(attrs)
+
+		span.Resource = []KeyValue{keyValue("service.name", "otel-cli")}
+		span.InstrumentationLibrary.Name = s.Library
+		t.Add(span)
+	}
+}
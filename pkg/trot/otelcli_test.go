@@ -0,0 +1,42 @@
+package trot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOtelCLI(t *testing.T) {
+	// One span per process, and the outermost arrives last.
+	files := []string{
+		`{"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b8","parent_span_id":"1111111111111111","library":"otel-cli","name":"make","kind":"client","start":"2024-01-01T00:00:01Z","end":"2024-01-01T00:00:03Z","elapsed_ms":2000,"attributes":{"process.command":"make"}}`,
+		`{"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"2222222222222222","parent_span_id":"1111111111111111","library":"otel-cli","name":"test","kind":"client","start":"2024-01-01T00:00:03Z","end":"2024-01-01T00:00:04Z","elapsed_ms":1000,"attributes":{}}`,
+		`{"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"1111111111111111","parent_span_id":"","library":"otel-cli","name":"ci.sh","kind":"client","start":"2024-01-01T00:00:00Z","end":"2024-01-01T00:00:05Z","elapsed_ms":5000,"attributes":{}}`,
+	}
+
+	store := NewMemStore()
+	for _, f := range files {
+		tr, err := Parse(strings.NewReader(f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.Add(tr)
+	}
+
+	tr, ok := store.Get("4bf92f3577b34da6a3ce929d0e0e4736")
+	if !ok {
+		t.Fatal("trace not stored")
+	}
+	root := tr.Tree("root", RootID).Children
+	if got, want := names(root), "ci.sh"; got != want {
+		t.Fatalf("roots = %s, want %s", got, want)
+	}
+	if got, want := names(root[0].Children), "make,test"; got != want {
+		t.Fatalf("children = %s, want %s", got, want)
+	}
+	if got, want := root[0].Children[0].Span.Summary(), "make"; got != want {
+		t.Errorf("make summary = %q, want %q", got, want)
+	}
+	if got := store.List(); len(got) != 1 || got[0].Spans != 3 {
+		t.Errorf("List() = %+v, want one trace of 3 spans", got)
+	}
+}
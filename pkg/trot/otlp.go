@@ -0,0 +1,442 @@
+package trot
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// otlp is the OTLP/JSON encoding of ExportTraceServiceRequest, one per line
+// as written by the collector's file exporter.
+type otlp struct{}
+
+func (otlp) Name() string {
+	return "otlp"
+}
+
+func (otlp) Sniff(peek []byte) bool {
+	return bytes.Contains(peek, []byte(`"resourceSpans"`))
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes,omitempty"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+
+	// Pre-1.0 name for scopeSpans.
+	InstrumentationLibrarySpans []otlpScopeSpans `json:"instrumentationLibrarySpans,omitempty"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpScopeSpans struct {
+	Scope     otlpScope  `json:"scope"`
+	SchemaURL string     `json:"schemaUrl,omitempty"`
+	Spans     []otlpSpan `json:"spans"`
+
+	// Pre-1.0 name for scope.
+	InstrumentationLibrary *otlpScope `json:"instrumentationLibrary,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	TraceState        string         `json:"traceState,omitempty"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Flags             int            `json:"flags,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano unixNano       `json:"startTimeUnixNano"`
+	EndTimeUnixNano   unixNano       `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	DroppedAttributes int            `json:"droppedAttributesCount,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	DroppedEvents     int            `json:"droppedEventsCount,omitempty"`
+	Links             []otlpLink     `json:"links,omitempty"`
+	DroppedLinks      int            `json:"droppedLinksCount,omitempty"`
+	Status            struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	} `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano unixNano       `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpLink struct {
+	TraceID    string         `json:"traceId"`
+	SpanID     string         `json:"spanId"`
+	TraceState string         `json:"traceState,omitempty"`
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *otlpInt `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	ArrayValue  *struct {
+		Values []otlpAnyValue `json:"values"`
+	} `json:"arrayValue,omitempty"`
+	KvlistValue *struct {
+		Values []otlpKeyValue `json:"values"`
+	} `json:"kvlistValue,omitempty"`
+	BytesValue *string `json:"bytesValue,omitempty"`
+}
+
+func (v otlpAnyValue) value() any {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	case v.IntValue != nil:
+		return int64(*v.IntValue)
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.ArrayValue != nil:
+		vals := make([]any, len(v.ArrayValue.Values))
+		for i, val := range v.ArrayValue.Values {
+			vals[i] = val.value()
+		}
+		return vals
+	case v.KvlistValue != nil:
+		// Attributes can't be maps, so keep nested ones as JSON.
+		return string(rawJSON(v.jsonValue()))
+	case v.BytesValue != nil:
+		return *v.BytesValue
+	}
+	return nil
+}
+
+// jsonValue is like value, but keeps kvlistValues as maps.
+func (v otlpAnyValue) jsonValue() any {
+	switch {
+	case v.KvlistValue != nil:
+		m := make(map[string]any, len(v.KvlistValue.Values))
+		for _, kv := range v.KvlistValue.Values {
+			m[kv.Key] = kv.Value.jsonValue()
+		}
+		return m
+	case v.ArrayValue != nil:
+		vals := make([]any, len(v.ArrayValue.Values))
+		for i, val := range v.ArrayValue.Values {
+			vals[i] = val.jsonValue()
+		}
+		return vals
+	}
+	return v.value()
+}
+
+func otlpValue(v Value) otlpAnyValue {
+	var av otlpAnyValue
+	switch val := v.Value.(type) {
+	case string:
+		av.StringValue = &val
+	case bool:
+		av.BoolValue = &val
+	case int64:
+		n := otlpInt(val)
+		av.IntValue = &n
+	case float64:
+		if v.Type == "INT64" {
+			n := otlpInt(val)
+			av.IntValue = &n
+		} else {
+			av.DoubleValue = &val
+		}
+	case []any:
+		av.ArrayValue = &struct {
+			Values []otlpAnyValue `json:"values"`
+		}{}
+		for _, elem := range val {
+			av.ArrayValue.Values = append(av.ArrayValue.Values, otlpValue(valueOf(elem)))
+		}
+	default:
+		s := v.String()
+		av.StringValue = &s
+	}
+	return av
+}
+
+// otlpInt accepts both the string and number encodings of an int64, and writes strings.
+type otlpInt int64
+
+func (i *otlpInt) UnmarshalJSON(b []byte) error {
+	n, err := strconv.ParseInt(strings.Trim(string(b), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("parsing int: %w", err)
+	}
+	*i = otlpInt(n)
+	return nil
+}
+
+func (i otlpInt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatInt(int64(i), 10))
+}
+
+// unixNano accepts both the string and number encodings of a uint64 timestamp.
+type unixNano time.Time
+
+func (u *unixNano) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		return nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("parsing unix nanos: %w", err)
+	}
+	*u = unixNano(time.Unix(0, n).UTC())
+	return nil
+}
+
+func (u unixNano) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatInt(time.Time(u).UnixNano(), 10))
+}
+
+// otlpID accepts hex IDs, as the spec requires, and base64 IDs, as protojson writes them.
+func otlpID(id string) string {
+	if len(id) != 12 && len(id) != 24 {
+		return id
+	}
+	if _, err := hex.DecodeString(id); err == nil {
+		return id
+	}
+	b, err := base64.StdEncoding.DecodeString(id)
+	if err != nil {
+		return id
+	}
+	return hex.EncodeToString(b)
+}
+
+func otlpAttributes(kvs []otlpKeyValue) []KeyValue {
+	return appendOTLPAttributes(make([]KeyValue, 0, len(kvs)), "", kvs)
+}
+
+// appendOTLPAttributes flattens kvlistValues into an attribute per entry, so
+// {"k8s": {"pod": "x"}} becomes k8s.pod=x, since attributes can't be maps.
+func appendOTLPAttributes(attrs []KeyValue, prefix string, kvs []otlpKeyValue) []KeyValue {
+	for _, kv := range kvs {
+		if kv.Value.KvlistValue != nil {
+			attrs = appendOTLPAttributes(attrs, prefix+kv.Key+".", kv.Value.KvlistValue.Values)
+			continue
+		}
+		attrs = append(attrs, keyValue(prefix+kv.Key, kv.Value.value()))
+	}
+	return attrs
+}
+
+func otlpKeyValues(kvs []KeyValue) []otlpKeyValue {
+	out := make([]otlpKeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		out = append(out, otlpKeyValue{Key: kv.Key, Value: otlpValue(kv.Value)})
+	}
+	return out
+}
+
+// Span flags saying whether the parent span is in another process.
+const (
+	otlpHasIsRemote = 0x100
+	otlpIsRemote    = 0x200
+)
+
+var otlpStatus = map[int]string{
+	0: "Unset",
+	1: "Ok",
+	2: "Error",
+}
+
+func (otlp) Decode(r io.Reader, t *Trace) error {
+	dec := json.NewDecoder(r)
+	for i := 1; ; i++ {
+		var req otlpRequest
+		start := dec.InputOffset()
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("request %d: %w", i, valueOffset(start, err))
+		}
+
+		for _, rs := range req.ResourceSpans {
+			resource := otlpAttributes(rs.Resource.Attributes)
+
+			for _, ss := range append(rs.ScopeSpans, rs.InstrumentationLibrarySpans...) {
+				scope := ss.Scope
+				if scope.Name == "" && ss.InstrumentationLibrary != nil {
+					scope = *ss.InstrumentationLibrary
+				}
+
+				for _, s := range ss.Spans {
+					tid := otlpID(s.TraceID)
+					span := &Span{
+						Name:              s.Name,
+						SpanKind:          s.Kind,
+						StartTime:         time.Time(s.StartTimeUnixNano),
+						EndTime:           time.Time(s.EndTimeUnixNano),
+						Attributes:        rawJSON(otlpAttributes(s.Attributes)),
+						DroppedAttributes: s.DroppedAttributes,
+						DroppedEvents:     s.DroppedEvents,
+						DroppedLinks:      s.DroppedLinks,
+						Resource:          resource,
+					}
+					span.SpanContext = SpanContext{
+						TraceID:    tid,
+						SpanID:     otlpID(s.SpanID),
+						TraceFlags: fmt.Sprintf("%02x", s.Flags&0xff),
+						TraceState: s.TraceState,
+					}
+					span.Parent.TraceID = tid
+					span.Parent.SpanID = otlpID(s.ParentSpanID)
+					if span.Parent.SpanID == "" {
+						span.Parent.SpanID = RootID
+					}
+					span.Parent.Remote = s.Flags&otlpHasIsRemote != 0 && s.Flags&otlpIsRemote != 0
+					span.Status.Code = otlpStatus[s.Status.Code]
+					span.Status.Description = s.Status.Message
+					span.InstrumentationLibrary.Name = scope.Name
+					span.InstrumentationLibrary.Version = scope.Version
+					span.InstrumentationLibrary.SchemaURL = ss.SchemaURL
+
+					if len(s.Events) != 0 {
+						events := make([]Event, len(s.Events))
+						for i, e := range s.Events {
+							events[i] = Event{
+								Name:       e.Name,
+								Time:       time.Time(e.TimeUnixNano),
+								Attributes: otlpAttributes(e.Attributes),
+							}
+						}
+						span.Events = rawJSON(events)
+					}
+					if len(s.Links) != 0 {
+						links := make([]link, len(s.Links))
+						for i, l := range s.Links {
+							links[i] = link{
+								SpanContext: SpanContext{
+									TraceID:    otlpID(l.TraceID),
+									SpanID:     otlpID(l.SpanID),
+									TraceState: l.TraceState,
+								},
+								Attributes: otlpAttributes(l.Attributes),
+							}
+						}
+						span.Links = rawJSON(links)
+					}
+
+					t.Add(span)
+				}
+			}
+		}
+	}
+}
+
+// EncodeOTLP writes t as a single OTLP/JSON ExportTraceServiceRequest.
+func EncodeOTLP(w io.Writer, t *Trace) error {
+	req := otlpRequest{}
+
+	resources := map[string]int{}
+	scopes := map[[2]string]int{}
+
+	for _, span := range t.Sorted() {
+		rkey, err := json.Marshal(span.Resource)
+		if err != nil {
+			return err
+		}
+		ri, ok := resources[string(rkey)]
+		if !ok {
+			ri = len(req.ResourceSpans)
+			resources[string(rkey)] = ri
+			rs := otlpResourceSpans{}
+			rs.Resource.Attributes = otlpKeyValues(span.Resource)
+			req.ResourceSpans = append(req.ResourceSpans, rs)
+		}
+		rs := &req.ResourceSpans[ri]
+
+		lib := span.InstrumentationLibrary
+		skey := [2]string{string(rkey), lib.Name + "@" + lib.Version}
+		si, ok := scopes[skey]
+		if !ok {
+			si = len(rs.ScopeSpans)
+			scopes[skey] = si
+			rs.ScopeSpans = append(rs.ScopeSpans, otlpScopeSpans{
+				Scope:     otlpScope{Name: lib.Name, Version: lib.Version},
+				SchemaURL: lib.SchemaURL,
+			})
+		}
+		ss := &rs.ScopeSpans[si]
+
+		s := otlpSpan{
+			TraceID:           span.SpanContext.TraceID,
+			SpanID:            span.SpanContext.SpanID,
+			TraceState:        span.SpanContext.TraceState,
+			Name:              span.Name,
+			Kind:              span.SpanKind,
+			StartTimeUnixNano: unixNano(span.StartTime),
+			EndTimeUnixNano:   unixNano(span.EndTime),
+			Attributes:        otlpKeyValues(decodeAs[KeyValue](span.Attributes)),
+			DroppedAttributes: span.DroppedAttributes,
+			DroppedEvents:     span.DroppedEvents,
+			DroppedLinks:      span.DroppedLinks,
+		}
+		if flags, err := strconv.ParseInt(span.SpanContext.TraceFlags, 16, 64); err == nil {
+			s.Flags = int(flags)
+		}
+		if span.Parent.SpanID != RootID {
+			s.ParentSpanID = span.Parent.SpanID
+			if span.Parent.Remote {
+				s.Flags |= otlpHasIsRemote | otlpIsRemote
+			}
+		}
+		for code, name := range otlpStatus {
+			if name == span.Status.Code {
+				s.Status.Code = code
+			}
+		}
+		s.Status.Message = span.Status.Description
+
+		for _, e := range span.DecodeEvents() {
+			s.Events = append(s.Events, otlpEvent{
+				TimeUnixNano: unixNano(e.Time),
+				Name:         e.Name,
+				Attributes:   otlpKeyValues(e.Attributes),
+			})
+		}
+		for _, l := range decodeAs[link](span.Links) {
+			s.Links = append(s.Links, otlpLink{
+				TraceID:    l.SpanContext.TraceID,
+				SpanID:     l.SpanContext.SpanID,
+				TraceState: l.SpanContext.TraceState,
+				Attributes: otlpKeyValues(l.Attributes),
+			})
+		}
+
+		ss.Spans = append(ss.Spans, s)
+	}
+
+	return json.NewEncoder(w).Encode(req)
+}
@@ -0,0 +1,188 @@
+package trot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseError says where in the input a Decoder failed.
+type ParseError struct {
+	// Format is the decoder that was tried, and Sniffed whether it was auto-detected.
+	Format  string
+	Sniffed bool
+
+	// Offset is the byte offset of the failure; Line and Column are 1-based.
+	// Line and Column are 0 if the offset was too far back to locate.
+	Offset int64
+	Line   int
+	Column int
+
+	// Snippet is the input line around Offset.
+	Snippet string
+	caret   int
+
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "decoding %s", e.Format)
+	if e.Sniffed {
+		sb.WriteString(" (sniffed, use -format to override)")
+	}
+	if e.Line != 0 {
+		fmt.Fprintf(&sb, ": line %d, column %d (byte %d)", e.Line, e.Column, e.Offset)
+	} else {
+		fmt.Fprintf(&sb, ": byte %d", e.Offset)
+	}
+	fmt.Fprintf(&sb, ": %v", e.Err)
+	if e.Snippet != "" {
+		fmt.Fprintf(&sb, "\n\t%s\n\t%s^", e.Snippet, strings.Repeat(" ", e.caret))
+	}
+	return sb.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// offsetError lets decoders that re-parse part of the stream report a stream offset.
+type offsetError struct {
+	offset int64
+	err    error
+}
+
+func (e *offsetError) Error() string {
+	return e.err.Error()
+}
+
+func (e *offsetError) Unwrap() error {
+	return e.err
+}
+
+// atOffset rebases any JSON error offset in err onto base.
+func atOffset(base int64, err error) error {
+	if off, ok := jsonOffset(err); ok {
+		return &offsetError{offset: base + off, err: err}
+	}
+	return err
+}
+
+// valueOffset fixes up err from json.Decoder.Decode of a value starting at base:
+// syntax errors carry stream offsets but type errors are relative to the value.
+func valueOffset(base int64, err error) error {
+	var te *json.UnmarshalTypeError
+	if errors.As(err, &te) {
+		return &offsetError{offset: base + te.Offset, err: err}
+	}
+	return err
+}
+
+func jsonOffset(err error) (int64, bool) {
+	var oe *offsetError
+	if errors.As(err, &oe) {
+		return oe.offset, true
+	}
+	var se *json.SyntaxError
+	if errors.As(err, &se) {
+		return se.Offset, true
+	}
+	var te *json.UnmarshalTypeError
+	if errors.As(err, &te) {
+		return te.Offset, true
+	}
+	return 0, false
+}
+
+const (
+	trackWindow = 64 << 10
+	snippetLen  = 80
+)
+
+// tracker remembers the tail of what it has read so errors can show context.
+type tracker struct {
+	r     io.Reader
+	buf   []byte
+	start int64 // offset of buf[0]
+	lines int   // newlines before buf[0]
+	read  int64
+}
+
+func (t *tracker) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	t.read += int64(n)
+	t.buf = append(t.buf, p[:n]...)
+	if len(t.buf) > 2*trackWindow {
+		drop := len(t.buf) - trackWindow
+		t.lines += bytes.Count(t.buf[:drop], []byte{'\n'})
+		t.start += int64(drop)
+		t.buf = append(t.buf[:0], t.buf[drop:]...)
+	}
+	return n, err
+}
+
+func (t *tracker) parseError(format string, sniffed bool, err error) *ParseError {
+	pe := &ParseError{
+		Format:  format,
+		Sniffed: sniffed,
+		Err:     err,
+	}
+
+	off, ok := jsonOffset(err)
+	if !ok {
+		// Truncated input and the like fail wherever we stopped reading.
+		off = t.read
+	} else if off > 0 {
+		// encoding/json reports the offset after the byte it choked on.
+		off--
+	}
+	pe.Offset = off
+
+	rel := off - t.start
+	if rel < 0 || rel > int64(len(t.buf)) {
+		return pe
+	}
+
+	before := t.buf[:rel]
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	pe.Line = t.lines + bytes.Count(before, []byte{'\n'}) + 1
+	pe.Column = len(before) - lineStart + 1
+
+	lineEnd := bytes.IndexByte(t.buf[rel:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(t.buf)
+	} else {
+		lineEnd += int(rel)
+	}
+	line := t.buf[lineStart:lineEnd]
+
+	// Center the snippet on the column.
+	col := pe.Column - 1
+	from := col - snippetLen/2
+	if from < 0 {
+		from = 0
+	}
+	to := from + snippetLen
+	if to > len(line) {
+		to = len(line)
+	}
+	if from > to {
+		from = to
+	}
+	pe.Snippet = strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, string(line[from:to]))
+	pe.caret = col - from
+	if pe.caret > len(pe.Snippet) {
+		pe.caret = len(pe.Snippet)
+	}
+
+	return pe
+}
@@ -0,0 +1,22 @@
+package trot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	_, err := Parse(strings.NewReader("{\"Name\": \"a\"}\n{\"Name\": x}\n"))
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Parse() = %v, want a ParseError", err)
+	}
+	if pe.Format != "stdouttrace" || !pe.Sniffed || pe.Line != 2 || pe.Column != 10 || pe.Offset != 23 {
+		t.Errorf("ParseError = %+v, want stdouttrace, sniffed, line 2, column 10, byte 23", pe)
+	}
+	_, snippet, _ := strings.Cut(err.Error(), "\n")
+	if want := "\t{\"Name\": x}\n\t         ^"; snippet != want {
+		t.Errorf("snippet =\n%s\nwant\n%s", snippet, want)
+	}
+}
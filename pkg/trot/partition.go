@@ -0,0 +1,167 @@
+package trot
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"math"
+	"time"
+)
+
+// cell is a node's share of a partition of the tree: the fraction [x0, x1)
+// of the whole it covers, at depth.
+type cell struct {
+	node   *Node
+	depth  int
+	x0, x1 float64
+}
+
+// minCell is the smallest share of the whole that partition keeps, a pixel
+// of RenderIcicle's width.
+const minCell = 1.0 / icicleWidth
+
+// partition divides [0, 1) among root's descendants, each child getting a
+// share of its parent's in proportion to its duration, as in a flame graph.
+// Concurrent children can add up to more than their parent, in which case
+// they fill it.
+func partition(root *Node) []cell {
+	cells := []cell{}
+	var visit func(n *Node, depth int, x0, x1 float64)
+	visit = func(n *Node, depth int, x0, x1 float64) {
+		if x1-x0 < minCell {
+			return
+		}
+		cells = append(cells, cell{n, depth, x0, x1})
+
+		total := n.Duration()
+		var sum time.Duration
+		for _, kid := range n.Children {
+			sum += kid.Duration()
+		}
+		total = max(total, sum)
+		if total <= 0 {
+			return
+		}
+		x := x0
+		for _, kid := range n.Children {
+			w := (x1 - x0) * float64(kid.Duration()) / float64(total)
+			visit(kid, depth+1, x, x+w)
+			x += w
+		}
+	}
+	visit(root, 0, 0, 1)
+	return cells
+}
+
+// fill colors a span by its service, or red if it failed.
+func fill(span *Span) string {
+	if span.IsError() {
+		return "#e66"
+	}
+	h := fnv.New32a()
+	h.Write([]byte(span.Service()))
+	return fmt.Sprintf("hsl(%d, 60%%, 75%%)", h.Sum32()%360)
+}
+
+// cellLabel is what a cell's tooltip says.
+func cellLabel(c cell, namer *namer, opts Options) string {
+	name := c.node.Span.Name
+	if namer != nil && c.node.Span.SpanContext.TraceID != "" {
+		name = namer.name(c.node.Span)
+	}
+	return fmt.Sprintf("%s %s (%.1f%%)", name, opts.duration(c.node.Duration()), 100*(c.x1-c.x0))
+}
+
+const (
+	icicleWidth = 1200
+	icicleRow   = 20
+)
+
+// RenderIcicle writes t as an icicle chart: the root across the top, and
+// each span below its parent, as wide as its share of the parent's time.
+func RenderIcicle(w io.Writer, t *Trace, opts Options) error {
+	return renderPartition(w, t, opts, func(cells []cell, depth int, namer *namer) {
+		fmt.Fprintf(w, `<svg class="icicle" viewBox="0 0 %d %d" width="100%%">`, icicleWidth, (depth+1)*icicleRow)
+		for _, c := range cells {
+			x, y, width := c.x0*icicleWidth, c.depth*icicleRow, (c.x1-c.x0)*icicleWidth
+			label := html.EscapeString(cellLabel(c, namer, opts))
+			fmt.Fprintf(w, `<g><title>%s</title><rect x="%.2f" y="%d" width="%.2f" height="%d" fill="%s" stroke="#fff"/>`, label, x, y, width, icicleRow, fill(c.node.Span))
+			// About 7 units per character.
+			if chars := int(width / 7); chars > 3 {
+				text := []rune(c.node.Span.Name)
+				if len(text) > chars {
+					text = append(text[:chars-1], '…')
+				}
+				fmt.Fprintf(w, `<text x="%.2f" y="%d" font-size="12" dominant-baseline="middle">%s</text>`, x+3, y+icicleRow/2, html.EscapeString(string(text)))
+			}
+			fmt.Fprint(w, "</g>\n")
+		}
+		fmt.Fprint(w, `</svg>`)
+	})
+}
+
+// sunburstRing is how thick each depth's ring is.
+const sunburstRing = 40
+
+// RenderSunburst writes t as a sunburst: an icicle chart wrapped around the
+// root, so each ring is one level deeper.
+func RenderSunburst(w io.Writer, t *Trace, opts Options) error {
+	return renderPartition(w, t, opts, func(cells []cell, depth int, namer *namer) {
+		r := float64((depth + 1) * sunburstRing)
+		fmt.Fprintf(w, `<svg class="sunburst" viewBox="%.0f %.0f %.0f %.0f" width="100%%" height="90vh">`, -r, -r, 2*r, 2*r)
+		for _, c := range cells {
+			label := html.EscapeString(cellLabel(c, namer, opts))
+			fmt.Fprintf(w, `<g><title>%s</title><path d="%s" fill="%s" stroke="#fff"/></g>`+"\n", label, arc(c), fill(c.node.Span))
+		}
+		fmt.Fprint(w, `</svg>`)
+	})
+}
+
+// arc is the SVG path for c's slice of its ring.
+func arc(c cell) string {
+	r0, r1 := float64(c.depth*sunburstRing), float64((c.depth+1)*sunburstRing)
+	if c.depth == 0 {
+		// The root is the middle, a full circle.
+		return fmt.Sprintf("M%.2f,0A%.2f,%.2f 0 1,1 %.2f,0A%.2f,%.2f 0 1,1 %.2f,0Z", r1, r1, r1, -r1, r1, r1, r1)
+	}
+	// A whole ring's start and end would coincide, and not be drawn.
+	a0, a1 := 2*math.Pi*c.x0, 2*math.Pi*min(c.x1, c.x0+0.99999)
+	large := 0
+	if a1-a0 > math.Pi {
+		large = 1
+	}
+	point := func(r, a float64) (float64, float64) {
+		return r * math.Sin(a), -r * math.Cos(a)
+	}
+	x0, y0 := point(r1, a0)
+	x1, y1 := point(r1, a1)
+	x2, y2 := point(r0, a1)
+	x3, y3 := point(r0, a0)
+	return fmt.Sprintf("M%.2f,%.2fA%.0f,%.0f 0 %d,1 %.2f,%.2fL%.2f,%.2fA%.0f,%.0f 0 %d,0 %.2f,%.2fZ",
+		x0, y0, r1, r1, large, x1, y1, x2, y2, r0, r0, large, x3, y3)
+}
+
+func renderPartition(w io.Writer, t *Trace, opts Options, draw func(cells []cell, depth int, namer *namer)) error {
+	name := rootName(t)
+	if opts.Title == "" {
+		opts.Title = name
+	}
+	var namer *namer
+	if opts.Name != nil {
+		namer = newNamer(opts.Name)
+	}
+
+	cells := partition(t.Tree(name, RootID))
+	depth := 0
+	for _, c := range cells {
+		depth = max(depth, c.depth)
+	}
+
+	writeHeader(w, opts)
+	writeWarnings(w, t.Warnings())
+	writeErrors(w, t.Errors())
+	draw(cells, depth, namer)
+	writeFooter(w)
+	return nil
+}
@@ -0,0 +1,294 @@
+package trot
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// pprof is a pprof profile (profile.proto, gzipped or not), drawn as its call
+// tree: a span per function per stack, as long as its cumulative value, with
+// flat and cumulative values in its attributes. Values in nanoseconds (like
+// CPU time) are durations as is; anything else is drawn a nanosecond per unit.
+type pprof struct{}
+
+func (pprof) Name() string {
+	return "pprof"
+}
+
+func (pprof) Sniff(peek []byte) bool {
+	if len(peek) > 2 && peek[0] == 0x1f && peek[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(peek))
+		if err != nil {
+			return false
+		}
+		buf := make([]byte, 256)
+		n, _ := io.ReadFull(zr, buf)
+		peek = buf[:n]
+	}
+	// Every field up to where peek cuts off has to be one of profile.proto's
+	// with the right wire type, and the sample types are near the start.
+	sampleTypes := false
+	for len(peek) != 0 {
+		num, typ, n := protowire.ConsumeTag(peek)
+		if n < 0 || num < 1 || num > 14 {
+			return false
+		}
+		switch num {
+		case 1, 2, 3, 4, 5, 6, 11:
+			if typ != protowire.BytesType {
+				return false
+			}
+		case 13:
+		default:
+			if typ != protowire.VarintType {
+				return false
+			}
+		}
+		sampleTypes = sampleTypes || num == 1
+		m := protowire.ConsumeFieldValue(num, typ, peek[n:])
+		if m < 0 {
+			break
+		}
+		peek = peek[n+m:]
+	}
+	return sampleTypes
+}
+
+type pprofProfile struct {
+	sampleTypes [][2]int64 // type, unit
+	samples     []pprofSample
+	locations   map[uint64][]uint64 // function IDs, innermost first
+	functions   map[uint64]int64    // name
+	strings     []string
+	defaultType int64
+}
+
+type pprofSample struct {
+	locations []uint64
+	values    []int64
+}
+
+func (pprof) Decode(r io.Reader, t *Trace) error {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		r = zr
+	} else {
+		r = br
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	p := &pprofProfile{locations: map[uint64][]uint64{}, functions: map[uint64]int64{}}
+	if err := p.unmarshal(b); err != nil {
+		return fmt.Errorf("profile: %w", err)
+	}
+	if len(p.sampleTypes) == 0 {
+		return fmt.Errorf("profile has no sample types")
+	}
+
+	// Like pprof, show the default sample type, or else the last.
+	which := len(p.sampleTypes) - 1
+	for i, st := range p.sampleTypes {
+		if p.defaultType != 0 && st[0] == p.defaultType {
+			which = i
+		}
+	}
+	typ, unit := p.str(p.sampleTypes[which][0]), p.str(p.sampleTypes[which][1])
+
+	root := &frame{name: typ, children: map[string]*frame{}}
+	for _, s := range p.samples {
+		if which >= len(s.values) {
+			continue
+		}
+		v := s.values[which]
+		f := root
+		f.samples += v
+		for i := len(s.locations) - 1; i >= 0; i-- {
+			fns := p.locations[s.locations[i]]
+			for j := len(fns) - 1; j >= 0; j-- {
+				f = f.child(p.str(p.functions[fns[j]]))
+				f.samples += v
+			}
+		}
+		f.self += v
+	}
+	if root.samples == 0 {
+		return nil
+	}
+
+	h := fnv.New128a()
+	h.Write(b)
+	root.spans(t, fmt.Sprintf("%x", h.Sum(nil)), "pprof", pprofWeight(unit), func(f *frame) []KeyValue {
+		return []KeyValue{keyValue("pprof.flat", f.self), keyValue("pprof.cum", f.samples), keyValue("pprof.unit", unit)}
+	})
+	return nil
+}
+
+func (p *pprofProfile) str(i int64) string {
+	if i < 0 || i >= int64(len(p.strings)) {
+		return ""
+	}
+	return p.strings[i]
+}
+
+// fields calls fn with each field in b, and the varint or bytes it holds.
+func fields(b []byte, fn func(num protowire.Number, v uint64, bs []byte) error) error {
+	for len(b) != 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var v uint64
+		var bs []byte
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			bs, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := fn(num, v, bs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// varints appends a repeated varint field, packed (bs) or not (v).
+func varints(dst []uint64, v uint64, bs []byte) ([]uint64, error) {
+	if bs == nil {
+		return append(dst, v), nil
+	}
+	for len(bs) != 0 {
+		v, n := protowire.ConsumeVarint(bs)
+		if n < 0 {
+			return dst, protowire.ParseError(n)
+		}
+		dst = append(dst, v)
+		bs = bs[n:]
+	}
+	return dst, nil
+}
+
+// unmarshal reads the parts of profile.proto needed for a call tree.
+func (p *pprofProfile) unmarshal(b []byte) error {
+	return fields(b, func(num protowire.Number, v uint64, bs []byte) error {
+		switch num {
+		case 1: // sample_type
+			var st [2]int64
+			err := fields(bs, func(num protowire.Number, v uint64, _ []byte) error {
+				if num == 1 || num == 2 {
+					st[num-1] = int64(v)
+				}
+				return nil
+			})
+			p.sampleTypes = append(p.sampleTypes, st)
+			return err
+		case 2: // sample
+			var s pprofSample
+			err := fields(bs, func(num protowire.Number, v uint64, bs []byte) error {
+				var err error
+				switch num {
+				case 1:
+					s.locations, err = varints(s.locations, v, bs)
+				case 2:
+					var vs []uint64
+					vs, err = varints(nil, v, bs)
+					for _, v := range vs {
+						s.values = append(s.values, int64(v))
+					}
+				}
+				return err
+			})
+			p.samples = append(p.samples, s)
+			return err
+		case 4: // location
+			var id uint64
+			var fns []uint64
+			err := fields(bs, func(num protowire.Number, v uint64, bs []byte) error {
+				switch num {
+				case 1:
+					id = v
+				case 4: // line
+					return fields(bs, func(num protowire.Number, v uint64, _ []byte) error {
+						if num == 1 {
+							fns = append(fns, v)
+						}
+						return nil
+					})
+				}
+				return nil
+			})
+			p.locations[id] = fns
+			return err
+		case 5: // function
+			var id uint64
+			var name int64
+			err := fields(bs, func(num protowire.Number, v uint64, _ []byte) error {
+				switch num {
+				case 1:
+					id = v
+				case 2:
+					name = int64(v)
+				}
+				return nil
+			})
+			p.functions[id] = name
+			return err
+		case 6: // string_table
+			p.strings = append(p.strings, string(bs))
+		case 14: // default_sample_type
+			p.defaultType = int64(v)
+		}
+		return nil
+	})
+}
+
+// pprofWeight is how long to draw one unit of a sample value.
+func pprofWeight(unit string) time.Duration {
+	switch unit {
+	case "microseconds":
+		return time.Microsecond
+	case "milliseconds":
+		return time.Millisecond
+	}
+	return time.Nanosecond
+}
+
+// pprofSummary shows flat values, and cumulative ones when they aren't
+// already the span's duration.
+func pprofSummary(attrs []KeyValue) string {
+	unit := first(attrs, "pprof.unit")
+	flat, err := strconv.ParseInt(first(attrs, "pprof.flat"), 10, 64)
+	if err != nil {
+		return ""
+	}
+	cum, _ := strconv.ParseInt(first(attrs, "pprof.cum"), 10, 64)
+	switch unit {
+	case "nanoseconds", "microseconds", "milliseconds":
+		return "flat " + FormatDuration(time.Duration(flat)*pprofWeight(unit), 3)
+	case "bytes":
+		return "flat " + formatBytes(flat) + ", cum " + formatBytes(cum)
+	}
+	return fmt.Sprintf("flat %d, cum %d %s", flat, cum, unit)
+}
@@ -0,0 +1,79 @@
+package trot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// testProfile encodes a CPU profile where main calls work (with parse
+// inlined into it) for 30ms and idle for 10ms.
+func testProfile() []byte {
+	msg := func(fields ...[]byte) []byte {
+		return bytes.Join(fields, nil)
+	}
+	field := func(num protowire.Number, v uint64) []byte {
+		return protowire.AppendVarint(protowire.AppendTag(nil, num, protowire.VarintType), v)
+	}
+	nested := func(num protowire.Number, b []byte) []byte {
+		return protowire.AppendBytes(protowire.AppendTag(nil, num, protowire.BytesType), b)
+	}
+	packed := func(num protowire.Number, vs ...uint64) []byte {
+		var b []byte
+		for _, v := range vs {
+			b = protowire.AppendVarint(b, v)
+		}
+		return nested(num, b)
+	}
+
+	strs := []string{"", "samples", "count", "cpu", "nanoseconds", "main", "work", "idle", "parse"}
+	var b []byte
+	b = append(b, nested(1, msg(field(1, 1), field(2, 2)))...)
+	b = append(b, nested(1, msg(field(1, 3), field(2, 4)))...)
+	b = append(b, nested(2, msg(packed(1, 2, 1), packed(2, 3, uint64(30*time.Millisecond))))...)
+	b = append(b, nested(2, msg(packed(1, 3, 1), packed(2, 1, uint64(10*time.Millisecond))))...)
+	b = append(b, nested(4, msg(field(1, 1), nested(4, field(1, 1))))...)
+	// parse is inlined into work.
+	b = append(b, nested(4, msg(field(1, 2), nested(4, field(1, 4)), nested(4, field(1, 2))))...)
+	b = append(b, nested(4, msg(field(1, 3), nested(4, field(1, 3))))...)
+	for id, name := range []uint64{5, 6, 7, 8} {
+		b = append(b, nested(5, msg(field(1, uint64(id+1)), field(2, name)))...)
+	}
+	for _, s := range strs {
+		b = append(b, nested(6, []byte(s))...)
+	}
+	return b
+}
+
+func TestPprof(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(testProfile())
+	zw.Close()
+
+	tr, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := tr.Tree("root", RootID).Children
+	if len(root) != 1 || root[0].Span.Name != "cpu" {
+		t.Fatalf("roots = %s, want cpu", names(root))
+	}
+	main := root[0].Children[0]
+	if got, want := main.Span.Duration(), 40*time.Millisecond; got != want {
+		t.Errorf("main took %v, want %v", got, want)
+	}
+	if got, want := names(main.Children), "idle,work"; got != want {
+		t.Fatalf("callees = %s, want %s", got, want)
+	}
+	work := main.Children[1]
+	if got, want := names(work.Children), "parse"; got != want {
+		t.Fatalf("inlined = %s, want %s", got, want)
+	}
+	if got, want := work.Children[0].Span.Summary(), "flat 30ms"; got != want {
+		t.Errorf("parse summary = %q, want %q", got, want)
+	}
+}
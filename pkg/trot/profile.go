@@ -0,0 +1,50 @@
+package trot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// profiles are name templates for tools whose spans keep what they're about
+// (image refs, package names, digests) in attributes.
+var profiles = map[string]string{
+	"ko":       `{{.Name}}{{with attr "ko.importpath"}} {{.}}{{end}}{{with attr "ko.platform"}} {{.}}{{end}}{{with attr "image.ref"}} {{.}}{{end}}{{with attr "image.digest"}} {{short .}}{{end}}`,
+	"crane":    `{{.Name}}{{with attr "image.ref"}} {{.}}{{end}}{{with attr "crane.ref"}} {{.}}{{end}}{{with attr "image.digest"}} {{short .}}{{end}}`,
+	"apko":     `{{.Name}}{{with attr "apko.arch"}} {{.}}{{end}}{{with attr "package.name"}} {{.}}{{end}}{{with attr "package.version"}} {{.}}{{end}}{{with attr "image.ref"}} {{.}}{{end}}{{with attr "image.digest"}} {{short .}}{{end}}`,
+	"melange":  `{{.Name}}{{with attr "package.name"}} {{.}}{{end}}{{with attr "package.version"}} {{.}}{{end}}{{with attr "melange.arch"}} {{.}}{{end}}{{with attr "melange.pipeline"}} {{.}}{{end}}`,
+	"buildkit": `{{.Name}}{{with attr "vertex"}} {{short .}}{{end}}{{with attr "buildkit.vertex"}} {{short .}}{{end}}`,
+}
+
+// ProfileNames lists the tools Profile knows about.
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile returns the name template for spans from tool, one of
+// ProfileNames.
+func Profile(tool string) (*template.Template, error) {
+	s, ok := profiles[tool]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q (want one of %s)", tool, strings.Join(ProfileNames(), ", "))
+	}
+	return ParseNameTemplate(s)
+}
+
+// shortDigest abbreviates a digest like sha256:0123... to its first 12 hex
+// digits, as docker does. Anything else comes back as is.
+func shortDigest(s string) string {
+	if _, hex, ok := strings.Cut(s, ":"); ok && len(hex) > 12 && !strings.ContainsAny(hex, "/:@") {
+		return hex[:12]
+	}
+	if i := strings.LastIndex(s, "@sha256:"); i >= 0 && len(s) > i+8+12 {
+		return s[:i+8+12]
+	}
+	return s
+}
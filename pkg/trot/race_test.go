@@ -0,0 +1,6 @@
+//go:build race
+
+package trot
+
+// raceEnabled is set when the race detector, which allocates, is on.
+const raceEnabled = true
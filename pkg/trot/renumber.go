@@ -0,0 +1,67 @@
+package trot
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Renumber returns t with sequential TraceIDs and SpanIDs in place of its
+// own, numbered in the order the spans are drawn, so that rendering two runs
+// of the same instrumentation, with their random IDs, gives the same page.
+func (t *Trace) Renumber() *Trace {
+	traces, spans := map[string]string{}, map[string]string{}
+	number := func(ids map[string]string, id, format string) string {
+		if isZeroID(id) {
+			return id
+		}
+		n, ok := ids[id]
+		if !ok {
+			n = fmt.Sprintf(format, len(ids)+1)
+			ids[id] = n
+		}
+		return n
+	}
+
+	order := []*Span{}
+	roots := t.Roots()
+	sort.SliceStable(roots, func(i, j int) bool {
+		a, b := roots[i].Span, roots[j].Span
+		if !a.StartTime.Equal(b.StartTime) {
+			return a.StartTime.Before(b.StartTime)
+		}
+		return a.Name < b.Name
+	})
+	for _, root := range roots {
+		root.Walk(func(n *Node, _ int) bool {
+			order = append(order, n.Span)
+			return true
+		})
+	}
+	// Spans in a cycle have no root to be reached from.
+	order = append(order, t.Sorted()...)
+
+	out := t.empty()
+	done := map[*Span]bool{}
+	for _, span := range order {
+		if done[span] {
+			continue
+		}
+		done[span] = true
+
+		c := *span
+		c.SpanContext.TraceID = number(traces, span.SpanContext.TraceID, "%032x")
+		c.Parent.TraceID = number(traces, span.Parent.TraceID, "%032x")
+		// A missing parent is numbered before its child, as it's drawn.
+		c.Parent.SpanID = number(spans, span.Parent.SpanID, "%016x")
+		c.SpanContext.SpanID = number(spans, span.SpanContext.SpanID, "%016x")
+		if links := decodeAs[link](span.Links); len(links) != 0 {
+			for i, l := range links {
+				links[i].SpanContext.TraceID = number(traces, l.SpanContext.TraceID, "%032x")
+				links[i].SpanContext.SpanID = number(spans, l.SpanContext.SpanID, "%016x")
+			}
+			c.Links = rawJSON(links)
+		}
+		out.Add(&c)
+	}
+	return out
+}
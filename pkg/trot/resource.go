@@ -0,0 +1,73 @@
+package trot
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+)
+
+// Resource is something that produced spans, like a process or a pod.
+type Resource struct {
+	Attributes []KeyValue
+
+	// Spans is how many spans in the trace came from it.
+	Spans int
+}
+
+// Service returns the service.name attribute, or "unknown".
+func (r Resource) Service() string {
+	if v, ok := lookup(r.Attributes, "service.name"); ok {
+		return v
+	}
+	return "unknown"
+}
+
+// Resources returns every distinct resource in t, ordered by service name.
+func (t *Trace) Resources() []Resource {
+	index := map[string]int{}
+	// Resources are usually interned, so most spans can skip building a key.
+	shared := map[*KeyValue]int{}
+
+	rs := []Resource{}
+	for _, span := range t.Spans {
+		if len(span.Resource) == 0 {
+			continue
+		}
+		i, ok := shared[&span.Resource[0]]
+		if !ok {
+			key := resourceKey(span.Resource)
+			if i, ok = index[key]; !ok {
+				i = len(rs)
+				index[key] = i
+				rs = append(rs, Resource{Attributes: span.Resource})
+			}
+			shared[&span.Resource[0]] = i
+		}
+		rs[i].Spans++
+	}
+
+	sort.Slice(rs, func(i, j int) bool {
+		if a, b := rs[i].Service(), rs[j].Service(); a != b {
+			return a < b
+		}
+		return resourceKey(rs[i].Attributes) < resourceKey(rs[j].Attributes)
+	})
+	return rs
+}
+
+func writeResources(w io.Writer, rs []Resource) {
+	if len(rs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, `<details class="resources"><summary>%d resources</summary>`, len(rs))
+	for _, r := range rs {
+		fmt.Fprintf(w, `<table><caption>%s (%d spans)</caption>`, html.EscapeString(r.Service()), r.Spans)
+		for _, kv := range r.Attributes {
+			fmt.Fprintf(w, `<tr><th>%s</th><td>%s</td></tr>`, html.EscapeString(kv.Key), html.EscapeString(kv.Value.String()))
+		}
+		fmt.Fprint(w, `</table>`)
+	}
+	fmt.Fprintln(w, `</details>`)
+}
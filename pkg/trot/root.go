@@ -0,0 +1,148 @@
+package trot
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// Adopt adds root to t as the parent of every span whose parent isn't in t,
+// so traces from instrumentation that only emits spans from the middle of
+// the tree still have a meaningful top. If root has no times, it covers the
+// spans it adopts.
+func (t *Trace) Adopt(root *Span) {
+	id := root.SpanContext.SpanID
+	adopted := []*Span{}
+	for _, parent := range t.Missing() {
+		if parent == id {
+			// Already root's, e.g. from a TRACEPARENT.
+			adopted = append(adopted, t.Children[parent]...)
+			continue
+		}
+		for _, span := range t.Children[parent] {
+			// Spans may be shared with other Traces, so change a copy.
+			c := *span
+			c.Parent.SpanID = id
+			t.Spans[c.SpanContext.SpanID] = &c
+			t.Children[id] = append(t.Children[id], &c)
+			adopted = append(adopted, &c)
+		}
+		delete(t.Children, parent)
+	}
+
+	if root.StartTime.IsZero() && root.EndTime.IsZero() {
+		for _, span := range adopted {
+			if root.StartTime.IsZero() || span.StartTime.Before(root.StartTime) {
+				root.StartTime = span.StartTime
+			}
+			if span.EndTime.After(root.EndTime) {
+				root.EndTime = span.EndTime
+			}
+		}
+	}
+
+	root.Parent.SpanID = RootID
+	t.Add(root)
+}
+
+// SynthesizeRoots returns t with a synthetic root span for each trace in it
+// that has none, adopting that trace's orphans.
+func (t *Trace) SynthesizeRoots() *Trace {
+	out := t.empty()
+	for tid, tt := range t.Split() {
+		if _, ok := tt.Children[RootID]; !ok && len(tt.Spans) != 0 {
+			tt.Adopt(&Span{
+				Name: "synthetic root",
+				SpanContext: SpanContext{
+					TraceID: tid,
+					SpanID:  stableID("trot root " + tid),
+				},
+			})
+		}
+		for _, span := range tt.Spans {
+			out.Add(span)
+		}
+	}
+	return out
+}
+
+// PromoteOrphans returns t with every span whose parent isn't in t made a
+// root, for inputs that leave out the spans above theirs, or that mark roots
+// with a parent that doesn't exist.
+func (t *Trace) PromoteOrphans() *Trace {
+	out := t.empty()
+	for _, span := range t.Spans {
+		if _, ok := t.Spans[span.Parent.SpanID]; !ok && span.Parent.SpanID != RootID {
+			// Spans may be shared with other Traces, so change a copy.
+			c := *span
+			c.Parent.SpanID = RootID
+			span = &c
+		}
+		out.Add(span)
+	}
+	return out
+}
+
+// AddPlaceholders returns t with a span named "unknown parent" in place of
+// each parent that isn't in t, covering its children, so orphans stay
+// together with their siblings.
+func (t *Trace) AddPlaceholders() *Trace {
+	out := t.empty()
+	for _, span := range t.Spans {
+		out.Add(span)
+	}
+	for _, parent := range t.Missing() {
+		if parent == RootID {
+			continue
+		}
+		kids := t.Children[parent]
+		p := &Span{
+			Name: "unknown parent",
+			SpanContext: SpanContext{
+				TraceID: kids[0].SpanContext.TraceID,
+				SpanID:  parent,
+			},
+			StartTime: kids[0].StartTime,
+			EndTime:   kids[0].EndTime,
+		}
+		for _, kid := range kids {
+			if kid.StartTime.Before(p.StartTime) {
+				p.StartTime = kid.StartTime
+			}
+			if kid.EndTime.After(p.EndTime) {
+				p.EndTime = kid.EndTime
+			}
+		}
+		p.Parent.TraceID = p.SpanContext.TraceID
+		p.Parent.SpanID = RootID
+		out.Add(p)
+	}
+	return out
+}
+
+// DropOrphans returns t without the spans that aren't under a root: those
+// whose parent isn't in t, and everything under them.
+func (t *Trace) DropOrphans() *Trace {
+	out := t.empty()
+	seen := map[string]bool{}
+	queue := append([]*Span(nil), t.Children[RootID]...)
+	for len(queue) != 0 {
+		span := queue[0]
+		queue = queue[1:]
+		id := span.SpanContext.SpanID
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		out.Add(span)
+		queue = append(queue, t.Children[id]...)
+	}
+	return out
+}
+
+// stableID is a SpanID derived from key, for spans that trot makes up, so
+// they get the same ID every time.
+func stableID(key string) string {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
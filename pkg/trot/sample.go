@@ -0,0 +1,103 @@
+package trot
+
+import (
+	"hash/fnv"
+	"io"
+	"math"
+	"sort"
+)
+
+// Sample returns a Trace with about rate of t's spans, chosen by hashing
+// their SpanIDs so the same input always samples the same way. Ancestors of
+// sampled spans are kept too, so nothing ends up orphaned.
+func (t *Trace) Sample(rate float64) *Trace {
+	keep := map[string]bool{}
+	for id, span := range t.Spans {
+		if span.Parent.SpanID == RootID || sampled(id, rate) {
+			t.keep(keep, span, math.MaxInt)
+		}
+	}
+	return t.subset(keep)
+}
+
+func sampled(id string, rate float64) bool {
+	h := fnv.New64a()
+	io.WriteString(h, id)
+	// FNV's high bits barely change between sequential IDs, like the ones
+	// some formats number spans with, so mix them in (as in MurmurHash3).
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	return float64(x)/math.MaxUint64 < rate
+}
+
+// Limit returns a Trace with at most n of t's spans, keeping the ones that
+// matter most for a rough picture: the critical path from each root first,
+// then the longest spans, each with its ancestors.
+func (t *Trace) Limit(n int) *Trace {
+	if len(t.Spans) <= n {
+		return t
+	}
+
+	keep := map[string]bool{}
+	for _, root := range t.Roots() {
+		for _, node := range root.CriticalPath() {
+			// The rest of the path is below node, so it can't fit either.
+			if !t.keep(keep, node.Span, n) {
+				break
+			}
+		}
+	}
+
+	spans := make([]*Span, 0, len(t.Spans))
+	for _, span := range t.Spans {
+		spans = append(spans, span)
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		if di, dj := spans[i].Duration(), spans[j].Duration(); di != dj {
+			return di > dj
+		}
+		return spans[i].SpanContext.SpanID < spans[j].SpanContext.SpanID
+	})
+	for _, span := range spans {
+		if len(keep) >= n {
+			break
+		}
+		t.keep(keep, span, n)
+	}
+
+	return t.subset(keep)
+}
+
+// keep adds span and its ancestors to keep and reports true, unless that
+// would make it more than n. It gives up as soon as the chain is too long,
+// so a deep span costs no more than the room left.
+func (t *Trace) keep(keep map[string]bool, span *Span, n int) bool {
+	chain := []string{}
+	seen := map[string]bool{}
+	for span != nil {
+		id := span.SpanContext.SpanID
+		if keep[id] || seen[id] {
+			break
+		}
+		if len(keep)+len(chain) >= n {
+			return false
+		}
+		seen[id] = true
+		chain = append(chain, id)
+		span = t.Spans[span.Parent.SpanID]
+	}
+	for _, id := range chain {
+		keep[id] = true
+	}
+	return true
+}
+
+func (t *Trace) subset(keep map[string]bool) *Trace {
+	out := t.empty()
+	for id := range keep {
+		out.Add(t.Spans[id])
+	}
+	return out
+}
@@ -0,0 +1,130 @@
+package trot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// ids lists t's SpanIDs in order.
+func ids(t *Trace) string {
+	s := []string{}
+	for id := range t.Spans {
+		s = append(s, id)
+	}
+	sort.Strings(s)
+	return strings.Join(s, ",")
+}
+
+// checkAncestors fails if any span in t has a parent that isn't in t.
+func checkAncestors(t *testing.T, tr *Trace) {
+	t.Helper()
+	for id, span := range tr.Spans {
+		if p := span.Parent.SpanID; p != RootID {
+			if _, ok := tr.Spans[p]; !ok {
+				t.Errorf("%s kept without its parent %s", id, p)
+			}
+		}
+	}
+}
+
+// flat is a root with n children, each 1ms longer than the last.
+func flat(n int) *Trace {
+	tr := NewTrace()
+	tr.Add(span("root", RootID, "root", 0, n+1))
+	for i := 0; i < n; i++ {
+		tr.Add(span(fmt.Sprintf("%04d", i), "root", "child", 0, i+1))
+	}
+	return tr
+}
+
+// chain is n spans, each the only child of the one before.
+func chain(n int) *Trace {
+	tr := NewTrace()
+	parent := RootID
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("%016x", i+1)
+		tr.Add(span(id, parent, "call", i, 2*n-i))
+		parent = id
+	}
+	return tr
+}
+
+func TestLimit(t *testing.T) {
+	for _, tc := range []struct {
+		n    int
+		want string
+	}{
+		{10, "a,b,c,d,e"},
+		{5, "a,b,c,d,e"},
+		// The critical path first: c finishes after b, and e is under c.
+		{3, "a,c,e"},
+		{2, "a,c"},
+		{1, "a"},
+		// Then the longest spans: b, but d would need b too.
+		{4, "a,b,c,e"},
+	} {
+		got := testTrace().Limit(tc.n)
+		if ids(got) != tc.want {
+			t.Errorf("Limit(%d) = %s, want %s", tc.n, ids(got), tc.want)
+		}
+		checkAncestors(t, got)
+	}
+
+	tr := flat(100)
+	got := tr.Limit(10)
+	if len(got.Spans) != 10 {
+		t.Errorf("Limit(10) kept %d spans", len(got.Spans))
+	}
+	// The critical path to the last child, then the longest others.
+	if want := "0091,0092,0093,0094,0095,0096,0097,0098,0099,root"; ids(got) != want {
+		t.Errorf("Limit(10) = %s, want %s", ids(got), want)
+	}
+	if again := tr.Limit(10); ids(again) != ids(got) {
+		t.Errorf("Limit(10) = %s, then %s", ids(got), ids(again))
+	}
+}
+
+func TestLimitDeep(t *testing.T) {
+	tr := chain(100000)
+	got := tr.Limit(100)
+	if len(got.Spans) != 100 {
+		t.Fatalf("Limit(100) kept %d spans", len(got.Spans))
+	}
+	checkAncestors(t, got)
+	if _, ok := got.Spans[fmt.Sprintf("%016x", 100)]; !ok {
+		t.Errorf("the top of the chain wasn't kept")
+	}
+}
+
+func TestSample(t *testing.T) {
+	tr := flat(1000)
+	for _, tc := range []struct {
+		rate     float64
+		min, max int
+	}{
+		{0, 1, 1},
+		{0.1, 50, 150},
+		{0.5, 400, 600},
+		{1, 1001, 1001},
+	} {
+		got := tr.Sample(tc.rate)
+		if n := len(got.Spans); n < tc.min || n > tc.max {
+			t.Errorf("Sample(%v) kept %d spans, want %d to %d", tc.rate, n, tc.min, tc.max)
+		}
+		if _, ok := got.Spans["root"]; !ok {
+			t.Errorf("Sample(%v) dropped the root", tc.rate)
+		}
+		if again := tr.Sample(tc.rate); ids(again) != ids(got) {
+			t.Errorf("Sample(%v) isn't deterministic", tc.rate)
+		}
+	}
+
+	deep := chain(1000)
+	got := deep.Sample(0.1)
+	checkAncestors(t, got)
+	if len(got.Spans) < 100 {
+		t.Errorf("Sample(0.1) of a chain kept %d spans, want ancestors of sampled spans too", len(got.Spans))
+	}
+}
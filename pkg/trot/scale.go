@@ -0,0 +1,83 @@
+package trot
+
+import (
+	"math"
+	"time"
+)
+
+// shortest is the shortest leaf span under root, which Log scales measure
+// durations in.
+func shortest(root *Node) time.Duration {
+	d := time.Duration(math.MaxInt64)
+	root.Walk(func(n *Node, depth int) bool {
+		if depth != 0 && len(n.Children) == 0 && n.Duration() > 0 {
+			d = min(d, n.Duration())
+		}
+		return true
+	})
+	if d == math.MaxInt64 {
+		return time.Nanosecond
+	}
+	return d
+}
+
+// x is how far from r.start t is drawn, in nanoseconds, with gaps
+// compressed.
+func (r *renderer) x(t time.Time) float64 {
+	d := float64(t.Sub(r.start))
+	for _, g := range r.gaps {
+		if !t.After(g.start) {
+			break
+		}
+		if !t.Before(g.end) {
+			d -= float64(g.idle() - g.width)
+			continue
+		}
+		into := float64(t.Sub(g.start))
+		d += float64(g.width)*into/float64(g.idle()) - into
+		break
+	}
+	return d
+}
+
+// at is the time drawn x from r.start, undoing x.
+func (r *renderer) at(x float64) time.Time {
+	// shift is how much shorter the gaps so far are drawn.
+	shift := 0.0
+	for _, g := range r.gaps {
+		drawn := float64(g.start.Sub(r.start)) - shift
+		if x <= drawn {
+			break
+		}
+		if x < drawn+float64(g.width) {
+			return g.start.Add(time.Duration(float64(g.idle()) * (x - drawn) / float64(g.width)))
+		}
+		shift += float64(g.idle() - g.width)
+	}
+	return r.start.Add(time.Duration(math.Round(x + shift)))
+}
+
+// pads are the fractions of parent's width left of node and right of it.
+//
+// If r.unit is set, for a Log scale, node is as wide as the log of its
+// duration in units is of parent's, so short spans stay visible, and starts
+// where it would on a linear scale, or as close to it as still fits.
+func (r *renderer) pads(parent, node *Node) (left, right float64) {
+	total := r.x(parent.Span.EndTime) - r.x(parent.Span.StartTime)
+	left = (r.x(node.Span.StartTime) - r.x(parent.Span.StartTime)) / total
+	right = (r.x(parent.Span.EndTime) - r.x(node.Span.EndTime)) / total
+	if r.unit <= 0 {
+		return left, right
+	}
+
+	width := 1.0
+	if outer := r.logWidth(parent); outer > 0 {
+		width = min(r.logWidth(node)/outer, 1)
+	}
+	left = min(max(left, 0), 1-width)
+	return left, 1 - width - left
+}
+
+func (r *renderer) logWidth(n *Node) float64 {
+	return math.Log1p(float64(n.Duration()) / float64(r.unit))
+}
@@ -0,0 +1,45 @@
+package trot
+
+// Scope names the instrumentation library that created s, with its version
+// if it has one, e.g. "go.opentelemetry.io/contrib/net/http/otelhttp 0.46.1".
+func (s *Span) Scope() string {
+	lib := s.InstrumentationLibrary
+	if lib.Version == "" {
+		return lib.Name
+	}
+	return lib.Name + " " + lib.Version
+}
+
+// HideScopes returns a Trace without the spans whose Scope matches hide,
+// e.g. to drop a noisy library. Their children move up to their nearest
+// remaining ancestor.
+func (t *Trace) HideScopes(hide func(scope string) bool) *Trace {
+	hidden := map[string]bool{}
+	for id, span := range t.Spans {
+		if hide(span.Scope()) {
+			hidden[id] = true
+		}
+	}
+	if len(hidden) == 0 {
+		return t
+	}
+
+	out := t.empty()
+	for id, span := range t.Spans {
+		if hidden[id] {
+			continue
+		}
+		parent := span.Parent.SpanID
+		// Bounded, in case the input has a cycle.
+		for i := 0; hidden[parent] && i < len(hidden); i++ {
+			parent = t.Spans[parent].Parent.SpanID
+		}
+		if parent != span.Parent.SpanID {
+			adopted := *span
+			adopted.Parent.SpanID = parent
+			span = &adopted
+		}
+		out.Add(span)
+	}
+	return out
+}
@@ -0,0 +1,265 @@
+package trot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeScript adds the trace page's toolbar and remembers what the user was
+// looking at in localStorage, keyed by the traces on the page, so reloading
+// a big report doesn't lose an investigation. Notes, keyed by SpanID, start
+// out showing on their spans.
+func writeScript(w io.Writer, opts Options) {
+	if opts.NoScript {
+		return
+	}
+	nonce := opts.nonce()
+	if len(opts.Notes) != 0 {
+		// Marshal escapes <, so this can't end the script early.
+		b, err := json.Marshal(opts.Notes)
+		if err == nil {
+			fmt.Fprintf(w, `<script type="application/json" id="trot-notes"%s>%s</script>`, nonce, b)
+		}
+	}
+	fmt.Fprint(w, strings.Replace(script, "<script>", "<script"+nonce+">", 1))
+}
+
+// Notes is the sidecar format for notes exported from a page, which
+// render -notes reads back in.
+type Notes struct {
+	// Notes are keyed by SpanID.
+	Notes map[string]string `json:"notes"`
+}
+
+const script = `
+<script>
+(() => {
+  const traces = [...document.querySelectorAll('div.trace')].map(d => d.dataset.trace);
+  const key = 'trot:' + (traces.length ? traces.join(',') : location.pathname);
+  let state = {};
+  try {
+    state = JSON.parse(localStorage.getItem(key)) || {};
+  } catch (e) {}
+
+  const bar = document.createElement('div');
+  bar.className = 'toolbar';
+  const input = document.createElement('input');
+  input.type = 'search';
+  input.placeholder = 'find spans';
+  const theme = document.createElement('button');
+  theme.textContent = 'theme';
+  const exportNotes = document.createElement('button');
+  exportNotes.textContent = 'export notes';
+  const importNotes = document.createElement('button');
+  importNotes.textContent = 'import notes';
+  const file = document.createElement('input');
+  file.type = 'file';
+  file.accept = 'application/json,.json';
+  file.hidden = true;
+  const exportImage = document.createElement('button');
+  exportImage.textContent = 'export image';
+  bar.append(input, theme, exportNotes, importNotes, exportImage, file);
+  document.body.prepend(bar);
+
+  // Notes from render -notes come first, then whatever was added here.
+  const embedded = document.getElementById('trot-notes');
+  const notes = Object.assign(embedded ? JSON.parse(embedded.textContent) : {}, state.notes);
+  const showNotes = () => {
+    for (const el of document.querySelectorAll('[data-id]')) {
+      let mark = el.querySelector(':scope > mark.note');
+      const note = notes[el.dataset.id];
+      if (!note) {
+        if (mark) mark.remove();
+        continue;
+      }
+      if (!mark) {
+        mark = document.createElement('mark');
+        mark.className = 'note';
+        el.append(' ', mark);
+      }
+      mark.textContent = note;
+    }
+  };
+
+  const save = () => {
+    state.open = [...document.querySelectorAll('details[open] > summary[data-id]')].map(s => s.dataset.id);
+    state.dark = document.body.classList.contains('dark');
+    state.filter = input.value;
+    state.notes = notes;
+    try {
+      localStorage.setItem(key, JSON.stringify(state));
+    } catch (e) {}
+  };
+  let pending;
+  const later = () => {
+    clearTimeout(pending);
+    pending = setTimeout(save, 200);
+  };
+
+  const labels = 'div.trace details:not(.exception):not(.logs) > summary, div.trace div > span, div.trace p';
+  const find = () => {
+    for (const el of document.querySelectorAll('.match')) el.classList.remove('match');
+    const q = input.value.toLowerCase();
+    if (!q) return;
+    for (const el of document.querySelectorAll(labels)) {
+      if (!el.textContent.toLowerCase().includes(q)) continue;
+      el.classList.add('match');
+      // Open everything above it, but not the match itself.
+      const from = el.tagName === 'SUMMARY' ? el.parentElement.parentElement : el;
+      for (let d = from.closest('details'); d; d = d.parentElement.closest('details')) d.open = true;
+    }
+  };
+
+  if (state.open) {
+    const open = new Set(state.open);
+    for (const s of document.querySelectorAll('details > summary[data-id]')) s.parentElement.open = open.has(s.dataset.id);
+  }
+  if (state.dark !== undefined) document.body.classList.toggle('dark', state.dark);
+  if (state.filter) {
+    input.value = state.filter;
+    find();
+  }
+
+  showNotes();
+
+  // Alt-click a span to write a note on it.
+  document.addEventListener('click', e => {
+    if (!e.altKey) return;
+    const el = e.target.closest('[data-id]');
+    if (!el) return;
+    e.preventDefault();
+    const note = prompt('Note for ' + el.dataset.id, notes[el.dataset.id] || '');
+    if (note === null) return;
+    // Keep removed notes as "" so embedded ones don't come back on reload.
+    notes[el.dataset.id] = note.trim();
+    showNotes();
+    later();
+  });
+  exportNotes.addEventListener('click', () => {
+    const a = document.createElement('a');
+    a.href = URL.createObjectURL(new Blob([JSON.stringify({notes: Object.fromEntries(Object.entries(notes).filter(([, v]) => v))}, null, 2)], {type: 'application/json'}));
+    a.download = (traces[0] || 'trot') + '.notes.json';
+    a.click();
+    URL.revokeObjectURL(a.href);
+  });
+  importNotes.addEventListener('click', () => file.click());
+
+  // Rasterize what's on screen by drawing the page into a canvas as an SVG
+  // foreignObject, so it works offline without any libraries.
+  exportImage.addEventListener('click', () => {
+    const w = innerWidth, h = innerHeight, scale = devicePixelRatio || 1;
+    const page = document.documentElement.cloneNode(true);
+    for (const el of page.querySelectorAll('script, div.toolbar')) el.remove();
+    const body = page.querySelector('body');
+    body.style.margin = '0';
+    body.style.transform = 'translate(' + -scrollX + 'px,' + -scrollY + 'px)';
+    if (getComputedStyle(document.body).backgroundColor === 'rgba(0, 0, 0, 0)') body.style.backgroundColor = 'white';
+    const svg = '<svg xmlns="http://www.w3.org/2000/svg" width="' + w + '" height="' + h + '">' +
+      '<foreignObject width="100%" height="100%">' + new XMLSerializer().serializeToString(page) + '</foreignObject></svg>';
+
+    const img = new Image();
+    img.onload = () => {
+      const canvas = document.createElement('canvas');
+      canvas.width = w * scale;
+      canvas.height = h * scale;
+      const ctx = canvas.getContext('2d');
+      ctx.scale(scale, scale);
+      ctx.drawImage(img, 0, 0);
+      canvas.toBlob(blob => {
+        const a = document.createElement('a');
+        a.href = URL.createObjectURL(blob);
+        a.download = (traces[0] || 'trot') + '.png';
+        a.click();
+        URL.revokeObjectURL(a.href);
+      });
+    };
+    img.onerror = () => alert('this browser could not render the page to an image');
+    img.src = 'data:image/svg+xml;charset=utf-8,' + encodeURIComponent(svg);
+  });
+  file.addEventListener('change', async () => {
+    if (!file.files.length) return;
+    try {
+      Object.assign(notes, JSON.parse(await file.files[0].text()).notes);
+    } catch (e) {
+      alert('bad notes file: ' + e);
+    }
+    file.value = '';
+    showNotes();
+    later();
+  });
+
+  // Keyboard navigation follows the ARIA tree view pattern: one span at a
+  // time is in the tab order, and the arrow keys move between spans.
+  const shown = () => [...document.querySelectorAll('[role=treeitem]')].filter(el => {
+    const from = el.tagName === 'SUMMARY' ? el.parentElement.parentElement : el;
+    return !from.closest('details:not([open])');
+  });
+  const rove = el => {
+    for (const t of document.querySelectorAll('[role=treeitem][tabindex="0"]')) t.tabIndex = -1;
+    el.tabIndex = 0;
+  };
+  const items = document.querySelectorAll('[role=treeitem]');
+  for (const el of items) el.tabIndex = -1;
+  if (items.length) items[0].tabIndex = 0;
+  document.addEventListener('focusin', e => {
+    if (e.target.matches('[role=treeitem]')) rove(e.target);
+  });
+  document.addEventListener('keydown', e => {
+    const el = e.target;
+    if (!el.matches('[role=treeitem]') || e.altKey || e.ctrlKey || e.metaKey) return;
+    const d = el.tagName === 'SUMMARY' ? el.parentElement : null;
+    const all = shown();
+    let next;
+    switch (e.key) {
+      case 'ArrowDown':
+        next = all[all.indexOf(el) + 1];
+        break;
+      case 'ArrowUp':
+        next = all[all.indexOf(el) - 1];
+        break;
+      case 'Home':
+        next = all[0];
+        break;
+      case 'End':
+        next = all[all.length - 1];
+        break;
+      case 'ArrowRight':
+        if (d && !d.open) d.open = true;
+        else if (d) next = d.querySelector(':scope > div [role=treeitem]');
+        break;
+      case 'ArrowLeft':
+        if (d && d.open) {
+          d.open = false;
+        } else {
+          const up = (d || el).parentElement.closest('details');
+          if (up) next = up.querySelector(':scope > summary[role=treeitem]');
+        }
+        break;
+      default:
+        return;
+    }
+    e.preventDefault();
+    if (next) {
+      rove(next);
+      next.focus();
+    }
+  });
+
+  document.addEventListener('toggle', e => {
+    const s = e.target.querySelector(':scope > summary[aria-expanded]');
+    if (s) s.setAttribute('aria-expanded', e.target.open);
+    later();
+  }, true);
+  input.addEventListener('input', () => {
+    find();
+    later();
+  });
+  theme.addEventListener('click', () => {
+    document.body.classList.toggle('dark');
+    later();
+  });
+})();
+</script>
+`
@@ -0,0 +1,146 @@
+package trot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Selector picks out spans by where they are in a trace, like a file path:
+// span names from a top-level span down, separated by "/". Within a name, "*"
+// matches anything and "?" any one character; a whole "**" matches any number
+// of spans in between, including none. A backslash escapes the next
+// character, so `GET \/users` is one name.
+//
+// For example, root/serve/**/db.query is every db.query span anywhere under
+// a serve span directly under a top-level span named root.
+type Selector struct {
+	s string
+
+	// steps match one span name each; nil is a "**".
+	steps []*regexp.Regexp
+}
+
+// ParseSelector parses s as a Selector.
+func ParseSelector(s string) (*Selector, error) {
+	sel := &Selector{s: s}
+
+	parts := []string{}
+	var part strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			if i+1 == len(s) {
+				return nil, fmt.Errorf("selector %q: trailing backslash", s)
+			}
+			i++
+			part.WriteString(regexp.QuoteMeta(s[i : i+1]))
+		case '/':
+			parts = append(parts, part.String())
+			part.Reset()
+		case '*':
+			part.WriteString(".*")
+		case '?':
+			part.WriteString(".")
+		default:
+			part.WriteString(regexp.QuoteMeta(s[i : i+1]))
+		}
+	}
+	parts = append(parts, part.String())
+
+	for _, p := range parts {
+		if p == "" {
+			return nil, fmt.Errorf("selector %q: empty span name", s)
+		}
+		if p == ".*.*" {
+			sel.steps = append(sel.steps, nil)
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + p + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("selector %q: %w", s, err)
+		}
+		sel.steps = append(sel.steps, re)
+	}
+	return sel, nil
+}
+
+func (sel *Selector) String() string {
+	return sel.s
+}
+
+// Match reports whether the span names in path, from a top-level span down,
+// are selected.
+func (sel *Selector) Match(path []string) bool {
+	states := sel.start()
+	for _, name := range path {
+		states = sel.next(states, name)
+	}
+	return states[len(sel.steps)]
+}
+
+// start returns the states before any names. Rather than backtrack over
+// every way to split a path between "**"s, which is exponential in how many
+// there are, selectors match a name at a time, tracking every step they
+// could be at: states[i] means steps[:i] have matched so far, and
+// states[len(steps)] means all of them have.
+func (sel *Selector) start() []bool {
+	states := make([]bool, len(sel.steps)+1)
+	states[0] = true
+	return sel.skip(states)
+}
+
+// next returns the states after matching name from states.
+func (sel *Selector) next(states []bool, name string) []bool {
+	out := make([]bool, len(states))
+	for i, ok := range states[:len(sel.steps)] {
+		switch {
+		case !ok:
+		case sel.steps[i] == nil:
+			// A "**" can take any number of names.
+			out[i] = true
+		case sel.steps[i].MatchString(name):
+			out[i+1] = true
+		}
+	}
+	return sel.skip(out)
+}
+
+// skip adds the states past each "**" that matches no names.
+func (sel *Selector) skip(states []bool) []bool {
+	for i, step := range sel.steps {
+		if states[i] && step == nil {
+			states[i+1] = true
+		}
+	}
+	return states
+}
+
+// dead reports whether no span below states can be selected.
+func dead(states []bool) bool {
+	for _, ok := range states {
+		if ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the spans under n (a root from Tree, whose children are the
+// top-level spans) that sel selects, in pre-order.
+func (n *Node) Select(sel *Selector) []*Span {
+	selected := []*Span{}
+	// states[depth] is where sel is after the names down to that depth.
+	states := [][]bool{sel.start()}
+	n.Walk(func(node *Node, depth int) bool {
+		if depth == 0 {
+			return true
+		}
+		states = append(states[:depth], sel.next(states[depth-1], node.Span.Name))
+		if states[depth][len(sel.steps)] {
+			selected = append(selected, node.Span)
+		}
+		return !dead(states[depth])
+	})
+	return selected
+}
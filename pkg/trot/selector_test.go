@@ -0,0 +1,84 @@
+package trot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelector(t *testing.T) {
+	// testTrace is a > b > d, and a > c > db.query.
+	root := testTrace().Tree("root", RootID)
+	for _, tc := range []struct {
+		sel, want string
+	}{
+		{"a", "a"},
+		{"a/*", "b,c"},
+		{"a/**", "a,b,d,c,db.query"},
+		{"**/db.query", "db.query"},
+		{"a/**/db.*", "db.query"},
+		{"a/c/db.query", "db.query"},
+		{"a/db.query", ""},
+		{"*/?", "b,c"},
+		{"**/d/**", "d"},
+		{`**/db\.query`, "db.query"},
+	} {
+		sel, err := ParseSelector(tc.sel)
+		if err != nil {
+			t.Fatalf("ParseSelector(%q): %v", tc.sel, err)
+		}
+		got := []string{}
+		for _, span := range root.Select(sel) {
+			got = append(got, span.Name)
+		}
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("Select(%q) = %s, want %s", tc.sel, strings.Join(got, ","), tc.want)
+		}
+	}
+
+	sel, err := ParseSelector(`GET \/users/*`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sel.Match([]string{"GET /users", "db"}) || sel.Match([]string{"GET", "users", "db"}) {
+		t.Errorf("escaped slash: %v", sel.steps)
+	}
+
+	for _, bad := range []string{"", "a//b", "a/", `a\`} {
+		if _, err := ParseSelector(bad); err == nil {
+			t.Errorf("ParseSelector(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestSelectorDeep(t *testing.T) {
+	const depth = 100000
+	root := chain(depth).Tree("root", RootID)
+	for _, tc := range []struct {
+		sel  string
+		want int
+	}{
+		{"**/zzz", 0},
+		{"**/**/**/**/**/**/**/zzz", 0},
+		{"**/call", depth},
+		{"call/**/call/**/call", depth - 2},
+		{"call/call/zzz/**", 0},
+	} {
+		sel, err := ParseSelector(tc.sel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(root.Select(sel)); got != tc.want {
+			t.Errorf("Select(%q) = %d spans, want %d", tc.sel, got, tc.want)
+		}
+	}
+
+	// Backtracking over where each ** ends is exponential in how many there are.
+	path := strings.Split(strings.Repeat("a/", 60)+"b", "/")
+	sel, err := ParseSelector(strings.Repeat("**/a/", 20) + "**/zzz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sel.Match(path) {
+		t.Errorf("Match(%q) matched", sel)
+	}
+}
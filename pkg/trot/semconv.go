@@ -0,0 +1,347 @@
+package trot
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// summarizers describe spans that follow an OpenTelemetry semantic
+// convention, keyed by the attribute prefix that convention uses.
+var summarizers = []struct {
+	prefix []byte
+	fn     func(attrs []KeyValue) string
+}{
+	{[]byte(`"http.`), httpSummary},
+	{dbPrefix, dbSummary},
+	{[]byte(`"rpc.`), rpcSummary},
+	{[]byte(`"messaging.`), messagingSummary},
+	{[]byte(`"buildkit.`), buildkitSummary},
+	{[]byte(`"pprof.`), pprofSummary},
+	{[]byte(`"strace.`), straceSummary},
+	{[]byte(`"process.`), processSummary},
+}
+
+var dbPrefix = []byte(`"db.`)
+
+// Summary describes what s did in a few words, e.g. "GET /api/users → 500",
+// if its attributes follow a semantic convention trot knows about.
+func (s *Span) Summary() string {
+	var attrs []KeyValue
+	for _, sum := range summarizers {
+		// Most spans follow none of them, so don't decode unless we have to.
+		if !bytes.Contains(s.Attributes, sum.prefix) {
+			continue
+		}
+		if attrs == nil {
+			attrs = s.Attrs()
+		}
+		if summary := sum.fn(attrs); summary != "" {
+			return summary
+		}
+	}
+	return ""
+}
+
+// first returns the value of the first of keys present in attrs, so we can
+// handle both current and deprecated attribute names.
+func first(attrs []KeyValue, keys ...string) string {
+	for _, key := range keys {
+		if v, ok := lookup(attrs, key); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func httpSummary(attrs []KeyValue) string {
+	method := first(attrs, "http.request.method", "http.method")
+	target := first(attrs, "url.path", "http.target", "http.route", "url.full", "http.url")
+	status := first(attrs, "http.response.status_code", "http.status_code")
+	if method == "" && target == "" && status == "" {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSpace(method + " " + target))
+	if status != "" {
+		if sb.Len() != 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString("→ " + status)
+	}
+	if size, err := strconv.ParseInt(first(attrs, "http.response.body.size", "http.response_content_length"), 10, 64); err == nil {
+		sb.WriteString(", " + formatBytes(size))
+	}
+	return sb.String()
+}
+
+func dbSummary(attrs []KeyValue) string {
+	system := first(attrs, "db.system.name", "db.system")
+	op := first(attrs, "db.operation.name", "db.operation")
+	stmt := normalizeStatement(first(attrs, "db.query.text", "db.statement"))
+
+	parts := []string{}
+	for _, p := range []string{system, op, stmt} {
+		if p == "" {
+			continue
+		}
+		// The statement usually starts with the operation; don't say it twice.
+		if p == stmt && op != "" && strings.HasPrefix(strings.ToUpper(stmt), strings.ToUpper(op)) {
+			parts[len(parts)-1] = stmt
+			continue
+		}
+		parts = append(parts, p)
+	}
+	return strings.Join(parts, " ")
+}
+
+func rpcSummary(attrs []KeyValue) string {
+	system := first(attrs, "rpc.system")
+	service := first(attrs, "rpc.service")
+	method := first(attrs, "rpc.method")
+	if service == "" && method == "" {
+		return ""
+	}
+
+	summary := strings.TrimSpace(system + " " + strings.Trim(service+"/"+method, "/"))
+	if code := first(attrs, "rpc.grpc.status_code"); code != "" {
+		if n, err := strconv.Atoi(code); err == nil && n >= 0 && n < len(grpcCodes) {
+			code = grpcCodes[n]
+		}
+		summary += " → " + code
+	}
+	return summary
+}
+
+func messagingSummary(attrs []KeyValue) string {
+	system := first(attrs, "messaging.system")
+	op := first(attrs, "messaging.operation.name", "messaging.operation.type", "messaging.operation")
+	dest := first(attrs, "messaging.destination.name", "messaging.destination")
+	if op == "" && dest == "" {
+		return ""
+	}
+	return strings.Join(strings.Fields(system+" "+op+" "+dest), " ")
+}
+
+// processSummary shows the command line of spans for a command, like the ones
+// otel-cli exec and trot run make, and how it exited if not cleanly.
+func processSummary(attrs []KeyValue) string {
+	cmd := first(attrs, "process.command_line")
+	for _, kv := range attrs {
+		if args, ok := kv.Value.Value.([]any); ok && kv.Key == "process.command_args" {
+			parts := make([]string, 0, len(args))
+			for _, a := range args {
+				parts = append(parts, fmt.Sprint(a))
+			}
+			cmd = strings.Join(parts, " ")
+		}
+	}
+	if cmd == "" {
+		cmd = first(attrs, "process.command")
+	}
+	if cmd == "" {
+		return ""
+	}
+	if code := first(attrs, "process.exit.code"); code != "" && code != "0" {
+		cmd += " → exit " + code
+	}
+	return cmd
+}
+
+func buildkitSummary(attrs []KeyValue) string {
+	if cached, _ := lookup(attrs, "buildkit.cached"); cached == "true" {
+		return "CACHED"
+	}
+	if size, err := strconv.ParseInt(first(attrs, "buildkit.total_bytes"), 10, 64); err == nil {
+		return formatBytes(size)
+	}
+	return ""
+}
+
+// grpcCodes names gRPC status codes, as in google.golang.org/grpc/codes.
+var grpcCodes = []string{
+	"OK",
+	"CANCELLED",
+	"UNKNOWN",
+	"INVALID_ARGUMENT",
+	"DEADLINE_EXCEEDED",
+	"NOT_FOUND",
+	"ALREADY_EXISTS",
+	"PERMISSION_DENIED",
+	"RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION",
+	"ABORTED",
+	"OUT_OF_RANGE",
+	"UNIMPLEMENTED",
+	"INTERNAL",
+	"UNAVAILABLE",
+	"DATA_LOSS",
+	"UNAUTHENTICATED",
+}
+
+// CodeLocation is where in the source a span was created.
+type CodeLocation struct {
+	Path     string
+	Line     int
+	Function string
+}
+
+var codePrefix = []byte(`"code.`)
+
+// Code returns the span's code.* attributes, if it has any.
+func (s *Span) Code() (CodeLocation, bool) {
+	if !bytes.Contains(s.Attributes, codePrefix) {
+		return CodeLocation{}, false
+	}
+	attrs := s.Attrs()
+	loc := CodeLocation{
+		Path:     first(attrs, "code.file.path", "code.filepath"),
+		Function: first(attrs, "code.function.name", "code.function"),
+	}
+	loc.Line, _ = strconv.Atoi(first(attrs, "code.line.number", "code.lineno"))
+	return loc, loc.Path != ""
+}
+
+func (c CodeLocation) String() string {
+	if c.Line == 0 {
+		return path.Base(c.Path)
+	}
+	return path.Base(c.Path) + ":" + strconv.Itoa(c.Line)
+}
+
+// URL fills in l's .Path, .Line, and .Function with c's, trimming root from
+// the start of the path, e.g. with
+// https://github.com/org/repo/blob/main/{{.Path}}#L{{.Line}}.
+func (c CodeLocation) URL(l LinkTemplate, root string) string {
+	u, _ := l.execute(LinkData{
+		Path:     strings.TrimPrefix(strings.TrimPrefix(c.Path, root), "/"),
+		Line:     c.Line,
+		Function: c.Function,
+	})
+	return u
+}
+
+// maxStatement is how many characters of a query to show.
+const maxStatement = 80
+
+// normalizeStatement collapses whitespace and replaces literals with ?, so
+// queries are short and don't leak values, then truncates the result.
+func normalizeStatement(s string) string {
+	var b strings.Builder
+	space := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			space = b.Len() != 0
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		switch {
+		case c == '\'':
+			// Skip to the closing quote; '' is an escaped quote.
+			j := i + 1
+			for ; j < len(s); j++ {
+				if s[j] == '\'' {
+					if j+1 < len(s) && s[j+1] == '\'' {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			b.WriteByte('?')
+			i = j
+		case isDigit(c) && (i == 0 || !isWordByte(s[i-1])):
+			j := i
+			for j < len(s) && (isDigit(s[j]) || s[j] == '.') {
+				j++
+			}
+			b.WriteByte('?')
+			i = j - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	out := b.String()
+	if utf8.RuneCountInString(out) <= maxStatement {
+		return out
+	}
+	runes := []rune(out)
+	return string(runes[:maxStatement-1]) + "…"
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isWordByte(c byte) bool {
+	return isDigit(c) || c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// dbTime is how long any database call in spans was running. Overlapping
+// calls, like a query inside a transaction, only count once.
+func dbTime(spans map[string]*Span) time.Duration {
+	type interval struct{ start, end time.Time }
+	calls := []interval{}
+	for _, span := range spans {
+		if isDBCall(span) {
+			calls = append(calls, interval{span.StartTime, span.EndTime})
+		}
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		return calls[i].start.Before(calls[j].start)
+	})
+
+	var total time.Duration
+	var end time.Time
+	for _, c := range calls {
+		if c.start.Before(end) {
+			if !c.end.After(end) {
+				continue
+			}
+			c.start = end
+		}
+		total += c.end.Sub(c.start)
+		end = c.end
+	}
+	return total
+}
+
+// isDBCall reports whether span has any db.* attributes.
+func isDBCall(span *Span) bool {
+	// Only decode the attributes of spans that might have one.
+	if !bytes.Contains(span.Attributes, dbPrefix) {
+		return false
+	}
+	for _, kv := range span.Attrs() {
+		if strings.HasPrefix(kv.Key, "db.") {
+			return true
+		}
+	}
+	return false
+}
+
+// formatBytes formats n in decimal units, e.g. 12.4 KB.
+func formatBytes(n int64) string {
+	if n < 1000 {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	f := float64(n)
+	for _, unit := range []string{"KB", "MB", "GB", "TB"} {
+		f /= 1000
+		if f < 999.95 || unit == "TB" {
+			return strconv.FormatFloat(f, 'f', 1, 64) + " " + unit
+		}
+	}
+	panic("unreachable")
+}
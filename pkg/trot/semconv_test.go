@@ -0,0 +1,161 @@
+package trot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummary(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		attrs []KeyValue
+		want  string
+	}{{
+		name: "none",
+		attrs: []KeyValue{
+			keyValue("foo", "bar"),
+		},
+	}, {
+		name: "http",
+		attrs: []KeyValue{
+			keyValue("http.request.method", "GET"),
+			keyValue("url.path", "/api/users"),
+			keyValue("http.response.status_code", float64(500)),
+			keyValue("http.response.body.size", float64(12400)),
+		},
+		want: "GET /api/users → 500, 12.4 KB",
+	}, {
+		name: "http deprecated",
+		attrs: []KeyValue{
+			keyValue("http.method", "POST"),
+			keyValue("http.route", "/users/:id"),
+			keyValue("http.response_content_length", float64(512)),
+		},
+		want: "POST /users/:id, 512 B",
+	}, {
+		name: "http status only",
+		attrs: []KeyValue{
+			keyValue("http.status_code", float64(404)),
+		},
+		want: "→ 404",
+	}, {
+		name: "db",
+		attrs: []KeyValue{
+			keyValue("db.system", "postgresql"),
+			keyValue("db.operation", "SELECT"),
+			keyValue("db.statement", "SELECT *\n  FROM users\n  WHERE id = 42 AND name = 'o''brien'"),
+		},
+		want: "postgresql SELECT * FROM users WHERE id = ? AND name = ?",
+	}, {
+		name: "db without statement",
+		attrs: []KeyValue{
+			keyValue("db.system.name", "redis"),
+			keyValue("db.operation.name", "GET"),
+		},
+		want: "redis GET",
+	}, {
+		name: "grpc",
+		attrs: []KeyValue{
+			keyValue("rpc.system", "grpc"),
+			keyValue("rpc.service", "myapp.Users"),
+			keyValue("rpc.method", "GetUser"),
+			keyValue("rpc.grpc.status_code", float64(5)),
+		},
+		want: "grpc myapp.Users/GetUser → NOT_FOUND",
+	}, {
+		name: "messaging",
+		attrs: []KeyValue{
+			keyValue("messaging.system", "kafka"),
+			keyValue("messaging.operation.name", "publish"),
+			keyValue("messaging.destination.name", "orders"),
+		},
+		want: "kafka publish orders",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &Span{Attributes: rawJSON(tc.attrs)}
+			if got := s.Summary(); got != tc.want {
+				t.Errorf("Summary() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{
+		0:             "0 B",
+		999:           "999 B",
+		1000:          "1.0 KB",
+		12_400:        "12.4 KB",
+		999_999:       "1.0 MB",
+		3_500_000_000: "3.5 GB",
+	} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestNormalizeStatement(t *testing.T) {
+	long := "SELECT " + strings.Repeat("column, ", 20) + "x FROM t"
+	for in, want := range map[string]string{
+		"select  1":                  "select ?",
+		"SELECT t1.a FROM t1":        "SELECT t1.a FROM t1",
+		"INSERT INTO t VALUES (1.5)": "INSERT INTO t VALUES (?)",
+		"WHERE s = 'it''s'":          "WHERE s = ?",
+		long:                         long[:maxStatement-1] + "…",
+	} {
+		if got := normalizeStatement(in); got != want {
+			t.Errorf("normalizeStatement(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDBTime(t *testing.T) {
+	db := rawJSON([]KeyValue{keyValue("db.system", "sqlite")})
+	at := func(ms int) time.Time {
+		return epoch.Add(time.Duration(ms) * time.Millisecond)
+	}
+	spans := map[string]*Span{
+		"tx":    {Attributes: db, StartTime: at(0), EndTime: at(100)},
+		"query": {Attributes: db, StartTime: at(10), EndTime: at(50)},
+		"later": {Attributes: db, StartTime: at(90), EndTime: at(120)},
+		"other": {StartTime: at(200), EndTime: at(300)},
+		// Only keys count, not values that look like them.
+		"value": {Attributes: rawJSON([]KeyValue{keyValue("note", `"db.`)}), StartTime: at(300), EndTime: at(400)},
+		"last":  {Attributes: db, StartTime: at(400), EndTime: at(410)},
+	}
+	if got, want := dbTime(spans), 130*time.Millisecond; got != want {
+		t.Errorf("dbTime() = %v, want %v", got, want)
+	}
+}
+
+func TestCode(t *testing.T) {
+	s := &Span{Attributes: rawJSON([]KeyValue{
+		keyValue("code.filepath", "/home/me/src/repo/pkg/foo/foo.go"),
+		keyValue("code.lineno", float64(42)),
+		keyValue("code.function", "foo.Bar"),
+	})}
+	loc, ok := s.Code()
+	if !ok {
+		t.Fatal("Code() found nothing")
+	}
+	if got, want := loc.String(), "foo.go:42"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	l, err := ParseLinkTemplate("https://github.com/org/repo/blob/abc123/{{.Path}}#L{{.Line}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := loc.URL(l, "/home/me/src/repo"), "https://github.com/org/repo/blob/abc123/pkg/foo/foo.go#L42"; got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+	loc.Path = "/home/me/src/repo/my dir/a#b.go"
+	if got, want := loc.URL(l, "/home/me/src/repo"), "https://github.com/org/repo/blob/abc123/my%20dir/a%23b.go#L42"; got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+
+	if _, ok := (&Span{}).Code(); ok {
+		t.Error("Code() found something in a span without code.* attributes")
+	}
+}
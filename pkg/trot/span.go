@@ -0,0 +1,216 @@
+package trot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	KindUnspecified = iota
+	KindInternal
+	KindServer
+	KindClient
+	KindProducer
+	KindConsumer
+)
+
+type SpanContext struct {
+	TraceID    string `json:"TraceID"`
+	SpanID     string `json:"SpanID"`
+	TraceFlags string `json:"TraceFlags"`
+	TraceState string `json:"TraceState"`
+	Remote     bool   `json:"Remote"`
+}
+
+// Span matches the JSON written by go.opentelemetry.io/otel/exporters/stdout/stdouttrace.
+//
+// Thank you mholt.
+type Span struct {
+	Name        string      `json:"Name"`
+	SpanContext SpanContext `json:"SpanContext"`
+	Parent      struct {
+		TraceID    string `json:"TraceID"`
+		SpanID     string `json:"SpanID"`
+		TraceFlags string `json:"TraceFlags"`
+		TraceState string `json:"TraceState"`
+		Remote     bool   `json:"Remote"`
+	} `json:"Parent"`
+	SpanKind  int       `json:"SpanKind"`
+	StartTime time.Time `json:"StartTime"`
+	EndTime   time.Time `json:"EndTime"`
+
+	// Attributes, Events, and Links stay as JSON until something asks for them,
+	// since rendering most spans never does. See Attrs and decodeAs.
+	Attributes json.RawMessage `json:"Attributes"`
+	Events     json.RawMessage `json:"Events"`
+	Links      json.RawMessage `json:"Links"`
+
+	Status struct {
+		Code        string `json:"Code"`
+		Description string `json:"Description"`
+	} `json:"Status"`
+	DroppedAttributes      int        `json:"DroppedAttributes"`
+	DroppedEvents          int        `json:"DroppedEvents"`
+	DroppedLinks           int        `json:"DroppedLinks"`
+	ChildSpanCount         int        `json:"ChildSpanCount"`
+	Resource               []KeyValue `json:"Resource"`
+	InstrumentationLibrary struct {
+		Name      string `json:"Name"`
+		Version   string `json:"Version"`
+		SchemaURL string `json:"SchemaURL"`
+	} `json:"InstrumentationLibrary"`
+}
+
+type KeyValue struct {
+	Key   string `json:"Key"`
+	Value Value  `json:"Value"`
+}
+
+type Value struct {
+	Type  string `json:"Type"`
+	Value any    `json:"Value"`
+}
+
+// String formats the value regardless of its type.
+func (v Value) String() string {
+	switch val := v.Value.(type) {
+	case string:
+		return val
+	case float64:
+		// Decoded JSON numbers are all float64; don't write big ints as 3e+07.
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	}
+	return fmt.Sprint(v.Value)
+}
+
+func valueOf(v any) Value {
+	typ := "STRING"
+	switch v := v.(type) {
+	case bool:
+		typ = "BOOL"
+	case int, int64:
+		typ = "INT64"
+	case float64:
+		typ = "FLOAT64"
+		if v == float64(int64(v)) {
+			typ = "INT64"
+		}
+	case []any:
+		typ = "SLICE"
+	}
+	return Value{Type: typ, Value: v}
+}
+
+// keyValue builds an attribute from a decoded JSON value, for decoders of other formats.
+func keyValue(key string, value any) KeyValue {
+	return KeyValue{Key: key, Value: valueOf(value)}
+}
+
+// rawJSON encodes v for the lazily decoded Span fields.
+func rawJSON(v any) json.RawMessage {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+func (s *Span) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// Attr returns the value of the span attribute named key.
+func (s *Span) Attr(key string) (string, bool) {
+	return attr(s.Attributes, key)
+}
+
+// Attrs decodes every span attribute.
+func (s *Span) Attrs() []KeyValue {
+	return decodeAs[KeyValue](s.Attributes)
+}
+
+// Baggage returns the span attributes copied from W3C baggage, which are
+// named baggage.<key>, without that prefix.
+func (s *Span) Baggage() []KeyValue {
+	if !bytes.Contains(s.Attributes, []byte("baggage.")) {
+		return nil
+	}
+	var bag []KeyValue
+	for _, kv := range s.Attrs() {
+		if key, ok := strings.CutPrefix(kv.Key, "baggage."); ok {
+			kv.Key = key
+			bag = append(bag, kv)
+		}
+	}
+	return bag
+}
+
+// DecodeEvents decodes the span's events.
+func (s *Span) DecodeEvents() []Event {
+	return decodeAs[Event](s.Events)
+}
+
+// Service returns the service.name resource attribute, or "unknown".
+func (s *Span) Service() string {
+	for _, kv := range s.Resource {
+		if kv.Key == "service.name" {
+			return kv.Value.String()
+		}
+	}
+	return "unknown"
+}
+
+func (s *Span) IsError() bool {
+	return s.Status.Code == "Error"
+}
+
+// attr finds key in a stdouttrace-style list of {"Key": ..., "Value": {"Type": ..., "Value": ...}}.
+func attr(attrs json.RawMessage, key string) (string, bool) {
+	// Most lookups miss, so don't decode unless the key is in there somewhere.
+	if !bytes.Contains(attrs, []byte(key)) {
+		return "", false
+	}
+	return lookup(decodeAs[KeyValue](attrs), key)
+}
+
+// lookup finds key in kvs.
+func lookup(kvs []KeyValue, key string) (string, bool) {
+	for _, kv := range kvs {
+		if kv.Key != key {
+			continue
+		}
+		if kv.Value.Value == nil {
+			return "", false
+		}
+		return kv.Value.String(), true
+	}
+	return "", false
+}
+
+// Event is something that happened during a span, like an exception.
+type Event struct {
+	Name       string     `json:"Name"`
+	Attributes []KeyValue `json:"Attributes"`
+	Time       time.Time  `json:"Time"`
+}
+
+type link struct {
+	SpanContext SpanContext `json:"SpanContext"`
+	Attributes  []KeyValue  `json:"Attributes"`
+}
+
+// decodeAs decodes a stdouttrace-style list, or returns nil if it isn't one.
+func decodeAs[T any](raw json.RawMessage) []T {
+	if len(raw) == 0 {
+		return nil
+	}
+	var out []T
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil
+	}
+	return out
+}
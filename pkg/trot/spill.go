@@ -0,0 +1,151 @@
+package trot
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"sort"
+)
+
+// spillBuckets is how many files spilled traces are spread over. Close reads
+// one bucket at a time, so each should hold about 1/spillBuckets of the spill.
+const spillBuckets = 256
+
+// spanOverhead approximates the memory a decoded span takes beyond its strings.
+const spanOverhead = 512
+
+func spanSize(s *Span) int64 {
+	return spanOverhead + int64(len(s.Name)+len(s.Attributes)+len(s.Events)+len(s.Links))
+}
+
+// Spill makes s move incomplete traces into files under dir once the spans it
+// is holding add up to more than about limit bytes. Spilled traces are read
+// back one file at a time by Close, so memory stays roughly bounded no matter
+// how large the input is.
+func (s *Streamer) Spill(dir string, limit int64) {
+	s.spill = &spill{
+		dir:     dir,
+		limit:   limit,
+		spilled: map[string]bool{},
+	}
+}
+
+type spill struct {
+	dir     string
+	limit   int64
+	spilled map[string]bool
+	files   [spillBuckets]*os.File
+	encs    [spillBuckets]*json.Encoder
+}
+
+func (sp *spill) has(tid string) bool {
+	return sp.spilled[tid]
+}
+
+func (sp *spill) bucket(tid string) int {
+	h := fnv.New32a()
+	io.WriteString(h, tid)
+	return int(h.Sum32() % spillBuckets)
+}
+
+// add appends span to its trace's bucket.
+func (sp *spill) add(span *Span) error {
+	tid := span.SpanContext.TraceID
+	sp.spilled[tid] = true
+
+	i := sp.bucket(tid)
+	if sp.files[i] == nil {
+		f, err := os.CreateTemp(sp.dir, "spill-*.json")
+		if err != nil {
+			return fmt.Errorf("spilling: %w", err)
+		}
+		sp.files[i] = f
+		sp.encs[i] = json.NewEncoder(f)
+	}
+	if err := sp.encs[i].Encode(span); err != nil {
+		return fmt.Errorf("spilling: %w", err)
+	}
+	return nil
+}
+
+// drain calls fn with every spilled trace, removing each file once read.
+func (sp *spill) drain(fn func(*Trace) error) error {
+	for i, f := range sp.files {
+		if f == nil {
+			continue
+		}
+		sp.files[i] = nil
+
+		err := func() error {
+			defer os.Remove(f.Name())
+			defer f.Close()
+
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			t := NewTrace()
+			if err := decode(f, "stdouttrace", t); err != nil {
+				return fmt.Errorf("reading spill: %w", err)
+			}
+
+			traces := []*Trace{}
+			for _, tt := range t.Split() {
+				traces = append(traces, tt)
+			}
+			byStart(traces)
+			for _, tt := range traces {
+				if err := fn(tt); err != nil {
+					return err
+				}
+			}
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanup removes any files that haven't been drained.
+func (sp *spill) cleanup() {
+	for i, f := range sp.files {
+		if f != nil {
+			f.Close()
+			os.Remove(f.Name())
+			sp.files[i] = nil
+		}
+	}
+}
+
+// spillLargest moves the biggest pending traces to disk until s is holding
+// half its limit, so it doesn't have to spill again on the very next span.
+func (s *Streamer) spillLargest() error {
+	type tracePending struct {
+		tid string
+		*pending
+	}
+	ps := make([]tracePending, 0, len(s.pending))
+	for tid, p := range s.pending {
+		ps = append(ps, tracePending{tid, p})
+	}
+	sort.Slice(ps, func(i, j int) bool {
+		return ps[i].size > ps[j].size
+	})
+
+	for _, p := range ps {
+		if s.held <= s.spill.limit/2 {
+			break
+		}
+		for _, span := range p.t.Sorted() {
+			if err := s.spill.add(span); err != nil {
+				return err
+			}
+		}
+		delete(s.pending, p.tid)
+		s.held -= p.size
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package trot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// spillInput is n traces of 10 spans each, interleaved so none completes
+// before all of them have started, each span with an attribute to check.
+func spillInput(n int) []*Span {
+	spans := []*Span{}
+	for i := 9; i >= 0; i-- {
+		for tid := 0; tid < n; tid++ {
+			parent := RootID
+			if i != 0 {
+				parent = fmt.Sprintf("%d-0", tid)
+			}
+			s := tspan(fmt.Sprint(tid), fmt.Sprintf("%d-%d", tid, i), parent, tid+i, tid+20, 0)
+			if i == 0 {
+				s.ChildSpanCount = 9
+			}
+			s.Attributes = rawJSON([]KeyValue{keyValue("i", i)})
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+func TestSpill(t *testing.T) {
+	dir := t.TempDir()
+	got := map[string]*Trace{}
+	s := NewStreamer(func(t *Trace) error {
+		tid := t.Summarize().TraceID
+		if got[tid] != nil {
+			return fmt.Errorf("trace %s came back twice", tid)
+		}
+		got[tid] = t
+		return nil
+	})
+	// About five spans' worth, so most traces have to spill.
+	s.Spill(dir, 5*spanOverhead)
+
+	const n = 50
+	for _, span := range spillInput(n) {
+		s.Add(span)
+	}
+	if files, _ := os.ReadDir(dir); len(files) == 0 {
+		t.Fatalf("nothing spilled")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != n {
+		t.Errorf("got %d traces back, want %d", len(got), n)
+	}
+	for tid, tr := range got {
+		if len(tr.Spans) != 10 {
+			t.Errorf("trace %s has %d spans, want 10", tid, len(tr.Spans))
+		}
+		for id, span := range tr.Spans {
+			var i int
+			if _, err := fmt.Sscanf(id, tid+"-%d", &i); err != nil {
+				t.Errorf("span %s in trace %s", id, tid)
+				continue
+			}
+			if v, _ := span.Attr("i"); v != fmt.Sprint(i) {
+				t.Errorf("span %s has i=%s, want %d", id, v, i)
+			}
+		}
+		if roots := tr.Tree("root", RootID).Children; len(roots) != 1 || len(roots[0].Children) != 9 {
+			t.Errorf("trace %s isn't one root with 9 children", tid)
+		}
+	}
+
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("%d spill files left behind", len(files))
+	}
+}
+
+func TestSpillCleanup(t *testing.T) {
+	dir := t.TempDir()
+	errStop := errors.New("stop")
+	s := NewStreamer(func(*Trace) error {
+		return errStop
+	})
+	// Spill some traces but keep others in memory, none complete until
+	// Close, so it fails before reading the spill.
+	s.Spill(dir, 50*spanOverhead)
+	for _, span := range spillInput(20) {
+		span.ChildSpanCount *= 2
+		s.Add(span)
+	}
+	if err := s.Close(); !errors.Is(err, errStop) {
+		t.Errorf("Close() = %v, want %v", err, errStop)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("%d spill files left behind after an error", len(files))
+	}
+}
@@ -0,0 +1,53 @@
+package trot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// stdouttrace is the format written by go.opentelemetry.io/otel/exporters/stdout/stdouttrace.
+type stdouttrace struct{}
+
+func (stdouttrace) Name() string {
+	return "stdouttrace"
+}
+
+func (stdouttrace) Sniff(peek []byte) bool {
+	return bytes.Contains(peek, []byte(`"SpanContext"`))
+}
+
+func (stdouttrace) Decode(r io.Reader, t *Trace) error {
+	i := 0
+
+	dec := json.NewDecoder(r)
+	for {
+		i++
+		var span Span
+		start := dec.InputOffset()
+		if err := dec.Decode(&span); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return fmt.Errorf("span %d: %w", i, valueOffset(start, err))
+		}
+
+		t.Add(&span)
+	}
+
+	return nil
+}
+
+// EncodeStdouttrace writes every span in t as stdouttrace JSON, one per line, ordered by start time.
+func EncodeStdouttrace(w io.Writer, t *Trace) error {
+	enc := json.NewEncoder(w)
+	for _, span := range t.Sorted() {
+		if err := enc.Encode(span); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package trot
+
+import (
+	"sort"
+	"sync"
+)
+
+// Store holds traces for Handler.
+type Store interface {
+	// List summarizes every stored trace.
+	List() []Summary
+
+	// Get returns the trace with the given TraceID.
+	Get(traceID string) (*Trace, bool)
+}
+
+// MemStore is a Store that keeps everything in memory.
+type MemStore struct {
+	mu     sync.Mutex
+	traces map[string]*memTrace
+}
+
+// memTrace is a stored trace. Spans trickle in, often one per request from
+// short-lived processes, so they're only merged into t when it's next read.
+type memTrace struct {
+	t       *Trace
+	pending []*Span
+}
+
+func NewMemStore() *MemStore {
+	return &MemStore{
+		traces: map[string]*memTrace{},
+	}
+}
+
+// Add merges the spans in t into the store, grouped by TraceID, in whatever
+// order they arrive.
+func (s *MemStore) Add(t *Trace) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for tid, tt := range t.Split() {
+		existing, ok := s.traces[tid]
+		if !ok {
+			s.traces[tid] = &memTrace{t: tt}
+			continue
+		}
+		for _, span := range tt.Spans {
+			existing.pending = append(existing.pending, span)
+		}
+	}
+}
+
+// trace merges any pending spans into m.t and returns it.
+func (m *memTrace) trace() *Trace {
+	if len(m.pending) == 0 {
+		return m.t
+	}
+
+	// Copy rather than mutate, since Get hands out existing traces.
+	merged := m.t.empty()
+	for _, span := range m.t.Spans {
+		merged.Add(span)
+	}
+	for _, span := range m.pending {
+		merged.Add(span)
+	}
+	m.t, m.pending = merged, nil
+	return m.t
+}
+
+func (s *MemStore) List() []Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]Summary, 0, len(s.traces))
+	for _, m := range s.traces {
+		list = append(list, m.trace().Summarize())
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Start.After(list[j].Start)
+	})
+
+	return list
+}
+
+func (s *MemStore) Get(traceID string) (*Trace, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.traces[traceID]
+	if !ok {
+		return nil, false
+	}
+	return m.trace(), true
+}
+
+// Len returns how many traces and spans s holds.
+func (s *MemStore) Len() (traces, spans int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range s.traces {
+		spans += len(m.t.Spans)
+		// Pending spans may replace stored ones, or each other.
+		seen := map[string]bool{}
+		for _, span := range m.pending {
+			id := span.SpanContext.SpanID
+			if _, ok := m.t.Spans[id]; !ok && !seen[id] {
+				seen[id] = true
+				spans++
+			}
+		}
+	}
+	return len(s.traces), spans
+}
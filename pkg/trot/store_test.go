@@ -0,0 +1,28 @@
+package trot
+
+import "testing"
+
+func TestMemStoreLen(t *testing.T) {
+	store := NewMemStore()
+	if traces, spans := store.Len(); traces != 0 || spans != 0 {
+		t.Errorf("empty Len() = %d, %d", traces, spans)
+	}
+
+	store.Add(testTrace())
+	// These stay pending until the trace is next read: one new span, one
+	// that replaces a stored one, and a duplicate of the new one.
+	more := NewTrace()
+	more.Add(span("f", "a", "f", 0, 10))
+	more.Add(span("b", "a", "b", 10, 50))
+	store.Add(more)
+	again := NewTrace()
+	again.Add(span("f", "a", "f", 0, 10))
+	store.Add(again)
+
+	if traces, spans := store.Len(); traces != 1 || spans != 6 {
+		t.Errorf("Len() = %d, %d, want 1, 6", traces, spans)
+	}
+	if tr, _ := store.Get(""); len(tr.Spans) != 6 {
+		t.Errorf("Get() has %d spans, want 6", len(tr.Spans))
+	}
+}
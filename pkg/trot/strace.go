@@ -0,0 +1,201 @@
+package trot
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// strace is the output of strace -ttt -f (add -T for syscall durations): a
+// span per process, under whichever process forked it, holding a span per
+// syscall.
+type strace struct{}
+
+func (strace) Name() string {
+	return "strace"
+}
+
+// straceLine matches a line's pid (if -f put one there), its -ttt timestamp,
+// and the rest.
+var straceLine = regexp.MustCompile(`^(?:\[pid\s+(\d+)\]\s+|(\d+)\s+)?(\d{9,}\.\d+)\s+(.*)$`)
+
+// straceCall matches a syscall's name, args, return value, and -T duration.
+var straceCall = regexp.MustCompile(`^(\w+)\((.*)\)\s+=\s+(.+?)(?:\s+<(\d+\.\d+)>)?$`)
+
+// straceEvent matches the start of what a line can say.
+var straceEvent = regexp.MustCompile(`^(\w+\(|<\.\.\. |--- |\+\+\+ )`)
+
+func (strace) Sniff(peek []byte) bool {
+	line, _, _ := bytes.Cut(bytes.TrimLeft(peek, " \t\r\n"), []byte("\n"))
+	m := straceLine.FindSubmatch(bytes.TrimSpace(line))
+	return m != nil && straceEvent.Match(m[4])
+}
+
+// straceProc is a process and its syscalls.
+type straceProc struct {
+	pid   string
+	span  *Span
+	calls []*Span
+	// pending are unfinished syscalls, waiting to be resumed.
+	pending map[string]*Span
+}
+
+func (strace) Decode(r io.Reader, t *Trace) error {
+	procs := map[string]*straceProc{}
+	order := []*straceProc{}
+	parents := map[string]string{}
+	h := fnv.New128a()
+	var run string
+
+	proc := func(pid string, ts time.Time) *straceProc {
+		p, ok := procs[pid]
+		if !ok {
+			span := &Span{Name: "pid " + pid, SpanKind: KindInternal, StartTime: ts, EndTime: ts}
+			if pid == "" {
+				// Without -f, or before the first fork.
+				span.Name = "process"
+			}
+			span.SpanContext.SpanID = stableID("pid " + run + " " + pid)
+			span.Status.Code = "Unset"
+			span.Resource = []KeyValue{keyValue("service.name", "strace"), keyValue("process.pid", pid)}
+			span.Attributes = rawJSON([]KeyValue{keyValue("process.pid", pid)})
+			p = &straceProc{pid: pid, span: span, pending: map[string]*Span{}}
+			procs[pid] = p
+			order = append(order, p)
+		}
+		if ts.After(p.span.EndTime) {
+			p.span.EndTime = ts
+		}
+		return p
+	}
+	call := func(p *straceProc, name, args, ret string, start, end time.Time) {
+		span := &Span{Name: name, SpanKind: KindInternal, StartTime: start, EndTime: end}
+		span.SpanContext.SpanID = stableID(fmt.Sprintf("call %s %d", p.span.SpanContext.SpanID, len(p.calls)))
+		span.Status.Code = "Unset"
+		span.Resource = p.span.Resource
+		span.Attributes = rawJSON([]KeyValue{keyValue("strace.args", args), keyValue("strace.return", ret)})
+		p.calls = append(p.calls, span)
+		if end.After(p.span.EndTime) {
+			p.span.EndTime = end
+		}
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 16<<20)
+	for sc.Scan() {
+		m := straceLine.FindStringSubmatch(strings.TrimSpace(sc.Text()))
+		if m == nil {
+			continue
+		}
+		pid := m[1] + m[2]
+		if run == "" {
+			// The first line names the whole run, so the same run always
+			// gets the same IDs.
+			run = m[3] + " " + pid
+			h.Write([]byte(run))
+		}
+		ts, _ := unixSeconds(m[3])
+		p := proc(pid, ts)
+		rest := m[4]
+
+		switch {
+		case strings.HasPrefix(rest, "+++ "):
+			// +++ exited with 1 +++ or +++ killed by SIGKILL +++
+			msg := strings.TrimSuffix(strings.TrimPrefix(rest, "+++ "), " +++")
+			p.span.Attributes = rawJSON(append(p.span.Attrs(), keyValue("strace.exit", msg)))
+			if msg != "exited with 0" {
+				p.span.Status.Code = "Error"
+				p.span.Status.Description = msg
+			}
+		case strings.HasPrefix(rest, "--- "):
+			// --- SIGCHLD {si_signo=SIGCHLD, ...} ---
+			sig := strings.TrimSuffix(strings.TrimPrefix(rest, "--- "), " ---")
+			name, _, _ := strings.Cut(sig, " ")
+			p.span.Events = rawJSON(append(p.span.DecodeEvents(), Event{Name: name, Time: ts, Attributes: []KeyValue{keyValue("strace.signal", sig)}}))
+		case strings.HasSuffix(rest, "<unfinished ...>"):
+			name, args, _ := strings.Cut(strings.TrimSuffix(rest, "<unfinished ...>"), "(")
+			span := &Span{Name: name, StartTime: ts}
+			span.Attributes = rawJSON([]KeyValue{keyValue("strace.args", strings.TrimSpace(args))})
+			p.pending[name] = span
+		case strings.HasPrefix(rest, "<... "):
+			// <... read resumed>"...", 832) = 832 <0.000012>
+			name, resumed, _ := strings.Cut(strings.TrimPrefix(rest, "<... "), " resumed>")
+			start, args := ts, ""
+			if span, ok := p.pending[name]; ok {
+				start = span.StartTime
+				args, _ = span.Attr("strace.args")
+				delete(p.pending, name)
+			}
+			cm := straceCall.FindStringSubmatch(name + "(" + strings.TrimSpace(args+" "+resumed))
+			if cm == nil {
+				call(p, name, args+" "+resumed, "?", start, ts)
+				continue
+			}
+			call(p, name, cm[2], cm[3], start, ts)
+			if name == "clone" || name == "clone3" || name == "fork" || name == "vfork" {
+				parents[cm[3]] = pid
+			}
+		default:
+			cm := straceCall.FindStringSubmatch(rest)
+			if cm == nil {
+				continue
+			}
+			end := ts
+			if cm[4] != "" {
+				if d, err := strconv.ParseFloat(cm[4], 64); err == nil {
+					end = ts.Add(time.Duration(d * float64(time.Second)))
+				}
+			}
+			call(p, cm[1], cm[2], cm[3], ts, end)
+			switch cm[1] {
+			case "clone", "clone3", "fork", "vfork":
+				parents[cm[3]] = pid
+			case "execve":
+				if path, err := strconv.Unquote(strings.SplitN(cm[2], ",", 2)[0]); err == nil && !strings.HasPrefix(cm[3], "-1") {
+					p.span.Name = strings.TrimSpace("pid " + pid + " " + path)
+				}
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if len(order) == 0 {
+		return nil
+	}
+
+	tid := fmt.Sprintf("%x", h.Sum(nil))
+	for _, p := range order {
+		span := p.span
+		span.SpanContext.TraceID, span.Parent.TraceID = tid, tid
+		span.Parent.SpanID = RootID
+		if parent, ok := procs[parents[p.pid]]; ok && p.pid != "" {
+			span.Parent.SpanID = parent.span.SpanContext.SpanID
+		}
+		t.Add(span)
+		for _, c := range p.calls {
+			c.SpanContext.TraceID, c.Parent.TraceID = tid, tid
+			c.Parent.SpanID = span.SpanContext.SpanID
+			t.Add(c)
+		}
+	}
+	return nil
+}
+
+func straceSummary(attrs []KeyValue) string {
+	args, ret := first(attrs, "strace.args"), first(attrs, "strace.return")
+	if ret == "" {
+		return ""
+	}
+	if utf8.RuneCountInString(args) > maxStatement {
+		args = string([]rune(args)[:maxStatement-1]) + "…"
+	}
+	return "(" + args + ") = " + ret
+}
@@ -0,0 +1,42 @@
+package trot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStrace(t *testing.T) {
+	in := `100   1700000000.000000 execve("/bin/sh", ["sh", "-c", "ls"], 0x7ffd /* 20 vars */) = 0 <0.000300>
+100   1700000000.001000 clone(child_stack=NULL, flags=CLONE_CHILD_CLEARTID|SIGCHLD) = 101 <0.000100>
+101   1700000000.002000 execve("/bin/ls", ["ls"], 0x7ffd /* 20 vars */) = 0 <0.000200>
+100   1700000000.002500 wait4(-1,  <unfinished ...>
+101   1700000000.003000 openat(AT_FDCWD, "/nope", O_RDONLY) = -1 ENOENT (No such file or directory) <0.000010>
+101   1700000000.004000 +++ exited with 2 +++
+100   1700000000.004100 <... wait4 resumed>[{WIFEXITED(s) && WEXITSTATUS(s) == 2}], 0, NULL) = 101 <0.001600>
+100   1700000000.004200 --- SIGCHLD {si_signo=SIGCHLD, si_code=CLD_EXITED, si_pid=101} ---
+100   1700000000.005000 +++ exited with 2 +++
+`
+	tr, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := tr.Tree("root", RootID).Children
+	if len(root) != 1 || root[0].Span.Name != "pid 100 /bin/sh" {
+		t.Fatalf("roots = %s, want pid 100 /bin/sh", names(root))
+	}
+	if got, want := names(root[0].Children), "execve,clone,pid 101 /bin/ls,wait4"; got != want {
+		t.Fatalf("children = %s, want %s", got, want)
+	}
+	wait := root[0].Children[3].Span
+	if got, want := wait.Duration(), 1600*time.Microsecond; got != want {
+		t.Errorf("wait4 took %v, want %v", got, want)
+	}
+	child := root[0].Children[2]
+	if !child.Span.IsError() {
+		t.Errorf("child exited 2 but isn't an error")
+	}
+	if got, want := child.Children[1].Span.Summary(), `(AT_FDCWD, "/nope", O_RDONLY) = -1 ENOENT (No such file or directory)`; got != want {
+		t.Errorf("openat summary = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,154 @@
+package trot
+
+import (
+	"io"
+	"sort"
+	"time"
+)
+
+// Streamer groups spans by TraceID and hands each trace off as soon as it
+// looks complete, so huge inputs don't have to fit in memory all at once.
+//
+// A trace looks complete once its root span has arrived and every span's
+// ChildSpanCount is accounted for. Formats without child counts complete on
+// their root span, which exporters write last. Spans that arrive after their
+// trace was handed off start a new, partial trace.
+type Streamer struct {
+	fn      func(*Trace) error
+	pending map[string]*pending
+	err     error
+
+	// held approximates the bytes of spans in pending, for spilling.
+	held  int64
+	spill *spill
+}
+
+type pending struct {
+	t *Trace
+
+	root bool
+
+	// want is the sum of ChildSpanCount; found is how many children are present.
+	want, found int
+
+	size int64
+}
+
+// NewStreamer returns a Streamer that calls fn with each completed trace.
+func NewStreamer(fn func(*Trace) error) *Streamer {
+	return &Streamer{
+		fn:      fn,
+		pending: map[string]*pending{},
+	}
+}
+
+// Decode reads every span in r, as ParseFormat would.
+func (s *Streamer) Decode(r io.Reader, format string) error {
+	if err := decode(r, format, &Trace{sink: s.Add}); err != nil {
+		return err
+	}
+	return s.err
+}
+
+// Add adds span to its trace, flushing the trace if it is now complete.
+func (s *Streamer) Add(span *Span) {
+	if s.err != nil {
+		return
+	}
+
+	tid := span.SpanContext.TraceID
+	if s.spill != nil && s.spill.has(tid) {
+		s.err = s.spill.add(span)
+		return
+	}
+
+	p, ok := s.pending[tid]
+	if !ok {
+		// Each trace interns its own strings, so they go when it does.
+		p = &pending{t: NewTrace()}
+		s.pending[tid] = p
+	}
+
+	id := span.SpanContext.SpanID
+	if _, dup := p.t.Spans[id]; dup {
+		p.t.Add(span)
+		p.recount()
+	} else {
+		p.want += span.ChildSpanCount
+		if _, ok := p.t.Spans[span.Parent.SpanID]; ok {
+			p.found++
+		}
+		p.found += len(p.t.Children[id])
+		p.t.Add(span)
+		if span.Parent.SpanID == RootID {
+			p.root = true
+		}
+	}
+
+	size := spanSize(span)
+	p.size += size
+	s.held += size
+
+	if p.root && p.found >= p.want {
+		delete(s.pending, tid)
+		s.held -= p.size
+		s.err = s.fn(p.t)
+		return
+	}
+
+	if s.spill != nil && s.held > s.spill.limit {
+		s.err = s.spillLargest()
+	}
+}
+
+func (p *pending) recount() {
+	p.root, p.want, p.found = false, 0, 0
+	for id, span := range p.t.Spans {
+		p.want += span.ChildSpanCount
+		p.found += len(p.t.Children[id])
+		if span.Parent.SpanID == RootID {
+			p.root = true
+		}
+	}
+}
+
+// Close flushes every incomplete trace, oldest first.
+func (s *Streamer) Close() error {
+	if s.spill != nil {
+		// drain removes the files it reads; this gets the rest on error.
+		defer s.spill.cleanup()
+	}
+	if s.err != nil {
+		return s.err
+	}
+
+	rest := make([]*Trace, 0, len(s.pending))
+	for _, p := range s.pending {
+		rest = append(rest, p.t)
+	}
+	s.pending = map[string]*pending{}
+
+	byStart(rest)
+
+	for _, t := range rest {
+		if err := s.fn(t); err != nil {
+			return err
+		}
+	}
+
+	if s.spill != nil {
+		return s.spill.drain(s.fn)
+	}
+	return nil
+}
+
+// byStart sorts traces oldest first, summarizing each just once.
+func byStart(traces []*Trace) {
+	starts := make(map[*Trace]time.Time, len(traces))
+	for _, t := range traces {
+		starts[t] = t.Summarize().Start
+	}
+	sort.Slice(traces, func(i, j int) bool {
+		return starts[traces[i]].Before(starts[traces[j]])
+	})
+}
@@ -0,0 +1,114 @@
+package trot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// tspan is span in trace tid, claiming kids children.
+func tspan(tid, id, parent string, start, end, kids int) *Span {
+	s := span(id, parent, id, start, end)
+	s.SpanContext.TraceID = tid
+	s.Parent.TraceID = tid
+	s.ChildSpanCount = kids
+	return s
+}
+
+// flushed describes each trace a Streamer handed off: its TraceID and spans.
+type flushed []string
+
+func (f *flushed) add(t *Trace) error {
+	*f = append(*f, fmt.Sprintf("%s:%s", t.Summarize().TraceID, ids(t)))
+	return nil
+}
+
+func TestStreamer(t *testing.T) {
+	var got flushed
+	s := NewStreamer(got.add)
+
+	// Two traces, interleaved, with children before their parents as
+	// exporters write them.
+	for _, span := range []*Span{
+		tspan("1", "c", "b", 2, 3, 0),
+		tspan("2", "y", "x", 2, 3, 0),
+		tspan("1", "b", "a", 1, 4, 1),
+		tspan("2", "x", RootID, 0, 5, 1),
+	} {
+		s.Add(span)
+	}
+	if want := "2:x,y"; strings.Join(got, " ") != want {
+		t.Fatalf("flushed %q, want %q as soon as its root arrived", got, want)
+	}
+
+	// A root that claims more children than have arrived waits for them.
+	s.Add(tspan("1", "a", RootID, 0, 5, 2))
+	if len(got) != 1 {
+		t.Fatalf("flushed %q before all of a's children arrived", got)
+	}
+	s.Add(tspan("1", "d", "a", 4, 5, 0))
+	if want := "2:x,y 1:a,b,c,d"; strings.Join(got, " ") != want {
+		t.Fatalf("flushed %q, want %q", got, want)
+	}
+
+	// A span for a trace that was already flushed starts a new one.
+	s.Add(tspan("1", "e", "a", 4, 5, 0))
+	// A trace whose root never arrives waits for Close, orphans and all.
+	s.Add(tspan("3", "o", "missing", 1, 2, 0))
+	s.Add(tspan("3", "p", "o", 1, 2, 0))
+	if len(got) != 2 {
+		t.Fatalf("flushed %q before Close", got)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Oldest first.
+	if want := "2:x,y 1:a,b,c,d 3:o,p 1:e"; strings.Join(got, " ") != want {
+		t.Errorf("flushed %q, want %q", got, want)
+	}
+}
+
+func TestStreamerDecode(t *testing.T) {
+	var input strings.Builder
+	for _, span := range []*Span{
+		tspan("1", "b", "a", 1, 2, 0),
+		tspan("2", "x", RootID, 0, 5, 0),
+		tspan("1", "a", RootID, 0, 3, 1),
+	} {
+		b, err := json.Marshal(span)
+		if err != nil {
+			t.Fatal(err)
+		}
+		input.Write(b)
+		input.WriteString("\n")
+	}
+
+	var got flushed
+	s := NewStreamer(got.add)
+	if err := s.Decode(strings.NewReader(input.String()), ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "2:x 1:a,b"; strings.Join(got, " ") != want {
+		t.Errorf("flushed %q, want %q", got, want)
+	}
+}
+
+func TestStreamerError(t *testing.T) {
+	calls := 0
+	s := NewStreamer(func(*Trace) error {
+		calls++
+		return fmt.Errorf("disk full")
+	})
+	s.Add(tspan("1", "a", RootID, 0, 1, 0))
+	s.Add(tspan("2", "b", RootID, 0, 1, 0))
+	if err := s.Close(); err == nil || err.Error() != "disk full" {
+		t.Errorf("Close() = %v, want disk full", err)
+	}
+	if calls != 1 {
+		t.Errorf("fn called %d times after failing", calls)
+	}
+}
@@ -0,0 +1,162 @@
+package trot
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// RenderTable writes every span in t as a row of a table (name, service,
+// start, duration, self time, and status) that sorts by whichever column
+// header is clicked and filters by what's typed above it.
+func RenderTable(w io.Writer, t *Trace, opts Options) error {
+	if opts.Title == "" {
+		opts.Title = rootName(t)
+	}
+	var namer *namer
+	if opts.Name != nil {
+		namer = newNamer(opts.Name)
+	}
+
+	// Starts are relative to each trace's first span.
+	starts := map[string]time.Time{}
+	for _, span := range t.Spans {
+		tid := span.SpanContext.TraceID
+		if s, ok := starts[tid]; !ok || span.StartTime.Before(s) {
+			starts[tid] = span.StartTime
+		}
+	}
+	traces := len(starts) > 1
+
+	writeHeader(w, opts)
+	writeWarnings(w, t.Warnings())
+	writeErrors(w, t.Errors())
+	fmt.Fprint(w, `<table class="sortable"><thead><tr>`)
+	if traces {
+		fmt.Fprint(w, `<th>trace</th>`)
+	}
+	fmt.Fprint(w, `<th>name</th><th>service</th><th class="num">start</th><th class="num">duration</th><th class="num">self</th><th>status</th></tr></thead><tbody>`+"\n")
+
+	b := []byte{}
+	cell := func(sort any, text string, num bool) {
+		b = appendCell(b, sort, html.EscapeString(text), num)
+	}
+	for _, root := range t.Roots() {
+		root.Walk(func(n *Node, _ int) bool {
+			span := n.Span
+			name := span.Name
+			if namer != nil {
+				name = namer.name(span)
+			}
+			status := span.Status.Code
+			if span.Status.Description != "" {
+				status += ": " + span.Status.Description
+			}
+			start := span.StartTime.Sub(starts[span.SpanContext.TraceID])
+
+			b = append(b[:0], `<tr`...)
+			if span.IsError() {
+				b = append(b, ` class="error"`...)
+			}
+			b = append(b, '>')
+			if traces {
+				cell(html.EscapeString(span.SpanContext.TraceID), shortID(span.SpanContext.TraceID), false)
+			}
+			cell(html.EscapeString(strings.ToLower(name)), name, false)
+			cell(html.EscapeString(strings.ToLower(span.Service())), span.Service(), false)
+			cell(int64(start), "+"+opts.duration(start), true)
+			cell(int64(span.Duration()), opts.duration(span.Duration()), true)
+			self := n.SelfTime()
+			cell(int64(self), opts.duration(self), true)
+			cell(html.EscapeString(status), status, false)
+			b = append(b, "</tr>\n"...)
+			w.Write(b)
+			return true
+		})
+	}
+	fmt.Fprint(w, `</tbody></table>`)
+	writeSortable(w, opts)
+	writeFooter(w)
+	return nil
+}
+
+// appendCell appends a cell of a table.sortable, which sorts by sort, holding
+// the markup inner.
+func appendCell(b []byte, sort any, inner string, num bool) []byte {
+	b = append(b, `<td`...)
+	if num {
+		b = append(b, ` class="num"`...)
+	}
+	b = fmt.Appendf(b, ` data-sort="%v">`, sort)
+	b = append(b, inner...)
+	return append(b, `</td>`...)
+}
+
+// writeSortable styles the page's table.sortable and, unless NoScript is
+// set, sorts it by whichever column header is clicked and filters it by
+// what's typed above it.
+func writeSortable(w io.Writer, opts Options) {
+	nonce := opts.nonce()
+	fmt.Fprint(w, strings.Replace(tableStyle, "<style>", "<style"+nonce+">", 1))
+	if !opts.NoScript {
+		fmt.Fprint(w, strings.Replace(tableScript, "<script>", "<script"+nonce+">", 1))
+	}
+}
+
+// shortID is the start of a long ID, which is usually enough to tell
+// traces apart.
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+const tableScript = `
+<script>
+(() => {
+  const table = document.querySelector('table.sortable'), body = table.tBodies[0];
+  const filter = document.createElement('input');
+  filter.type = 'search';
+  filter.placeholder = 'filter rows';
+  table.before(filter);
+  filter.addEventListener('input', () => {
+    const q = filter.value.toLowerCase();
+    for (const row of body.rows) row.hidden = q && !row.textContent.toLowerCase().includes(q);
+  });
+
+  let by = -1, dir = 1;
+  table.tHead.rows[0].querySelectorAll('th').forEach((th, i) => {
+    th.addEventListener('click', () => {
+      dir = by === i ? -dir : th.classList.contains('num') ? -1 : 1;
+      by = i;
+      const num = th.classList.contains('num');
+      const key = (row) => num ? +row.cells[i].dataset.sort : row.cells[i].dataset.sort;
+      const rows = [...body.rows].sort((a, b) => {
+        const x = key(a), y = key(b);
+        return x < y ? -dir : x > y ? dir : 0;
+      });
+      // Too many rows to spread into one append.
+      const frag = document.createDocumentFragment();
+      for (const row of rows) frag.append(row);
+      body.append(frag);
+      for (const h of table.tHead.rows[0].cells) h.removeAttribute('aria-sort');
+      th.setAttribute('aria-sort', dir > 0 ? 'ascending' : 'descending');
+    });
+  });
+})();
+</script>`
+
+const tableStyle = `
+<style>
+table.sortable { border-collapse: collapse; }
+table.sortable th { cursor: pointer; text-align: left; border-bottom: 1px solid; }
+table.sortable th[aria-sort=ascending]::after { content: " ▲"; }
+table.sortable th[aria-sort=descending]::after { content: " ▼"; }
+table.sortable td { padding: 1px 8px; white-space: nowrap; }
+table.sortable .num { text-align: right; }
+table.sortable tr.error { color: darkred; }
+body.dark table.sortable tr.error { color: #f48771; }
+</style>`
@@ -0,0 +1,211 @@
+package trot
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Tenants splits traces into groups by a resource attribute of their
+// top-level spans, like service.name or k8s.namespace.name, so teams can
+// share one trot: see Handler.
+type Tenants struct {
+	// Key is the resource attribute that names a trace's group.
+	Key string
+
+	// Tokens, keyed by group, are what it takes to see those groups, as an
+	// HTTP Basic password or a Bearer token. Groups without one are open.
+	Tokens map[string]string
+
+	// LoadBaselines, if set, returns a group's Baselines, which are kept
+	// apart from other groups' so traces aren't compared across them.
+	LoadBaselines func(group string) (*Baselines, error)
+
+	// Options are what every group's pages are rendered with.
+	Options Options
+
+	mu        sync.Mutex
+	baselines map[string]*Baselines
+}
+
+// Group returns the group t belongs in: the Key of its earliest top-level
+// span, or of its earliest span if it has none, or "unknown".
+func (ts *Tenants) Group(t *Trace) string {
+	earliest := func(top bool) *Span {
+		var first *Span
+		for _, span := range t.Spans {
+			if top && span.Parent.SpanID != RootID {
+				continue
+			}
+			if first == nil || span.StartTime.Before(first.StartTime) {
+				first = span
+			}
+		}
+		return first
+	}
+	first := earliest(true)
+	if first == nil {
+		first = earliest(false)
+	}
+	if first != nil {
+		if v, ok := lookup(first.Resource, ts.Key); ok && v != "" {
+			return v
+		}
+	}
+	return "unknown"
+}
+
+// EscapeGroup escapes group for use as one segment of a URL path or as a
+// file name: like url.PathEscape, but with "." and ".." escaped too, so no
+// group can stand for its parent.
+func EscapeGroup(group string) string {
+	if group == "." || group == ".." {
+		return strings.ReplaceAll(group, ".", "%2E")
+	}
+	return url.PathEscape(group)
+}
+
+// Baselines returns group's Baselines, loading them the first time, or nil
+// if there's no LoadBaselines.
+func (ts *Tenants) Baselines(group string) (*Baselines, error) {
+	if ts.LoadBaselines == nil {
+		return nil, nil
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if b, ok := ts.baselines[group]; ok {
+		return b, nil
+	}
+	b, err := ts.LoadBaselines(group)
+	if err != nil {
+		return nil, err
+	}
+	if ts.baselines == nil {
+		ts.baselines = map[string]*Baselines{}
+	}
+	ts.baselines[group] = b
+	return b, nil
+}
+
+// Handler serves a list of the groups in store at "/", and each group like
+// BaselineHandler (or Handler, without LoadBaselines) at "/<group>/".
+// A group's traces can't be seen from any other group.
+func (ts *Tenants) Handler(store Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Split the escaped path, so groups can have slashes in them.
+		escaped, rest, nested := strings.Cut(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "/")
+		group, err := url.PathUnescape(escaped)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if group == "" {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			ts.index(w, store)
+			return
+		}
+
+		if token := ts.Tokens[group]; token != "" && !authorized(r, token) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", "trot: "+group))
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !nested {
+			// Relative links in the group's index need a trailing slash.
+			http.Redirect(w, r, EscapeGroup(group)+"/", http.StatusMovedPermanently)
+			return
+		}
+
+		baselines, err := ts.Baselines(group)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		path, err := url.PathUnescape("/" + rest)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path, r2.URL.RawPath = path, ""
+
+		h := &handler{store: &groupStore{store: store, ts: ts, group: group}, baselines: baselines, opts: ts.Options}
+		h.ServeHTTP(w, r2)
+	})
+}
+
+// authorized reports whether r has token as its Basic password or Bearer
+// token.
+func authorized(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		_, got, _ = r.BasicAuth()
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+func (ts *Tenants) index(w http.ResponseWriter, store Store) {
+	counts := map[string]int{}
+	for _, s := range store.List() {
+		if t, ok := store.Get(s.TraceID); ok {
+			counts[ts.Group(t)]++
+		}
+	}
+	groups := make([]string, 0, len(counts))
+	for group := range counts {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writeHeader(w, Options{Title: ts.Key})
+	fmt.Fprintf(w, `<table><tr><th>%s</th><th>traces</th></tr>`, html.EscapeString(ts.Key))
+	for _, group := range groups {
+		locked := ""
+		if ts.Tokens[group] != "" {
+			locked = " (token required)"
+		}
+		fmt.Fprintf(w, `<tr><td><a href="./%s/">%s</a>%s</td><td>%d</td></tr>`+"\n",
+			EscapeGroup(group), html.EscapeString(group), locked, counts[group])
+	}
+	fmt.Fprint(w, `</table>`)
+	writeFooter(w)
+}
+
+// groupStore is the part of a Store in one group.
+type groupStore struct {
+	store Store
+	ts    *Tenants
+	group string
+}
+
+func (g *groupStore) List() []Summary {
+	list := []Summary{}
+	for _, s := range g.store.List() {
+		if _, ok := g.Get(s.TraceID); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+func (g *groupStore) Get(traceID string) (*Trace, bool) {
+	t, ok := g.store.Get(traceID)
+	if !ok || g.ts.Group(t) != g.group {
+		return nil, false
+	}
+	return t, true
+}
@@ -0,0 +1,205 @@
+package trot
+
+import (
+	"io"
+	"maps"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RootID is the parent SpanID that stdouttrace uses for root spans.
+const RootID = "0000000000000000"
+
+// Trace is every span read from an input, which may contain many traces.
+type Trace struct {
+	Spans    map[string]*Span
+	Children map[string][]*Span
+
+	// Duplicates counts the spans Add replaced with a later one with the
+	// same SpanID.
+	Duplicates int
+	// duplicates counts them by the TraceID of the span replaced, so Split
+	// can keep them with their trace.
+	duplicates map[string]int
+
+	// sink, if set, receives spans from Add instead of t.
+	sink func(*Span)
+
+	in *interner
+}
+
+// NewTrace returns an empty Trace.
+func NewTrace() *Trace {
+	return &Trace{
+		Spans:    map[string]*Span{},
+		Children: map[string][]*Span{},
+		in:       newInterner(),
+	}
+}
+
+// Parse decodes r, sniffing which registered format it is in.
+func Parse(r io.Reader) (*Trace, error) {
+	return ParseFormat(r, "")
+}
+
+// Add indexes span by its SpanID and parent SpanID, replacing any span already added with the same SpanID.
+//
+// Spans with an empty or all-zero parent SpanID, as some exporters write
+// roots, are given RootID as their parent.
+func (t *Trace) Add(span *Span) {
+	if p := span.Parent.SpanID; p != RootID && isZeroID(p) {
+		span.Parent.SpanID = RootID
+	}
+	if t.sink != nil {
+		t.sink(span)
+		return
+	}
+	if t.in != nil {
+		t.in.span(span)
+	}
+
+	id := span.SpanContext.SpanID
+	if old, ok := t.Spans[id]; ok {
+		if old != span {
+			t.Duplicates++
+			if t.duplicates == nil {
+				t.duplicates = map[string]int{}
+			}
+			t.duplicates[old.SpanContext.TraceID]++
+		}
+		kids := t.Children[old.Parent.SpanID]
+		for i, kid := range kids {
+			if kid == old {
+				kids = append(kids[:i:i], kids[i+1:]...)
+				break
+			}
+		}
+		if len(kids) == 0 {
+			delete(t.Children, old.Parent.SpanID)
+		} else {
+			t.Children[old.Parent.SpanID] = kids
+		}
+	}
+
+	t.Spans[id] = span
+	t.Children[span.Parent.SpanID] = append(t.Children[span.Parent.SpanID], span)
+}
+
+// isZeroID reports whether id is empty or all zeros, i.e. not an ID at all.
+func isZeroID(id string) bool {
+	return strings.Trim(id, "0") == ""
+}
+
+// Missing returns the parent SpanIDs that are referenced but not present.
+func (t *Trace) Missing() []string {
+	missing := []string{}
+	for parent := range t.Children {
+		if _, ok := t.Spans[parent]; !ok {
+			missing = append(missing, parent)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// empty returns a Trace with none of t's spans, but what else t knows about
+// its input, for methods that return a changed copy of t.
+func (t *Trace) empty() *Trace {
+	out := NewTrace()
+	out.Duplicates = t.Duplicates
+	if t.duplicates != nil {
+		out.duplicates = maps.Clone(t.duplicates)
+	}
+	return out
+}
+
+// Split groups the spans in t by TraceID.
+func (t *Trace) Split() map[string]*Trace {
+	traces := map[string]*Trace{}
+	for _, span := range t.Spans {
+		tid := span.SpanContext.TraceID
+		tt, ok := traces[tid]
+		if !ok {
+			tt = NewTrace()
+			if n := t.duplicates[tid]; n != 0 {
+				tt.Duplicates = n
+				tt.duplicates = map[string]int{tid: n}
+			}
+			traces[tid] = tt
+		}
+		tt.Add(span)
+	}
+	return traces
+}
+
+// Summary describes a trace for listings.
+type Summary struct {
+	TraceID string
+	// Name and Service are the earliest top-level span's.
+	Name     string
+	Service  string
+	Start    time.Time
+	Duration time.Duration
+	Spans    int
+	Errors   int
+
+	// Database is how long the trace spent waiting on database calls.
+	Database time.Duration
+}
+
+// Summarize describes t, which is assumed to hold a single trace.
+func (t *Trace) Summarize() Summary {
+	s := Summary{
+		Spans: len(t.Spans),
+	}
+
+	var first *Span
+	var end time.Time
+	for _, span := range t.Spans {
+		if s.TraceID == "" {
+			s.TraceID = span.SpanContext.TraceID
+		}
+		if span.IsError() {
+			s.Errors++
+		}
+		if s.Start.IsZero() || span.StartTime.Before(s.Start) {
+			s.Start = span.StartTime
+		}
+		if span.EndTime.After(end) {
+			end = span.EndTime
+		}
+		if _, ok := t.Spans[span.Parent.SpanID]; ok {
+			continue
+		}
+		if first == nil || span.StartTime.Before(first.StartTime) || span.StartTime.Equal(first.StartTime) && span.Name < first.Name {
+			first = span
+		}
+	}
+
+	if first != nil {
+		s.Name = first.Name
+		s.Service = first.Service()
+	}
+	if !s.Start.IsZero() {
+		s.Duration = end.Sub(s.Start)
+	}
+	s.Database = dbTime(t.Spans)
+
+	return s
+}
+
+// Sorted returns every span in t ordered by start time, then SpanID.
+func (t *Trace) Sorted() []*Span {
+	spans := make([]*Span, 0, len(t.Spans))
+	for _, span := range t.Spans {
+		spans = append(spans, span)
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].StartTime.Equal(spans[j].StartTime) {
+			return spans[i].SpanContext.SpanID < spans[j].SpanContext.SpanID
+		}
+		return spans[i].StartTime.Before(spans[j].StartTime)
+	})
+	return spans
+}
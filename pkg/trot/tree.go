@@ -0,0 +1,326 @@
+package trot
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+type Node struct {
+	Span     *Span
+	Children []*Node
+}
+
+// Tree builds the tree under a synthetic root named name with SpanID id.
+func (t *Trace) Tree(name, id string) *Node {
+	root := &Node{
+		Span: &Span{
+			Name: name,
+			SpanContext: SpanContext{
+				SpanID: id,
+			},
+		},
+	}
+
+	buildTree(root, t.Children, t.Spans)
+
+	return root
+}
+
+// buildTree uses an explicit stack rather than recursion, since instrumented
+// recursive functions can nest spans arbitrarily deep.
+func buildTree(root *Node, children map[string][]*Span, spans map[string]*Span) {
+	// Visit in pre-order, so walking order backwards finishes every node's children before it.
+	order := []*Node{}
+	seen := map[string]bool{}
+	stack := []*Node{root}
+	for len(stack) != 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		// Duplicate SpanIDs can make a cycle; only expand each one once.
+		id := node.Span.SpanContext.SpanID
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		order = append(order, node)
+
+		kids, ok := children[id]
+		if !ok {
+			continue
+		}
+		node.Children = make([]*Node, len(kids))
+		for i, kid := range kids {
+			node.Children[i] = &Node{
+				Span: kid,
+			}
+		}
+		stack = append(stack, node.Children...)
+	}
+
+	for i := len(order) - 1; i >= 0; i-- {
+		node := order[i]
+		if len(node.Children) == 0 {
+			continue
+		}
+
+		// Siblings that start together are in whatever order the input had
+		// them in, which needn't be the same twice, so break ties.
+		slices.SortFunc(node.Children, func(a, b *Node) int {
+			if c := a.Span.StartTime.Compare(b.Span.StartTime); c != 0 {
+				return c
+			}
+			if c := strings.Compare(a.Span.Name, b.Span.Name); c != 0 {
+				return c
+			}
+			return strings.Compare(a.Span.SpanContext.SpanID, b.Span.SpanContext.SpanID)
+		})
+
+		if node.Span.StartTime == node.Span.EndTime {
+			node.Span.StartTime = node.Children[0].Span.StartTime
+
+			last := slices.MaxFunc(node.Children, func(a, b *Node) int {
+				return a.Span.EndTime.Compare(b.Span.EndTime)
+			})
+			node.Span.EndTime = last.Span.EndTime
+		}
+	}
+}
+
+// Sections splits n's children into runs whose times overlap, each
+// under a copy of n spanning only that run, so top-level spans that
+// happen far apart (like the steps of an async workflow) aren't squeezed
+// onto one time scale. It returns just n if they all overlap.
+func (n *Node) Sections() []*Node {
+	var sections []*Node
+	var end time.Time
+	for _, kid := range n.Children {
+		if len(sections) == 0 || kid.Span.StartTime.After(end) {
+			span := *n.Span
+			span.StartTime, span.EndTime = kid.Span.StartTime, kid.Span.EndTime
+			sections = append(sections, &Node{Span: &span})
+			end = kid.Span.EndTime
+		}
+		last := sections[len(sections)-1]
+		last.Children = append(last.Children, kid)
+		if kid.Span.EndTime.After(end) {
+			end = kid.Span.EndTime
+			last.Span.EndTime = end
+		}
+	}
+	if len(sections) <= 1 {
+		return []*Node{n}
+	}
+	return sections
+}
+
+func (n *Node) Duration() time.Duration {
+	return n.Span.Duration()
+}
+
+// Walk calls fn for n and every descendant in pre-order.
+// Returning false from fn skips that node's children.
+func (n *Node) Walk(fn func(node *Node, depth int) bool) {
+	type frame struct {
+		node  *Node
+		depth int
+	}
+	stack := []frame{{n, 0}}
+	for len(stack) != 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if !fn(f.node, f.depth) {
+			continue
+		}
+		// Push in reverse so the first child is visited first.
+		for i := len(f.node.Children) - 1; i >= 0; i-- {
+			stack = append(stack, frame{f.node.Children[i], f.depth + 1})
+		}
+	}
+}
+
+// Find returns every node under n (including n) that matches pred, in pre-order.
+func (n *Node) Find(pred func(*Node) bool) []*Node {
+	found := []*Node{}
+	n.Walk(func(node *Node, _ int) bool {
+		if pred(node) {
+			found = append(found, node)
+		}
+		return true
+	})
+	return found
+}
+
+// Filter returns a copy of the tree containing only nodes that match pred and their ancestors.
+// It returns nil if nothing matches.
+func (n *Node) Filter(pred func(*Node) bool) *Node {
+	order := []*Node{}
+	n.Walk(func(node *Node, _ int) bool {
+		order = append(order, node)
+		return true
+	})
+
+	// Backwards from pre-order, every node's children are filtered before it is.
+	kept := map[*Node]*Node{}
+	for i := len(order) - 1; i >= 0; i-- {
+		node := order[i]
+
+		var kids []*Node
+		for _, child := range node.Children {
+			if kid, ok := kept[child]; ok {
+				kids = append(kids, kid)
+			}
+		}
+
+		if len(kids) == 0 && !pred(node) {
+			continue
+		}
+
+		kept[node] = &Node{
+			Span:     node.Span,
+			Children: kids,
+		}
+	}
+
+	return kept[n]
+}
+
+// CriticalPath follows, from n down, the child that finished last at each level,
+// i.e. the chain of spans that n's end time was waiting on.
+func (n *Node) CriticalPath() []*Node {
+	path := []*Node{n}
+	for len(n.Children) != 0 {
+		n = slices.MaxFunc(n.Children, func(a, b *Node) int {
+			return a.Span.EndTime.Compare(b.Span.EndTime)
+		})
+		path = append(path, n)
+	}
+	return path
+}
+
+// SelfTime is the part of n's duration not covered by any of its children.
+func (n *Node) SelfTime() time.Duration {
+	start, end := n.Span.StartTime, n.Span.EndTime
+
+	kids := slices.Clone(n.Children)
+	slices.SortFunc(kids, func(a, b *Node) int {
+		return a.Span.StartTime.Compare(b.Span.StartTime)
+	})
+
+	var covered time.Duration
+	cursor := start
+	for _, kid := range kids {
+		ks, ke := kid.Span.StartTime, kid.Span.EndTime
+		if ks.Before(cursor) {
+			ks = cursor
+		}
+		if ke.After(end) {
+			ke = end
+		}
+		if ke.After(ks) {
+			covered += ke.Sub(ks)
+			cursor = ke
+		}
+	}
+
+	return n.Duration() - covered
+}
+
+// Descendants summarizes everything beneath a node.
+type Descendants struct {
+	// Spans is how many descendants there are.
+	Spans int
+
+	// Time is the sum of the children's durations, which can be more than
+	// the parent's if they ran concurrently.
+	Time time.Duration
+
+	// Errors is how many descendants have an error status.
+	Errors int
+}
+
+// Descendants summarizes what's beneath every node under n that has children.
+func (n *Node) Descendants() map[*Node]Descendants {
+	parents := []*Node{}
+	n.Walk(func(node *Node, _ int) bool {
+		if len(node.Children) != 0 {
+			parents = append(parents, node)
+		}
+		return true
+	})
+
+	// In reverse pre-order, every node's children are done before it.
+	stats := make(map[*Node]Descendants, len(parents))
+	for i := len(parents) - 1; i >= 0; i-- {
+		node := parents[i]
+		var d Descendants
+		for _, kid := range node.Children {
+			below := stats[kid]
+			d.Spans += 1 + below.Spans
+			d.Errors += below.Errors
+			if kid.Span.IsError() {
+				d.Errors++
+			}
+			d.Time += kid.Duration()
+		}
+		stats[node] = d
+	}
+	return stats
+}
+
+// Callee returns the server span that handled n, if n is a client span, like
+// the two halves of an RPC: its only server child, or if there are several
+// (e.g. retries), the only one with the same name.
+func (n *Node) Callee() *Node {
+	if n.Span.SpanKind != KindClient {
+		return nil
+	}
+	var only, named *Node
+	servers, names := 0, 0
+	for _, kid := range n.Children {
+		if kid.Span.SpanKind != KindServer {
+			continue
+		}
+		servers++
+		only = kid
+		if kid.Span.Name == n.Span.Name {
+			names++
+			named = kid
+		}
+	}
+	if servers == 1 {
+		return only
+	}
+	if names == 1 {
+		return named
+	}
+	return nil
+}
+
+// Network is how much of a call from n to its Callee was spent outside the
+// server, i.e. on the network, in queues, and in client libraries.
+func (n *Node) Network() (time.Duration, bool) {
+	callee := n.Callee()
+	if callee == nil {
+		return 0, false
+	}
+	// Clocks on different hosts can disagree.
+	return max(0, n.Duration()-callee.Duration()), true
+}
+
+// Roots builds a tree for every span whose parent is not in t, ordered by start time.
+func (t *Trace) Roots() []*Node {
+	roots := []*Node{}
+	for _, span := range t.Sorted() {
+		if _, ok := t.Spans[span.Parent.SpanID]; ok {
+			continue
+		}
+		node := &Node{Span: span}
+		buildTree(node, t.Children, t.Spans)
+		roots = append(roots, node)
+	}
+	return roots
+}
@@ -0,0 +1,491 @@
+package trot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func span(id, parent, name string, start, end int) *Span {
+	s := &Span{
+		Name:      name,
+		StartTime: epoch.Add(time.Duration(start) * time.Millisecond),
+		EndTime:   epoch.Add(time.Duration(end) * time.Millisecond),
+	}
+	s.SpanContext.SpanID = id
+	s.Parent.SpanID = parent
+	return s
+}
+
+// testTrace is:
+//
+//	a [0, 100]
+//	  b [10, 40]
+//	    d [20, 30]
+//	  c [30, 90]
+//	    e [50, 60]
+func testTrace() *Trace {
+	t := NewTrace()
+	for _, s := range []*Span{
+		span("a", RootID, "a", 0, 100),
+		span("c", "a", "c", 30, 90),
+		span("b", "a", "b", 10, 40),
+		span("d", "b", "d", 20, 30),
+		span("e", "c", "db.query", 50, 60),
+	} {
+		t.Add(s)
+	}
+	return t
+}
+
+func names(nodes []*Node) string {
+	s := []string{}
+	for _, n := range nodes {
+		s = append(s, n.Span.Name)
+	}
+	return strings.Join(s, ",")
+}
+
+func TestWalk(t *testing.T) {
+	root := testTrace().Tree("root", RootID)
+
+	got := []string{}
+	root.Walk(func(n *Node, depth int) bool {
+		got = append(got, strings.Repeat(".", depth)+n.Span.Name)
+		return n.Span.Name != "b"
+	})
+
+	if want := "root,.a,..b,..c,...db.query"; strings.Join(got, ",") != want {
+		t.Errorf("Walk() = %s, want %s", strings.Join(got, ","), want)
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := testTrace().Tree("root", RootID)
+
+	got := root.Find(func(n *Node) bool {
+		return len(n.Children) == 0
+	})
+	if want := "d,db.query"; names(got) != want {
+		t.Errorf("Find() = %s, want %s", names(got), want)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	root := testTrace().Tree("root", RootID)
+
+	filtered := root.Filter(func(n *Node) bool {
+		return strings.HasPrefix(n.Span.Name, "db.")
+	})
+	if filtered == nil {
+		t.Fatal("Filter() = nil")
+	}
+	got := filtered.Find(func(*Node) bool { return true })
+	if want := "root,a,c,db.query"; names(got) != want {
+		t.Errorf("Filter() = %s, want %s", names(got), want)
+	}
+
+	if none := root.Filter(func(*Node) bool { return false }); none != nil {
+		t.Errorf("Filter() = %v, want nil", none)
+	}
+}
+
+func TestCriticalPath(t *testing.T) {
+	root := testTrace().Tree("root", RootID)
+
+	if got, want := names(root.CriticalPath()), "root,a,c,db.query"; got != want {
+		t.Errorf("CriticalPath() = %s, want %s", got, want)
+	}
+}
+
+func TestSelfTime(t *testing.T) {
+	root := testTrace().Tree("root", RootID)
+	a := root.Children[0]
+
+	// b and c overlap during [30, 40], so children cover [10, 90].
+	if got, want := a.SelfTime(), 20*time.Millisecond; got != want {
+		t.Errorf("SelfTime(a) = %s, want %s", got, want)
+	}
+	if got, want := a.Children[1].SelfTime(), 50*time.Millisecond; got != want {
+		t.Errorf("SelfTime(c) = %s, want %s", got, want)
+	}
+}
+
+func TestDeepTrace(t *testing.T) {
+	const depth = 100000
+
+	tr := NewTrace()
+	parent := RootID
+	for i := 0; i < depth; i++ {
+		// Not from 0, which as a parent means none.
+		id := strconv.Itoa(i + 1)
+		tr.Add(span(id, parent, "recurse", i, 2*depth-i))
+		parent = id
+	}
+
+	root := tr.Tree("root", RootID)
+
+	n := 0
+	root.Walk(func(_ *Node, d int) bool {
+		n++
+		return true
+	})
+	if n != depth+1 {
+		t.Errorf("Walk visited %d nodes, want %d", n, depth+1)
+	}
+
+	if got := len(root.Filter(func(n *Node) bool { return len(n.Children) == 0 }).CriticalPath()); got != depth+1 {
+		t.Errorf("Filter kept %d levels, want %d", got, depth+1)
+	}
+
+	var sb strings.Builder
+	if err := RenderHTML(&sb, tr, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(sb.String(), "<summary aria-expanded"); got != maxDepth {
+		t.Errorf("rendered %d levels, want %d", got, maxDepth)
+	}
+	if want := fmt.Sprintf("(%d nested spans not shown)", depth-maxDepth); !strings.Contains(sb.String(), want) {
+		t.Errorf("missing %q", want)
+	}
+}
+
+func TestNetwork(t *testing.T) {
+	tr := NewTrace()
+	client, server := span("a", RootID, "call", 0, 100), span("b", "a", "handle", 20, 70)
+	client.SpanKind, server.SpanKind = KindClient, KindServer
+	tr.Add(client)
+	tr.Add(server)
+
+	call := tr.Tree("root", RootID).Children[0]
+	if got, ok := call.Network(); !ok || got != 50*time.Millisecond {
+		t.Errorf("Network() = %v, %v, want 50ms, true", got, ok)
+	}
+
+	// Client-side work, like resolving the host, doesn't get in the way.
+	tr.Add(span("c", "a", "dns", 0, 10))
+	call = tr.Tree("root", RootID).Children[0]
+	if callee := call.Callee(); callee == nil || callee.Span.Name != "handle" {
+		t.Errorf("Callee() = %v, want handle", callee)
+	}
+
+	// Of several servers, the one with the client's name.
+	retry := span("d", "a", "call", 75, 95)
+	retry.SpanKind = KindServer
+	tr.Add(retry)
+	call = tr.Tree("root", RootID).Children[0]
+	if got, ok := call.Network(); !ok || got != 80*time.Millisecond {
+		t.Errorf("Network() = %v, %v, want 80ms, true", got, ok)
+	}
+
+	retry.Name = "handle"
+	if callee := call.Callee(); callee != nil {
+		t.Errorf("Callee() = %s, want none of two servers named like each other", callee.Span.Name)
+	}
+
+	server.SpanKind, retry.SpanKind = KindInternal, KindInternal
+	if _, ok := call.Network(); ok {
+		t.Errorf("Network() found a call to an internal span")
+	}
+}
+
+func TestHandoffs(t *testing.T) {
+	tr := NewTrace()
+	publish, process, child := span("p", RootID, "publish", 0, 10), span("c", "x", "process", 40, 50), span("k", "p", "consume", 20, 30)
+	publish.SpanKind, process.SpanKind, child.SpanKind = KindProducer, KindConsumer, KindConsumer
+	process.Parent.SpanID = RootID
+	process.Links = rawJSON([]link{{SpanContext: publish.SpanContext}})
+	for _, s := range []*Span{publish, process, child} {
+		tr.Add(s)
+	}
+
+	handoffs := tr.Tree("root", RootID).Handoffs()
+	if got := handoffs[process]; got.Producer != publish || got.Queued() != 30*time.Millisecond {
+		t.Errorf("linked handoff = %v, queued %v", got.Producer, got.Queued())
+	}
+	if got := handoffs[child]; got.Producer != publish || got.Queued() != 10*time.Millisecond {
+		t.Errorf("child handoff = %v, queued %v", got.Producer, got.Queued())
+	}
+}
+
+func TestHideScopes(t *testing.T) {
+	tr := testTrace()
+	tr.Spans["b"].InstrumentationLibrary.Name = "noisy"
+	tr.Spans["c"].InstrumentationLibrary.Name = "noisy"
+
+	got := tr.HideScopes(func(scope string) bool {
+		return scope == "noisy"
+	}).Tree("root", RootID)
+
+	// d and e move up to a.
+	if got, want := names(got.Children[0].Children), "d,db.query"; got != want {
+		t.Errorf("a's children = %s, want %s", got, want)
+	}
+	if tr.Spans["d"].Parent.SpanID != "b" {
+		t.Errorf("HideScopes modified its input")
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	tr := testTrace()
+	for id, worker := range map[string]string{"b": "1", "c": "2", "d": "1", "e": "1"} {
+		tr.Spans[id].Attributes = rawJSON([]KeyValue{keyValue("worker", worker)})
+	}
+	key, err := ParseGroupBy("attr(worker)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	tr.Tree("root", RootID).GroupBy(key).Walk(func(n *Node, depth int) bool {
+		got = append(got, fmt.Sprintf("%s%s[%d,%d]", strings.Repeat(".", depth), n.Span.Name,
+			n.Span.StartTime.Sub(epoch).Milliseconds(), n.Span.EndTime.Sub(epoch).Milliseconds()))
+		return true
+	})
+	// d stays under b, since they're on the same worker; e gets its own lane under c.
+	want := []string{
+		"root[0,100]",
+		".a[0,100]",
+		"..worker=1[10,40]",
+		"...b[10,40]",
+		"....d[20,30]",
+		"..worker=2[30,90]",
+		"...c[30,90]",
+		"....worker=1[50,60]",
+		".....db.query[50,60]",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("GroupBy:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if _, err := ParseGroupBy("thread.id"); err == nil {
+		t.Error("ParseGroupBy(thread.id) succeeded")
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	tr := testTrace()
+	tr.Spans["e"].Status.Code = "Error"
+	root := tr.Tree("root", RootID)
+
+	stats := root.Descendants()
+	a := root.Children[0]
+	if got, want := stats[a], (Descendants{Spans: 4, Time: 90 * time.Millisecond, Errors: 1}); got != want {
+		t.Errorf("Descendants()[a] = %+v, want %+v", got, want)
+	}
+	if _, ok := stats[a.Children[0].Children[0]]; ok {
+		t.Errorf("Descendants() has an entry for leaf d")
+	}
+}
+
+func TestSynthesizeRoots(t *testing.T) {
+	tr := NewTrace()
+	for _, s := range []*Span{
+		span("b", "missing", "b", 10, 40),
+		span("c", "gone", "c", 30, 90),
+		span("d", "b", "d", 20, 30),
+	} {
+		tr.Add(s)
+	}
+
+	got := tr.SynthesizeRoots()
+	root := got.Tree("root", RootID).Children
+	if len(root) != 1 || root[0].Span.Name != "synthetic root" {
+		t.Fatalf("roots = %s, want synthetic root", names(root))
+	}
+	if got, want := names(root[0].Children), "b,c"; got != want {
+		t.Errorf("synthetic root's children = %s, want %s", got, want)
+	}
+	if start, end := root[0].Span.StartTime, root[0].Span.EndTime; !start.Equal(epoch.Add(10*time.Millisecond)) || !end.Equal(epoch.Add(90*time.Millisecond)) {
+		t.Errorf("synthetic root covers %v to %v, want 10ms to 90ms", start.Sub(epoch), end.Sub(epoch))
+	}
+	if tr.Spans["b"].Parent.SpanID != "missing" {
+		t.Errorf("SynthesizeRoots modified its input")
+	}
+
+	// Traces that have a root are left alone.
+	if got := testTrace().SynthesizeRoots(); len(got.Spans) != 5 {
+		t.Errorf("SynthesizeRoots added a root to a trace that has one")
+	}
+}
+
+func TestRenumber(t *testing.T) {
+	// The same spans with different IDs, added in a different order, and
+	// with siblings that start together.
+	render := func(ids map[string]string, order []int) string {
+		spans := []*Span{
+			span(ids["a"], RootID, "a", 0, 100),
+			span(ids["b"], ids["a"], "b", 10, 40),
+			span(ids["c"], ids["a"], "c", 10, 90),
+			span(ids["d"], ids["b"], "d", 20, 30),
+			span(ids["e"], ids["x"], "orphan", 50, 60),
+		}
+		tr := NewTrace()
+		for _, i := range order {
+			spans[i].SpanContext.TraceID = ids["t"]
+			tr.Add(spans[i])
+		}
+		var buf strings.Builder
+		if err := RenderHTML(&buf, tr.Renumber(), Options{}); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	one := render(map[string]string{"t": "4bf92f3577b34da6a3ce929d0e0e4736", "a": "00f067aa0ba902b8", "b": "1111111111111111", "c": "2222222222222222", "d": "3333333333333333", "e": "4444444444444444", "x": "5555555555555555"}, []int{0, 1, 2, 3, 4})
+	two := render(map[string]string{"t": "0af7651916cd43dd8448eb211c80319c", "a": "9999999999999999", "b": "8888888888888888", "c": "7777777777777777", "d": "6666666666666666", "e": "b7ad6b7169203331", "x": "5555555555555555"}, []int{4, 3, 2, 1, 0})
+	if one != two {
+		t.Errorf("renumbered pages differ:\n%s\n\n%s", one, two)
+	}
+	if !strings.Contains(one, "0000000000000001") || strings.Contains(one, "00f067aa0ba902b8") {
+		t.Errorf("spans not renumbered:\n%s", one)
+	}
+}
+
+func TestInferRoots(t *testing.T) {
+	tr := NewTrace()
+	tr.Add(span("a", "", "empty", 0, 10))
+	tr.Add(span("b", "00000000000000000000000000000000", "zeros", 0, 10))
+	tr.Add(span("c", "a", "child", 0, 5))
+	tr.Add(span("d", "x", "orphan", 0, 5))
+
+	if got, want := names(tr.Tree("root", RootID).Children), "empty,zeros"; got != want {
+		t.Errorf("roots = %s, want %s", got, want)
+	}
+	if got, want := names(tr.PromoteOrphans().Tree("root", RootID).Children), "empty,orphan,zeros"; got != want {
+		t.Errorf("roots with orphans = %s, want %s", got, want)
+	}
+	if tr.Spans["d"].Parent.SpanID != "x" {
+		t.Errorf("PromoteOrphans changed the original span")
+	}
+}
+
+func TestOrphanPolicies(t *testing.T) {
+	tr := testTrace()
+	tr.Add(span("f", "x", "orphan", 40, 70))
+	tr.Add(span("g", "x", "sibling", 30, 50))
+	tr.Add(span("h", "f", "grandchild", 45, 55))
+
+	root := tr.AddPlaceholders().Tree("root", RootID)
+	if got, want := names(root.Children), "a,unknown parent"; got != want {
+		t.Fatalf("roots with placeholders = %s, want %s", got, want)
+	}
+	p := root.Children[1]
+	if got, want := names(p.Children), "sibling,orphan"; got != want {
+		t.Errorf("placeholder's children = %s, want %s", got, want)
+	}
+	if start, end := p.Span.StartTime.Sub(epoch), p.Span.EndTime.Sub(epoch); start != 30*time.Millisecond || end != 70*time.Millisecond {
+		t.Errorf("placeholder covers %v to %v, want 30ms to 70ms", start, end)
+	}
+
+	if got := tr.DropOrphans(); len(got.Spans) != 5 || got.Spans["h"] != nil {
+		t.Errorf("DropOrphans kept %d spans, want testTrace's 5", len(got.Spans))
+	}
+}
+
+func TestSections(t *testing.T) {
+	tr := NewTrace()
+	tr.Add(span("a", RootID, "a", 0, 100))
+	tr.Add(span("b", RootID, "b", 50, 200))
+	tr.Add(span("c", RootID, "c", 10000, 10100))
+	tr.Add(span("d", "c", "d", 10000, 10050))
+
+	root := tr.Tree("root", RootID)
+	sections := root.Sections()
+	if len(sections) != 2 {
+		t.Fatalf("got %d sections, want 2", len(sections))
+	}
+	if got, want := names(sections[0].Children), "a,b"; got != want {
+		t.Errorf("first section = %s, want %s", got, want)
+	}
+	if got, want := sections[1].Duration(), 100*time.Millisecond; got != want {
+		t.Errorf("second section lasts %s, want %s", got, want)
+	}
+	if got, want := sectionName(sections[1], root, Options{}), "c · +10s"; got != want {
+		t.Errorf("sectionName() = %q, want %q", got, want)
+	}
+	late := NewTrace()
+	late.Add(span("a", RootID, "a", 0, 100))
+	late.Add(span("c", RootID, "c", 10123, 10200))
+	lateRoot := late.Tree("root", RootID)
+	for precision, want := range map[int]string{0: "c · +10.1s", 5: "c · +10.123s"} {
+		if got := sectionName(lateRoot.Sections()[1], lateRoot, Options{Precision: precision}); got != want {
+			t.Errorf("sectionName() with precision %d = %q, want %q", precision, got, want)
+		}
+	}
+
+	tr.Add(span("e", RootID, "e", 0, 20000))
+	if got := len(tr.Tree("root", RootID).Sections()); got != 1 {
+		t.Errorf("got %d sections of overlapping spans, want 1", got)
+	}
+}
+
+func TestMetas(t *testing.T) {
+	tr := testTrace()
+	tr.Spans["e"].Status.Code = "Error"
+	tr.Spans["e"].Resource = []KeyValue{keyValue("service.name", "db")}
+
+	metas := tr.Metas()
+	if len(metas) != 1 {
+		t.Fatalf("got %d metas, want 1", len(metas))
+	}
+	m := metas[0]
+	if m.Name != "a" || m.Duration != 100*time.Millisecond || m.Spans != 5 || m.Errors != 1 {
+		t.Errorf("Meta = %+v", m)
+	}
+	if got, want := fmt.Sprint(m.Services), "[db unknown]"; got != want {
+		t.Errorf("Services = %s, want %s", got, want)
+	}
+	path := []string{}
+	for _, s := range m.CriticalPath {
+		path = append(path, s.Name)
+	}
+	if got, want := strings.Join(path, ","), "a,c,db.query"; got != want {
+		t.Errorf("CriticalPath = %s, want %s", got, want)
+	}
+}
+
+func TestPageName(t *testing.T) {
+	if got := PageName("4bf92f3577b34da6a3ce929d0e0e4736"); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("PageName(hex) = %q", got)
+	}
+	for _, id := range []string{"../evil", "a/b", `a\b`, "..", ""} {
+		got := PageName(id)
+		if strings.ContainsAny(got, `/\.`) || !strings.HasPrefix(got, "trace-") {
+			t.Errorf("PageName(%q) = %q", id, got)
+		}
+	}
+}
+
+func TestInterning(t *testing.T) {
+	s := span("a", RootID, "a", 0, 100)
+	s.Attributes = json.RawMessage("[\n  {\n    \"Key\": \"k\",\n    \"Value\": {\"Type\": \"STRING\", \"Value\": \"v w\"}\n  }\n]")
+	tr := NewTrace()
+	tr.Add(s)
+	if got, want := string(s.Attributes), `[{"Key":"k","Value":{"Type":"STRING","Value":"v w"}}]`; got != want {
+		t.Errorf("Attributes = %s, want %s", got, want)
+	}
+
+	// Streamed traces don't hold on to each other's strings.
+	var traces []*Trace
+	st := NewStreamer(func(t *Trace) error {
+		traces = append(traces, t)
+		return nil
+	})
+	for _, tid := range []string{"1", "2"} {
+		s := span("a"+tid, RootID, "a", 0, 100)
+		s.SpanContext.TraceID = tid
+		st.Add(s)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != 2 || traces[0].in == traces[1].in {
+		t.Errorf("streamed %d traces sharing an interner", len(traces))
+	}
+}
@@ -0,0 +1,65 @@
+package trot
+
+import (
+	"sort"
+	"strings"
+)
+
+// Truncation is a span that reported more children than the input contains.
+type Truncation struct {
+	Span     *Span
+	Path     []string
+	Expected int
+	Found    int
+}
+
+// Truncations returns, by TraceID, the spans whose ChildSpanCount exceeds their children in t.
+func (t *Trace) Truncations() map[string][]Truncation {
+	spans, children := t.Spans, t.Children
+
+	traces := map[string][]Truncation{}
+
+	for id, span := range spans {
+		found := len(children[id])
+		if span.ChildSpanCount <= found {
+			continue
+		}
+
+		tid := span.SpanContext.TraceID
+		traces[tid] = append(traces[tid], Truncation{
+			Span:     span,
+			Path:     spanPath(span, spans),
+			Expected: span.ChildSpanCount,
+			Found:    found,
+		})
+	}
+
+	for _, ts := range traces {
+		sort.Slice(ts, func(i, j int) bool {
+			return strings.Join(ts[i].Path, "/") < strings.Join(ts[j].Path, "/")
+		})
+	}
+
+	return traces
+}
+
+// spanPath returns the names from the top-most known ancestor down to span.
+func spanPath(span *Span, spans map[string]*Span) []string {
+	path := []string{}
+	seen := map[string]struct{}{}
+	for span != nil {
+		if _, ok := seen[span.SpanContext.SpanID]; ok {
+			break
+		}
+		seen[span.SpanContext.SpanID] = struct{}{}
+
+		path = append(path, span.Name)
+		span = spans[span.Parent.SpanID]
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path
+}
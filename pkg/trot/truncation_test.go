@@ -0,0 +1,46 @@
+package trot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTruncations(t *testing.T) {
+	tr := NewTrace()
+	for _, s := range []*Span{
+		tspan("1", "a", RootID, 0, 100, 3),
+		tspan("1", "b", "a", 10, 40, 1),
+		tspan("1", "c", "a", 50, 90, 2),
+		tspan("1", "d", "c", 60, 70, 0),
+		tspan("2", "x", RootID, 0, 10, 1),
+		tspan("2", "y", "x", 1, 9, 0),
+		// Its parent is missing, so its path starts with it.
+		tspan("2", "z", "gone", 1, 9, 4),
+	} {
+		tr.Add(s)
+	}
+
+	got := []string{}
+	for _, tid := range []string{"1", "2"} {
+		for _, tc := range tr.Truncations()[tid] {
+			got = append(got, fmt.Sprintf("%s %s %d/%d", tid, strings.Join(tc.Path, "/"), tc.Found, tc.Expected))
+		}
+	}
+	want := []string{
+		"1 a 2/3",
+		"1 a/b 0/1",
+		"1 a/c 1/2",
+		"2 z 0/4",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Truncations() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if n := len(tr.Truncations()); n != 2 {
+		t.Errorf("Truncations() has %d traces, want 2", n)
+	}
+
+	if got := testTrace().Truncations(); len(got) != 0 {
+		t.Errorf("Truncations() of a complete trace = %v", got)
+	}
+}
@@ -0,0 +1,212 @@
+package trot
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Problem is something wrong with the input that trot works around, or can't.
+type Problem struct {
+	// Check names what's wrong: parse, missing-id, malformed-id, bad-timestamp,
+	// duplicate, dangling-parent, cycle, or no-root.
+	Check    string `json:"check"`
+	Severity string `json:"severity"` // error or warning
+	File     string `json:"file,omitempty"`
+	TraceID  string `json:"trace_id,omitempty"`
+	SpanID   string `json:"span_id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Report is what a Validator found.
+type Report struct {
+	Spans    int       `json:"spans"`
+	Traces   int       `json:"traces"`
+	Errors   int       `json:"errors"`
+	Warnings int       `json:"warnings"`
+	Problems []Problem `json:"problems"`
+}
+
+// Validator collects every span decoded, including the duplicates a Trace
+// would silently replace, to check them for the mistakes exporters make.
+type Validator struct {
+	spans    []*Span
+	problems []Problem
+}
+
+// NewValidator returns an empty Validator.
+func NewValidator() *Validator {
+	return &Validator{}
+}
+
+// Decode reads every span in r, as ParseFormat would, reporting a failure to
+// parse as a problem with file rather than returning it.
+func (v *Validator) Decode(r io.Reader, format, file string) {
+	if err := decode(r, format, &Trace{sink: v.add}); err != nil {
+		v.problems = append(v.problems, Problem{Check: "parse", Severity: "error", File: file, Message: err.Error()})
+	}
+}
+
+func (v *Validator) add(span *Span) {
+	v.spans = append(v.spans, span)
+}
+
+// isHexID reports whether id is n lowercase or uppercase hex digits.
+func isHexID(id string, n int) bool {
+	if len(id) != n {
+		return false
+	}
+	for _, c := range id {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// Report checks the spans decoded so far.
+func (v *Validator) Report() Report {
+	problems := append([]Problem{}, v.problems...)
+	problem := func(span *Span, check, severity, format string, args ...any) {
+		problems = append(problems, Problem{
+			Check:    check,
+			Severity: severity,
+			TraceID:  span.SpanContext.TraceID,
+			SpanID:   span.SpanContext.SpanID,
+			Name:     span.Name,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	type key struct{ tid, sid string }
+	seen := map[key]int{}
+	traces := map[string]map[string]*Span{}
+	for _, span := range v.spans {
+		tid, sid := span.SpanContext.TraceID, span.SpanContext.SpanID
+		switch {
+		case isZeroID(tid):
+			problem(span, "missing-id", "error", "span has no TraceID")
+		case !isHexID(tid, 32) && !isHexID(tid, 16):
+			problem(span, "malformed-id", "warning", "TraceID %q is not 32 hex digits", tid)
+		}
+		switch {
+		case isZeroID(sid):
+			problem(span, "missing-id", "error", "span has no SpanID")
+		case !isHexID(sid, 16):
+			problem(span, "malformed-id", "warning", "SpanID %q is not 16 hex digits", sid)
+		}
+
+		switch start, end := span.StartTime, span.EndTime; {
+		case start.IsZero():
+			problem(span, "bad-timestamp", "error", "span has no start time")
+		case end.IsZero():
+			problem(span, "bad-timestamp", "error", "span has no end time")
+		case end.Before(start):
+			problem(span, "bad-timestamp", "error", "span ends %s before it starts", FormatDuration(start.Sub(end), 3))
+		case start.Year() < 1980 || start.Year() > 2100:
+			// Usually seconds read as nanoseconds, or the other way around.
+			problem(span, "bad-timestamp", "warning", "span starts in %d", start.Year())
+		}
+
+		k := key{strings.ToLower(tid), strings.ToLower(sid)}
+		seen[k]++
+		if seen[k] == 2 {
+			problem(span, "duplicate", "warning", "SpanID appears more than once in trace; only the last is kept")
+		}
+		if traces[k.tid] == nil {
+			traces[k.tid] = map[string]*Span{}
+		}
+		traces[k.tid][k.sid] = span
+	}
+
+	tids := make([]string, 0, len(traces))
+	for tid := range traces {
+		tids = append(tids, tid)
+	}
+	sort.Strings(tids)
+	for _, tid := range tids {
+		spans := traces[tid]
+		sids := make([]string, 0, len(spans))
+		for sid := range spans {
+			sids = append(sids, sid)
+		}
+		sort.Strings(sids)
+
+		// Walk up from each span, colouring the spans on the way, until
+		// reaching a root or a span already walked: if it's on this walk,
+		// everything since it is a cycle.
+		const (
+			unwalked = iota
+			walking
+			walked
+		)
+		colour := map[string]int{}
+		cyclic := map[string]bool{}
+		for _, sid := range sids {
+			var path []string
+			for id := sid; colour[id] == unwalked; {
+				colour[id] = walking
+				path = append(path, id)
+				pid := strings.ToLower(spans[id].Parent.SpanID)
+				if _, ok := spans[pid]; !ok || pid == id {
+					// Roots, dangling parents, and spans that are their own
+					// parents are reported on their own.
+					break
+				}
+				if colour[pid] == walking {
+					for i := len(path) - 1; i >= 0; i-- {
+						cyclic[path[i]] = true
+						if path[i] == pid {
+							break
+						}
+					}
+					break
+				}
+				id = pid
+			}
+			for _, id := range path {
+				colour[id] = walked
+			}
+		}
+
+		roots := 0
+		for _, sid := range sids {
+			span := spans[sid]
+			parent := strings.ToLower(span.Parent.SpanID)
+			if isZeroID(parent) {
+				roots++
+				continue
+			}
+			if parent == sid {
+				problem(span, "cycle", "error", "span is its own parent")
+				continue
+			}
+			if _, ok := spans[parent]; !ok {
+				if span.Parent.Remote {
+					problem(span, "dangling-parent", "warning", "remote parent %s is not in the input", span.Parent.SpanID)
+				} else {
+					problem(span, "dangling-parent", "error", "parent %s is not in the input", span.Parent.SpanID)
+				}
+				continue
+			}
+			if cyclic[sid] {
+				problem(span, "cycle", "error", "span is its own ancestor")
+			}
+		}
+		if roots == 0 {
+			problems = append(problems, Problem{Check: "no-root", Severity: "warning", TraceID: tid, Message: "trace has no span without a parent"})
+		}
+	}
+
+	r := Report{Spans: len(v.spans), Traces: len(traces), Problems: problems}
+	for _, p := range problems {
+		if p.Severity == "error" {
+			r.Errors++
+		} else {
+			r.Warnings++
+		}
+	}
+	return r
+}
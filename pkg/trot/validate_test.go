@@ -0,0 +1,71 @@
+package trot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	input := `{"Timestamp":"2024-01-01T00:00:00Z","trace.trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","trace.span_id":"00f067aa0ba902b8","name":"GET /","duration_ms":20}
+{"Timestamp":"2024-01-01T00:00:00Z","trace.trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","trace.span_id":"00f067aa0ba902b8","name":"GET /","duration_ms":20}
+{"Timestamp":"2024-01-01T00:00:00Z","trace.trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","trace.span_id":"1111111111111111","trace.parent_id":"2222222222222222","name":"query","duration_ms":-2}
+{"Timestamp":"2024-01-01T00:00:00Z","trace.trace_id":"0af7651916cd43dd8448eb211c80319c","trace.span_id":"aaaaaaaaaaaaaaaa","trace.parent_id":"bbbbbbbbbbbbbbbb","name":"a","duration_ms":1}
+{"Timestamp":"2024-01-01T00:00:00Z","trace.trace_id":"0af7651916cd43dd8448eb211c80319c","trace.span_id":"bbbbbbbbbbbbbbbb","trace.parent_id":"aaaaaaaaaaaaaaaa","name":"b","duration_ms":1}
+`
+	v := NewValidator()
+	v.Decode(strings.NewReader(input), "", "in.json")
+	v.Decode(strings.NewReader("{not json"), "stdouttrace", "bad.json")
+	r := v.Report()
+
+	if r.Spans != 5 || r.Traces != 2 {
+		t.Errorf("got %d spans in %d traces, want 5 in 2", r.Spans, r.Traces)
+	}
+	got := []string{}
+	for _, p := range r.Problems {
+		got = append(got, p.Check+" "+p.Name+p.File)
+	}
+	want := []string{
+		"parse bad.json",
+		"duplicate GET /",
+		"bad-timestamp query",
+		"cycle a",
+		"cycle b",
+		"no-root ",
+		"dangling-parent query",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if r.Errors != 5 || r.Warnings != 2 {
+		t.Errorf("got %d errors and %d warnings, want 5 and 2", r.Errors, r.Warnings)
+	}
+}
+
+func TestValidateCycles(t *testing.T) {
+	line := func(sid, parent, name string) string {
+		return `{"Timestamp":"2024-01-01T00:00:00Z","trace.trace_id":"0af7651916cd43dd8448eb211c80319c","trace.span_id":"` + sid + `","trace.parent_id":"` + parent + `","name":"` + name + `","duration_ms":1}` + "\n"
+	}
+	// c leads into the cycle d, e, f without being on it; g is its own parent.
+	input := line("1000000000000000", "", "root") +
+		line("cccccccccccccccc", "dddddddddddddddd", "c") +
+		line("dddddddddddddddd", "ffffffffffffffff", "d") +
+		line("eeeeeeeeeeeeeeee", "dddddddddddddddd", "e") +
+		line("ffffffffffffffff", "eeeeeeeeeeeeeeee", "f") +
+		line("1111111111111111", "1111111111111111", "g")
+
+	v := NewValidator()
+	v.Decode(strings.NewReader(input), "", "in.json")
+	got := []string{}
+	for _, p := range v.Report().Problems {
+		got = append(got, p.Check+" "+p.Name+": "+p.Message)
+	}
+	want := []string{
+		"cycle g: span is its own parent",
+		"cycle d: span is its own ancestor",
+		"cycle e: span is its own ancestor",
+		"cycle f: span is its own ancestor",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
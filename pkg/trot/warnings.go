@@ -0,0 +1,78 @@
+package trot
+
+import (
+	"fmt"
+	"html"
+	"io"
+)
+
+// Warnings describes what's off about t's input that didn't stop it being
+// drawn, but could make the drawing misleading: missing parents, duplicate
+// spans, apparent clock skew, and data the exporter dropped.
+func (t *Trace) Warnings() []string {
+	warnings := []string{}
+	if len(t.Spans) == 0 {
+		return warnings
+	}
+	spans := func(n int) string {
+		return string(appendCount(nil, n, "span"))
+	}
+
+	if _, ok := t.Children[RootID]; !ok {
+		warnings = append(warnings, "no root span")
+	}
+	orphans, parents := 0, 0
+	for _, id := range t.Missing() {
+		if id != RootID {
+			parents++
+			orphans += len(t.Children[id])
+		}
+	}
+	if orphans != 0 {
+		warnings = append(warnings, spans(orphans)+" with a missing parent (under "+string(appendCount(nil, parents, "missing span"))+")")
+	}
+	if t.Duplicates != 0 {
+		warnings = append(warnings, spans(t.Duplicates)+" replaced by a later one with the same SpanID")
+	}
+
+	backwards, skewed, dropped, truncated := 0, 0, 0, 0
+	for id, span := range t.Spans {
+		if span.EndTime.Before(span.StartTime) {
+			backwards++
+		}
+		if p, ok := t.Spans[span.Parent.SpanID]; ok && (span.StartTime.Before(p.StartTime) || span.EndTime.After(p.EndTime)) {
+			skewed++
+		}
+		if span.DroppedAttributes+span.DroppedEvents+span.DroppedLinks != 0 {
+			dropped++
+		}
+		if span.ChildSpanCount > len(t.Children[id]) {
+			truncated++
+		}
+	}
+	if backwards != 0 {
+		warnings = append(warnings, spans(backwards)+" ending before they start")
+	}
+	if skewed != 0 {
+		warnings = append(warnings, spans(skewed)+" starting before or ending after their parent, maybe from clock skew between hosts")
+	}
+	if truncated != 0 {
+		warnings = append(warnings, spans(truncated)+" with fewer children than they reported, which the exporter may have dropped")
+	}
+	if dropped != 0 {
+		warnings = append(warnings, spans(dropped)+" over their SDK's limits on attributes, events, or links")
+	}
+	return warnings
+}
+
+func writeWarnings(w io.Writer, warnings []string) {
+	if len(warnings) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, `<details class="warnings"><summary>%s</summary><ul>`, appendCount(nil, len(warnings), "warning"))
+	for _, warning := range warnings {
+		fmt.Fprintf(w, `<li>%s</li>`, html.EscapeString(warning))
+	}
+	fmt.Fprintln(w, `</ul></details>`)
+}
@@ -0,0 +1,135 @@
+package trot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// zipkin is the Zipkin v2 JSON span list, or a list of such lists as returned by /api/v2/traces.
+type zipkin struct{}
+
+func (zipkin) Name() string {
+	return "zipkin"
+}
+
+func (zipkin) Sniff(peek []byte) bool {
+	peek = bytes.TrimLeft(peek, " \t\r\n")
+	return len(peek) != 0 && peek[0] == '[' && bytes.Contains(peek, []byte(`"traceId"`))
+}
+
+type zipkinSpan struct {
+	TraceID       string `json:"traceId"`
+	ID            string `json:"id"`
+	ParentID      string `json:"parentId"`
+	Name          string `json:"name"`
+	Kind          string `json:"kind"`
+	Timestamp     int64  `json:"timestamp"`
+	Duration      int64  `json:"duration"`
+	LocalEndpoint struct {
+		ServiceName string `json:"serviceName"`
+	} `json:"localEndpoint"`
+	RemoteEndpoint struct {
+		ServiceName string `json:"serviceName"`
+	} `json:"remoteEndpoint"`
+	Tags        map[string]string `json:"tags"`
+	Annotations []struct {
+		Timestamp int64  `json:"timestamp"`
+		Value     string `json:"value"`
+	} `json:"annotations"`
+	Shared bool `json:"shared"`
+}
+
+func (zipkin) Decode(r io.Reader, t *Trace) error {
+	dec := json.NewDecoder(r)
+	for i := 1; ; i++ {
+		var raw json.RawMessage
+		start := dec.InputOffset()
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("document %d: %w", i, err)
+		}
+
+		var spans []zipkinSpan
+		if err := json.Unmarshal(raw, &spans); err != nil {
+			var traces [][]zipkinSpan
+			if json.Unmarshal(raw, &traces) != nil {
+				// Report the error for the more common shape.
+				return fmt.Errorf("document %d: %w", i, atOffset(start, err))
+			}
+			for _, trace := range traces {
+				spans = append(spans, trace...)
+			}
+		}
+
+		for _, s := range spans {
+			span := &Span{
+				Name:      s.Name,
+				SpanKind:  kindOf(s.Kind),
+				StartTime: micros(s.Timestamp),
+				EndTime:   micros(s.Timestamp + s.Duration),
+			}
+			span.SpanContext = SpanContext{
+				TraceID: s.TraceID,
+				SpanID:  s.ID,
+				Remote:  s.Shared,
+			}
+			span.Parent.TraceID = s.TraceID
+			span.Parent.SpanID = s.ParentID
+			if span.Parent.SpanID == "" {
+				span.Parent.SpanID = RootID
+			}
+
+			span.Status.Code = "Unset"
+			keys := make([]string, 0, len(s.Tags))
+			for k := range s.Tags {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			attrs := []KeyValue{}
+			for _, k := range keys {
+				v := s.Tags[k]
+				switch k {
+				case "error":
+					span.Status.Code = "Error"
+					span.Status.Description = v
+					continue
+				case "otel.status_code":
+					if v == "ERROR" {
+						span.Status.Code = "Error"
+					}
+					continue
+				case "otel.status_description":
+					span.Status.Description = v
+					continue
+				}
+				attrs = append(attrs, keyValue(k, v))
+			}
+			if s.RemoteEndpoint.ServiceName != "" {
+				attrs = append(attrs, keyValue("peer.service", s.RemoteEndpoint.ServiceName))
+			}
+			span.Attributes = rawJSON(attrs)
+
+			if len(s.Annotations) != 0 {
+				events := []Event{}
+				for _, a := range s.Annotations {
+					events = append(events, Event{
+						Name: a.Value,
+						Time: micros(a.Timestamp),
+					})
+				}
+				span.Events = rawJSON(events)
+			}
+
+			span.Resource = []KeyValue{{Key: "service.name", Value: valueOf(s.LocalEndpoint.ServiceName)}}
+
+			t.Add(span)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// publish uploads local, a page or a -split directory, to dst
+// (s3://bucket/prefix or gs://bucket/prefix) with the aws or gcloud CLI,
+// so it uses whatever credentials those are already set up with. A page is
+// uploaded as name if dst is a directory. Every file is served as gzipped
+// HTML if gzipped is set. It returns the URL of the page, or of the
+// directory's index.html.
+func publish(ctx context.Context, local, name, dst string, gzipped bool) (string, error) {
+	u, err := url.Parse(dst)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" || (u.Scheme != "s3" && u.Scheme != "gs") {
+		return "", fmt.Errorf("%q is not an s3:// or gs:// URL", dst)
+	}
+	prefix := strings.TrimPrefix(u.Path, "/")
+
+	fi, err := os.Stat(local)
+	if err != nil {
+		return "", err
+	}
+	var files, keys []string
+	var key string
+	if fi.IsDir() {
+		entries, err := os.ReadDir(local)
+		if err != nil {
+			return "", err
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				files = append(files, filepath.Join(local, e.Name()))
+				keys = append(keys, path.Join(prefix, e.Name()))
+			}
+		}
+		key = path.Join(prefix, "index.html")
+	} else {
+		key = prefix
+		if key == "" || strings.HasSuffix(key, "/") {
+			key += name
+		}
+		files, keys = []string{local}, []string{key}
+	}
+
+	for i, file := range files {
+		if err := upload(ctx, u.Scheme, file, u.Host, keys[i], gzipped); err != nil {
+			return "", fmt.Errorf("publishing %s: %w", file, err)
+		}
+	}
+
+	if u.Scheme == "s3" {
+		return "https://" + u.Host + ".s3.amazonaws.com/" + key, nil
+	}
+	return "https://storage.googleapis.com/" + u.Host + "/" + key, nil
+}
+
+func upload(ctx context.Context, scheme, file, bucket, key string, gzipped bool) error {
+	dst := scheme + "://" + bucket + "/" + key
+	var cmd *exec.Cmd
+	if scheme == "s3" {
+		args := []string{"s3", "cp", "--only-show-errors", "--content-type", "text/html; charset=utf-8"}
+		if gzipped {
+			args = append(args, "--content-encoding", "gzip")
+		}
+		cmd = exec.CommandContext(ctx, "aws", append(args, file, dst)...)
+	} else {
+		args := []string{"storage", "cp", "--content-type=text/html; charset=utf-8"}
+		if gzipped {
+			args = append(args, "--content-encoding=gzip")
+		}
+		cmd = exec.CommandContext(ctx, "gcloud", append(args, file, dst)...)
+	}
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+
+	slog.Debug("uploading", "file", file, "to", dst)
+	return cmd.Run()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/jonjohnsonjr/trot/pkg/receiver"
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+func receiveCmd() *command {
+	cmd := newCommand("receive", "[flags]", "Accept OTLP/HTTP traces and serve them as they arrive.")
+	cmd.graceful = true
+
+	addr := cmd.flags.String("addr", "localhost:4318", "address to listen on")
+	loadViewer := viewerFlags(cmd.flags)
+	out := cmd.flags.String("o", "", "when shutting down, write every trace received to this file: a page if it ends in .html, otherwise OTLP/JSON")
+	debugAddr := cmd.flags.String("debug-addr", "", "serve pprof at /debug/pprof/ and trot's own memory, GC, and span counts at /debug/metrics on this address (localhost if it has no host, e.g. :6060), apart from the traces")
+	alerts := &stringList{}
+	cmd.flags.Var(alerts, "alert", "alert about traces matching this rule: duration>D, errors, span=path (like render -select), or several joined by commas, which all have to match (repeatable)")
+	webhooks := &stringList{}
+	cmd.flags.Var(webhooks, "webhook", "POST a JSON description of each -alert to this URL (repeatable)")
+	slack := &stringList{}
+	cmd.flags.Var(slack, "slack", "post a message about each -alert to this Slack incoming webhook URL (repeatable)")
+
+	cmd.run = func(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+		if len(*alerts) == 0 && len(*webhooks)+len(*slack) != 0 {
+			return fmt.Errorf("-webhook and -slack require -alert")
+		}
+		v, err := loadViewer()
+		if err != nil {
+			return err
+		}
+		store := trot.NewMemStore()
+		watcher := &rootWatcher{MemStore: store}
+		watcher.done = append(watcher.done, func(t *trot.Trace) {
+			baselines, err := v.baselinesFor(t)
+			if err != nil {
+				slog.Warn("baselines", "err", err)
+				return
+			}
+			warnRegression(t, baselines)
+		})
+
+		var a *alerter
+		if len(*alerts) != 0 {
+			base := baseURL(*addr)
+			page := func(t *trot.Trace) string {
+				return base + v.path(t)
+			}
+			a = &alerter{ctx: ctx, webhooks: *webhooks, slack: *slack, page: page, precision: v.opts.Precision, sent: map[string]bool{}}
+			for _, s := range *alerts {
+				rule, err := parseAlertRule(s)
+				if err != nil {
+					return fmt.Errorf("-alert %q: %w", s, err)
+				}
+				a.rules = append(a.rules, rule)
+			}
+			watcher.done = append(watcher.done, a.check)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle(receiver.Path, receiver.Handler(watcher))
+		mux.Handle("/", v.handler(store))
+
+		if *debugAddr != "" {
+			serveDebug(ctx, *debugAddr, store)
+		}
+		err = listenAndServe(ctx, *addr, mux)
+
+		// Whatever arrived before shutting down is still worth keeping.
+		if a != nil {
+			a.wait()
+		}
+		if *out != "" {
+			if serr := saveTraces(*out, store, v.opts); serr != nil {
+				return errors.Join(err, serr)
+			}
+		}
+		return err
+	}
+
+	return cmd
+}
+
+// rootWatcher adds spans to a store, and calls each of done with each trace
+// when its root span arrives, which is usually last.
+type rootWatcher struct {
+	*trot.MemStore
+	done []func(*trot.Trace)
+}
+
+func (rw *rootWatcher) Add(t *trot.Trace) {
+	rw.MemStore.Add(t)
+
+	for tid, tt := range t.Split() {
+		if _, ok := tt.Children[trot.RootID]; !ok {
+			continue
+		}
+		full, ok := rw.Get(tid)
+		if !ok {
+			continue
+		}
+		for _, fn := range rw.done {
+			fn(full)
+		}
+	}
+}
+
+// warnRegression warns if t is slower than its baseline.
+func warnRegression(t *trot.Trace, baselines *trot.Baselines) {
+	s := t.Summarize()
+	if d, ok := baselines.Compare(s); ok && d.Regressed {
+		slog.Warn("regression", "trace", s.TraceID, "name", s.Name, "duration", trot.FormatDuration(s.Duration, 3), "vs_baseline", d.String(), "baseline", d.Baseline)
+	}
+}
+
+// baseURL is where pages served on addr can be reached from this host.
+func baseURL(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "http://" + addr
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// saveTraces writes every trace in store to path, as a page rendered with
+// opts if it ends in .html, or otherwise as OTLP/JSON.
+func saveTraces(path string, store *trot.MemStore, opts trot.Options) error {
+	list := store.List()
+	if len(list) == 0 {
+		slog.Info("no traces to save")
+		return nil
+	}
+	t := trot.NewTrace()
+	for _, s := range list {
+		if tt, ok := store.Get(s.TraceID); ok {
+			for _, span := range tt.Spans {
+				t.Add(span)
+			}
+		}
+	}
+
+	if err := writeFile(path, func(w io.Writer) error {
+		if strings.HasSuffix(path, ".html") {
+			return trot.RenderHTML(w, t, opts)
+		}
+		return trot.EncodeOTLP(w, t)
+	}); err != nil {
+		return fmt.Errorf("-o: %w", err)
+	}
+	slog.Info("saved", "traces", len(list), "path", path)
+	return nil
+}
@@ -0,0 +1,676 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+func renderCmd() *command {
+	cmd := newCommand("render", "[flags] [file...]", "Render traces as a single HTML page.")
+
+	in := formatFlags(cmd.flags)
+	title := cmd.flags.String("title", "", "page title")
+	flame := cmd.flags.Bool("flame", false, "merge every trace in the input into one flame graph keyed by span name path")
+	deps := cmd.flags.String("deps", "", "write the service dependency graph instead of the trace (dot or html)")
+	out := cmd.flags.String("o", "", "write to this file instead of stdout")
+	open := cmd.flags.Bool("open", false, "open the output in a browser (writes to a temp file without -o)")
+	split := cmd.flags.Bool("split", false, "write one <TraceID>.html per trace into the -o directory")
+	watchFlag := cmd.flags.Bool("watch", false, "re-render whenever the input files change (requires -o or -open)")
+	interval := cmd.flags.Duration("interval", time.Second, "how often -watch polls for changes")
+	theme := cmd.flags.String("theme", "light", "color theme (light or dark)")
+	expand := cmd.flags.Int("expand", 0, "how many levels below the root start out expanded")
+	filter := cmd.flags.String("filter", "", "only show spans whose name matches this regexp, and their ancestors")
+	selector := cmd.flags.String("select", "", "only show spans at this path of span names, and their ancestors, e.g. root/serve/**/db.query (\"*\" is any one span, \"**\" any number)")
+	highlight := cmd.flags.String("highlight", "", "outline spans at this path of span names, like -select")
+	logFiles := &stringList{}
+	cmd.flags.Var(logFiles, "logs", "attach structured logs (JSON lines with trace_id and span_id, or OTLP/JSON) from these files to their spans (repeatable)")
+	links := &stringList{}
+	cmd.flags.Var(links, "link-template", "link each trace and span to another tool, as [name=]template, e.g. tempo=https://grafana/explore?traceID={{.TraceID}} (repeatable)")
+	breakdowns := &stringList{}
+	cmd.flags.Var(breakdowns, "breakdown", "break spans down by this attribute's values (like http.status_code), with how many had each and how long they took (repeatable)")
+	colors := &stringList{}
+	cmd.flags.Var(colors, "color", "color spans whose name matches, as regexp=color, or regexp>duration=color for only the ones slower than that (repeatable)")
+	precision := cmd.flags.Int("duration-precision", 3, "significant digits in durations")
+	timeFlag := cmd.flags.String("time", "relative", "show times relative to the trace start, or as wall-clock times in a zone (local, UTC, America/New_York, ...)")
+	codeURL := cmd.flags.String("code-url", "", "link spans with code.* attributes to their source with this template, like -link-template, with .Path, .Line, and .Function, e.g. https://github.com/org/repo/blob/<sha>/{{.Path}}#L{{.Line}}")
+	codeRoot := cmd.flags.String("code-root", "", "trim this prefix from code.filepath for -code-url")
+	notesFile := cmd.flags.String("notes", "", "show notes exported from a page (a JSON sidecar) on their spans")
+	name := cmd.flags.String("name", "", "what to call spans, as a template like '{{.Name}} {{attr \"http.route\"}}' (attr and resource look up attributes)")
+	profile := cmd.flags.String("profile", "", "name spans from a known tool with the attributes it records: "+strings.Join(trot.ProfileNames(), ", "))
+	groupBy := cmd.flags.String("group-by", "", "gather spans into lanes by attr(key), resource(key), service, or scope, e.g. attr(thread.id)")
+	syntheticRoot := cmd.flags.Bool("synthetic-root", false, "give traces without a root span one that covers their spans and adopts their orphans")
+	scopes := cmd.flags.Bool("scopes", false, "badge each span with the instrumentation library that created it")
+	hideScope := cmd.flags.String("hide-scope", "", "hide spans from instrumentation libraries matching this regexp, moving their children up")
+	view := cmd.flags.String("view", "tree", "how to draw traces: tree (collapsible spans), canvas (a flame chart on a <canvas>, for hundreds of thousands of spans), icicle or sunburst (each span as big as its share of its parent's time), or table (a sortable row per span)")
+	scale := cmd.flags.String("scale", "linear", "time scale (linear, or log to keep microsecond spans visible next to ones that take seconds)")
+	compressGaps := cmd.flags.Bool("compress-gaps", false, "draw stretches when no span is doing anything (longer than a tenth of the trace) much shorter, marked on the ruler")
+	compact := cmd.flags.Bool("compact", false, "emit much smaller markup, rounding span widths to 0.1% (for huge traces)")
+	csp := cmd.flags.Bool("csp", false, "avoid inline style attributes, for pages served with a strict Content-Security-Policy")
+	nonce := cmd.flags.String("nonce", "", "add this nonce to the page's <style> and <script> elements")
+	noScript := cmd.flags.Bool("no-script", false, "leave out the page's script (and with it the toolbar)")
+	strictOffline := cmd.flags.Bool("strict-offline", false, "fail unless each page loads nothing external and its inline CSS and JS fit in -offline-budget, and add a content hash footer (buffers each page)")
+	offlineBudget := cmd.flags.String("offline-budget", "128KB", "with -strict-offline, the most inline CSS and JS a page may have")
+	compress := cmd.flags.Bool("compress", false, "gzip the output (with -split, files are named <TraceID>.html.gz)")
+	sampleRate := cmd.flags.Float64("sample", 1, "render only about this fraction of spans (and their ancestors)")
+	maxSpans := cmd.flags.Int("max-spans", 0, "render at most this many spans (per trace with -stream), keeping critical paths and the longest spans")
+	maxMemory := cmd.flags.String("max-memory", "", "with -stream, spill incomplete traces to disk beyond about this much memory (e.g. 512MB)")
+	stream := cmd.flags.Bool("stream", false, "render each trace as soon as its spans are all read, one tree per trace, instead of holding the whole input in memory")
+	failOnError := cmd.flags.Bool("fail-on-error-spans", false, "exit non-zero if any span has an error status, after writing the output")
+	publishTo := cmd.flags.String("publish", "", "upload the output (with -split, every page and an index) to s3://bucket/path or gs://bucket/path with the aws or gcloud CLI, and print its URL")
+	githubSummary := cmd.flags.Bool("github-summary", false, "append a Markdown report to $GITHUB_STEP_SUMMARY and set the step's html output to the page (which defaults to trot.html instead of stdout)")
+	deterministic := cmd.flags.Bool("deterministic", false, "render the same spans the same way every time, for golden-file tests: renumber TraceIDs and SpanIDs in the order spans are drawn, and require relative times")
+	failOnMissing := cmd.flags.Bool("fail-on-missing-parents", false, "exit non-zero if any span's parent is missing, after writing the output")
+	emitMeta := cmd.flags.String("emit-meta", "", "also write each trace's metadata (ID, duration, span and error counts, services, critical path, and page) to this JSON file, for indexing")
+
+	cmd.run = func(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+		if *split && *out == "" {
+			return fmt.Errorf("-split requires -o <dir>")
+		}
+		var spillAt int64
+		if *maxMemory != "" {
+			n, err := parseSize(*maxMemory)
+			if err != nil {
+				return fmt.Errorf("-max-memory: %w", err)
+			}
+			if !*stream {
+				return fmt.Errorf("-max-memory requires -stream")
+			}
+			spillAt = n
+		}
+		switch *view {
+		case "tree", "icicle", "sunburst", "table":
+		case "canvas":
+			if *noScript {
+				return fmt.Errorf("-view %s can't be combined with -no-script", *view)
+			}
+		default:
+			return fmt.Errorf("unknown -view %q (want tree, canvas, icicle, sunburst, or table)", *view)
+		}
+		if *stream && !*split && *view != "tree" {
+			return fmt.Errorf("-view %s can't be combined with -stream, except with -split", *view)
+		}
+		if *stream && (*flame || *deps != "" || *watchFlag || *emitMeta != "") {
+			return fmt.Errorf("-stream can't be combined with -flame, -deps, -watch, or -emit-meta")
+		}
+		if *publishTo != "" && (*stream || *watchFlag) {
+			return fmt.Errorf("-publish can't be combined with -stream or -watch")
+		}
+		if *githubSummary {
+			if *stream || *watchFlag {
+				return fmt.Errorf("-github-summary can't be combined with -stream or -watch")
+			}
+			if *out == "" && !*open {
+				*out = "trot.html"
+			}
+		}
+		if *sampleRate <= 0 || *sampleRate > 1 {
+			return fmt.Errorf("-sample must be in (0, 1]")
+		}
+		if *csp && (*flame || *deps != "") {
+			return fmt.Errorf("-csp can't be combined with -flame or -deps")
+		}
+		budget := int64(0)
+		if *strictOffline {
+			if *deps == "dot" {
+				return fmt.Errorf("-strict-offline requires HTML output")
+			}
+			n, err := parseSize(*offlineBudget)
+			if err != nil {
+				return fmt.Errorf("-offline-budget: %w", err)
+			}
+			budget = n
+		}
+		if *deterministic && (*stream || *timeFlag != "relative") {
+			return fmt.Errorf("-deterministic can't be combined with -stream or -time")
+		}
+		if *compress && *open {
+			return fmt.Errorf("-compress can't be combined with -open")
+		}
+		if *watchFlag {
+			if len(args) == 0 {
+				return fmt.Errorf("-watch requires file or directory arguments")
+			}
+			if *out == "" && !*open {
+				return fmt.Errorf("-watch requires -o or -open")
+			}
+		}
+
+		opts := trot.Options{
+			Title:     *title,
+			Theme:     *theme,
+			Expand:    *expand,
+			Compact:   *compact,
+			Precision: *precision,
+			CodeRoot:  *codeRoot,
+			Scopes:    *scopes,
+			CSP:       *csp,
+			Nonce:     *nonce,
+			NoScript:  *noScript,
+
+			CompressGaps: *compressGaps,
+			Scale:        *scale,
+			Breakdown:    *breakdowns,
+		}
+		if *scale != "linear" && *scale != "log" {
+			return fmt.Errorf("unknown -scale %q (want linear or log)", *scale)
+		}
+		if *watchFlag {
+			opts.Refresh = *interval
+		}
+		switch *timeFlag {
+		case "relative":
+		case "local":
+			opts.Location = time.Local
+		default:
+			loc, err := time.LoadLocation(*timeFlag)
+			if err != nil {
+				return fmt.Errorf("-time: %w", err)
+			}
+			opts.Location = loc
+		}
+		if *filter != "" {
+			re, err := regexp.Compile(*filter)
+			if err != nil {
+				return fmt.Errorf("-filter: %w", err)
+			}
+			opts.Filter = func(n *trot.Node) bool {
+				return re.MatchString(n.Span.Name)
+			}
+		}
+		if *selector != "" {
+			sel, err := trot.ParseSelector(*selector)
+			if err != nil {
+				return fmt.Errorf("-select: %w", err)
+			}
+			opts.Select = sel
+		}
+		if *highlight != "" {
+			sel, err := trot.ParseSelector(*highlight)
+			if err != nil {
+				return fmt.Errorf("-highlight: %w", err)
+			}
+			opts.Highlight = sel
+		}
+		if *notesFile != "" {
+			b, err := os.ReadFile(*notesFile)
+			if err != nil {
+				return fmt.Errorf("-notes: %w", err)
+			}
+			var notes trot.Notes
+			if err := json.Unmarshal(b, &notes); err != nil {
+				return fmt.Errorf("-notes: %s: %w", *notesFile, err)
+			}
+			opts.Notes = notes.Notes
+		}
+		if *name != "" && *profile != "" {
+			return fmt.Errorf("-profile can't be combined with -name")
+		}
+		if *name != "" {
+			tmpl, err := trot.ParseNameTemplate(*name)
+			if err != nil {
+				return err
+			}
+			opts.Name = tmpl
+		}
+		if *profile != "" {
+			tmpl, err := trot.Profile(*profile)
+			if err != nil {
+				return fmt.Errorf("-profile: %w", err)
+			}
+			opts.Name = tmpl
+		}
+		if *groupBy != "" {
+			key, err := trot.ParseGroupBy(*groupBy)
+			if err != nil {
+				return err
+			}
+			opts.GroupBy = key
+		}
+		if *codeURL != "" {
+			tmpl, err := trot.ParseLinkTemplate(*codeURL)
+			if err != nil {
+				return fmt.Errorf("-code-url: %w", err)
+			}
+			opts.CodeURL = &tmpl
+		}
+		for _, l := range *links {
+			tmpl, err := trot.ParseLinkTemplate(l)
+			if err != nil {
+				return err
+			}
+			opts.Links = append(opts.Links, tmpl)
+		}
+		for _, c := range *colors {
+			rule, err := trot.ParseColorRule(c)
+			if err != nil {
+				return err
+			}
+			opts.Colors = append(opts.Colors, rule)
+		}
+
+		// read is readTrace plus any -logs.
+		read := func() (*trot.Trace, error) {
+			t, err := readTrace(ctx, r, args, *in)
+			if err != nil {
+				return nil, err
+			}
+			if len(*logFiles) != 0 {
+				logs, err := readLogs(*logFiles)
+				if err != nil {
+					return nil, fmt.Errorf("-logs: %w", err)
+				}
+				attachLogs(t, logs)
+			}
+			if *deterministic {
+				t = t.Renumber()
+			}
+			return t, nil
+		}
+
+		// output gzips whatever fn writes if -compress is set, and checks
+		// and seals it first for -strict-offline.
+		output := func(w io.Writer, fn func(io.Writer) error) error {
+			if *strictOffline {
+				write := fn
+				fn = func(w io.Writer) error {
+					var buf bytes.Buffer
+					if err := write(&buf); err != nil {
+						return err
+					}
+					page, err := trot.SealOffline(buf.Bytes(), int(budget))
+					if err != nil {
+						return fmt.Errorf("-strict-offline: %w", err)
+					}
+					_, err = w.Write(page)
+					return err
+				}
+			}
+			if *compress {
+				return gzipped(w, fn)
+			}
+			return fn(w)
+		}
+		ext := ".html"
+		if *compress {
+			ext += ".gz"
+		}
+
+		var hidden *regexp.Regexp
+		if *hideScope != "" {
+			re, err := regexp.Compile(*hideScope)
+			if err != nil {
+				return fmt.Errorf("-hide-scope: %w", err)
+			}
+			hidden = re
+		}
+
+		// sample thins out t for -hide-scope, -sample, and -max-spans, after
+		// -synthetic-root.
+		sample := func(t *trot.Trace) *trot.Trace {
+			if *syntheticRoot {
+				t = t.SynthesizeRoots()
+			}
+			if hidden != nil {
+				t = t.HideScopes(hidden.MatchString)
+			}
+			if *sampleRate < 1 {
+				t = t.Sample(*sampleRate)
+			}
+			if *maxSpans > 0 {
+				t = t.Limit(*maxSpans)
+			}
+			return t
+		}
+
+		renderView := func(w io.Writer, t *trot.Trace) error {
+			switch *view {
+			case "canvas":
+				return trot.RenderCanvas(w, t, opts)
+			case "icicle":
+				return trot.RenderIcicle(w, t, opts)
+			case "sunburst":
+				return trot.RenderSunburst(w, t, opts)
+			case "table":
+				return trot.RenderTable(w, t, opts)
+			}
+			return trot.RenderHTML(w, t, opts)
+		}
+		renderTrace := func(w io.Writer, t *trot.Trace) error {
+			t = sample(t)
+
+			if *flame {
+				return trot.RenderFlame(w, t.Flame(), opts)
+			}
+
+			if *deps != "" {
+				switch *deps {
+				case "dot":
+					return trot.RenderDOT(w, t.Deps(), opts)
+				case "html":
+					return trot.RenderDepsHTML(w, t.Deps(), opts)
+				}
+				return fmt.Errorf("unknown -deps format %q (want dot or html)", *deps)
+			}
+
+			warn(t)
+			return renderView(w, t)
+		}
+		render := func(w io.Writer, t *trot.Trace) error {
+			return output(w, func(w io.Writer) error {
+				return renderTrace(w, t)
+			})
+		}
+
+		// check turns trace problems into errors, for CI.
+		check := func(errored, orphaned int) error {
+			var errs []error
+			if *failOnError && errored != 0 {
+				errs = append(errs, fmt.Errorf("%d error spans", errored))
+			}
+			if *failOnMissing && orphaned != 0 {
+				errs = append(errs, fmt.Errorf("%d spans with missing parents", orphaned))
+			}
+			return errors.Join(errs...)
+		}
+
+		if *stream {
+			// Traces are gone once rendered, so tally problems as we go.
+			var logs []trot.Log
+			if len(*logFiles) != 0 {
+				var err error
+				if logs, err = readLogs(*logFiles); err != nil {
+					return fmt.Errorf("-logs: %w", err)
+				}
+			}
+
+			errored, orphaned := 0, 0
+			collect := func(t *trot.Trace) *trot.Trace {
+				attachLogs(t, logs)
+				warn(t)
+				errored += errorSpans(t)
+				orphaned += orphans(t)
+				return sample(t)
+			}
+
+			var err error
+			switch {
+			case *split:
+				if err := os.MkdirAll(*out, 0o755); err != nil {
+					return err
+				}
+				// Spans that arrive after their trace was written come back
+				// as another piece of it, which would overwrite the first.
+				written := map[string]bool{}
+				err = streamTrace(r, args, *in, spillAt, func(t *trot.Trace) error {
+					t = collect(t)
+					tid := t.Summarize().TraceID
+					if written[tid] {
+						return fmt.Errorf("trace %s arrived in more than one piece; render it without -stream", tid)
+					}
+					written[tid] = true
+					return writeFile(filepath.Join(*out, trot.PageName(tid)+ext), func(w io.Writer) error {
+						return output(w, func(w io.Writer) error {
+							return renderView(w, t)
+						})
+					})
+				})
+			case *out == "" && !*open:
+				err = output(w, func(w io.Writer) error {
+					return streamPage(w, r, args, *in, spillAt, opts, collect)
+				})
+			default:
+				path := *out
+				if path == "" {
+					f, err := os.CreateTemp("", "trot-*.html")
+					if err != nil {
+						return err
+					}
+					f.Close()
+					path = f.Name()
+				}
+				err = writeFile(path, func(w io.Writer) error {
+					return output(w, func(w io.Writer) error {
+						return streamPage(w, r, args, *in, spillAt, opts, collect)
+					})
+				})
+				if err == nil && *open {
+					err = openBrowser(path)
+				}
+			}
+			if err != nil {
+				return err
+			}
+			return check(errored, orphaned)
+		}
+
+		if *out == "" && !*open && *publishTo == "" {
+			t, err := read()
+			if err != nil {
+				return err
+			}
+			if err := render(w, t); err != nil {
+				return err
+			}
+			if *emitMeta != "" {
+				if err := writeMeta(*emitMeta, t, "", false, ext); err != nil {
+					return err
+				}
+			}
+			return check(errorSpans(t), orphans(t))
+		}
+
+		path := *out
+		if path == "" {
+			f, err := os.CreateTemp("", "trot-*.html")
+			if err != nil {
+				return err
+			}
+			f.Close()
+			path = f.Name()
+		}
+
+		write := func() (*trot.Trace, error) {
+			t, err := read()
+			if err != nil {
+				return nil, err
+			}
+			if *emitMeta != "" {
+				if err := writeMeta(*emitMeta, t, path, *split, ext); err != nil {
+					return nil, err
+				}
+			}
+			if *split {
+				if err := renderSplit(path, ext, t, render); err != nil {
+					return nil, err
+				}
+				if *publishTo != "" {
+					// Published with the pages, so gzipped like them.
+					return t, writeFile(filepath.Join(path, "index.html"), func(w io.Writer) error {
+						if *compress {
+							return gzipped(w, func(w io.Writer) error {
+								return trot.RenderIndex(w, summarize(t.Split()), ext)
+							})
+						}
+						return trot.RenderIndex(w, summarize(t.Split()), ext)
+					})
+				}
+				return t, nil
+			}
+			return t, renderFile(path, t, render)
+		}
+
+		if !*watchFlag {
+			t, err := write()
+			if err != nil {
+				return err
+			}
+			if *publishTo != "" {
+				if *out == "" && !*open {
+					defer os.Remove(path)
+				}
+				// Not the temp file's name, without -o.
+				name := filepath.Base(*out)
+				if *out == "" {
+					name = trot.PageName(t.Summarize().TraceID) + ext
+				}
+				u, err := publish(ctx, path, name, *publishTo, *compress)
+				if err != nil {
+					return fmt.Errorf("-publish: %w", err)
+				}
+				fmt.Fprintln(w, u)
+			}
+			if *githubSummary {
+				if err := writeGitHubSummary(t, path, *precision); err != nil {
+					return err
+				}
+			}
+			if *open {
+				if err := openBrowser(path); err != nil {
+					return err
+				}
+			}
+			return check(errorSpans(t), orphans(t))
+		}
+
+		opened := false
+		return watch(ctx, append(args, *logFiles...), *interval, func() error {
+			t, err := write()
+			if err != nil {
+				return err
+			}
+			// Keep watching; the next write may fix it.
+			if err := check(errorSpans(t), orphans(t)); err != nil {
+				slog.Warn("check", "err", err)
+			}
+			if *open && !opened {
+				opened = true
+				return openBrowser(path)
+			}
+			return nil
+		})
+	}
+
+	return cmd
+}
+
+// warn logs what the page's warnings panel says, like spans whose parents
+// are missing and traces without a root.
+func warn(t *trot.Trace) {
+	for _, w := range t.Warnings() {
+		slog.Warn(w)
+	}
+}
+
+func errorSpans(t *trot.Trace) int {
+	n := 0
+	for _, span := range t.Spans {
+		if span.IsError() {
+			n++
+		}
+	}
+	return n
+}
+
+// orphans counts spans whose parent is missing.
+func orphans(t *trot.Trace) int {
+	n := 0
+	for _, missed := range t.Missing() {
+		if missed != trot.RootID {
+			n += len(t.Children[missed])
+		}
+	}
+	return n
+}
+
+// streamPage renders each trace onto one page as soon as it is complete.
+// each sees every trace first and returns what to render in its place.
+func streamPage(w io.Writer, r io.Reader, args []string, in input, maxMemory int64, opts trot.Options, each func(*trot.Trace) *trot.Trace) error {
+	page := trot.NewPage(w, opts)
+	if err := streamTrace(r, args, in, maxMemory, func(t *trot.Trace) error {
+		return page.Add(each(t))
+	}); err != nil {
+		return err
+	}
+	return page.Close()
+}
+
+// renderFile writes to a temp file next to path and renames it into place,
+// so a browser reloading path never sees a partial page.
+func renderFile(path string, t *trot.Trace, render func(io.Writer, *trot.Trace) error) error {
+	return writeFile(path, func(w io.Writer) error {
+		return render(w, t)
+	})
+}
+
+// writeFile is renderFile for any writer.
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".trot-*.html")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := write(f); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+func renderSplit(dir, ext string, t *trot.Trace, render func(io.Writer, *trot.Trace) error) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for tid, tt := range t.Split() {
+		if err := renderFile(filepath.Join(dir, trot.PageName(tid)+ext), tt, render); err != nil {
+			return fmt.Errorf("rendering %s: %w", tid, err)
+		}
+	}
+
+	return nil
+}
+
+// parseSize parses a byte count like "512MB", "2GiB", or "1000000".
+func parseSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		n      int64
+	}{
+		{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+		{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9},
+		{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+		{"B", 1},
+	}
+	mult := int64(1)
+	num := strings.TrimSpace(s)
+	for _, u := range units {
+		if strings.HasSuffix(strings.ToUpper(num), strings.ToUpper(u.suffix)) {
+			num = strings.TrimSpace(num[:len(num)-len(u.suffix)])
+			mult = u.n
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f <= 0 {
+		return 0, fmt.Errorf("bad size %q", s)
+	}
+	return int64(f * float64(mult)), nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jonjohnsonjr/trot/pkg/receiver"
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+func runCmd() *command {
+	cmd := newCommand("run", "[flags] -- command [arg...]", "Run a command with an OTLP receiver and render the traces it sends.")
+
+	out := cmd.flags.String("o", "", "write to this file instead of stdout")
+	open := cmd.flags.Bool("open", false, "open the output in a browser (writes to a temp file without -o)")
+	title := cmd.flags.String("title", "", "page title")
+	theme := cmd.flags.String("theme", "light", "color theme (light or dark)")
+	addr := cmd.flags.String("addr", "localhost:0", "address for the receiver to listen on")
+
+	cmd.run = func(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("run requires a command")
+		}
+
+		l, err := net.Listen("tcp", *addr)
+		if err != nil {
+			return err
+		}
+		spans := &collector{t: trot.NewTrace()}
+		mux := http.NewServeMux()
+		mux.Handle(receiver.Path, receiver.Handler(spans))
+		srv := &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go srv.Serve(l)
+
+		endpoint := "http://" + l.Addr().String()
+		slog.Info("receiving", "endpoint", endpoint)
+
+		// The command's lifecycle is the root span, which instrumentation
+		// that reads TRACEPARENT will parent its spans under.
+		root := &trot.Span{Name: filepath.Base(args[0]), SpanKind: trot.KindInternal}
+		root.SpanContext.TraceID = randomID(16)
+		root.SpanContext.SpanID = randomID(8)
+		root.SpanContext.TraceFlags = "01"
+
+		c := exec.CommandContext(ctx, args[0], args[1:]...)
+		c.Env = append(os.Environ(),
+			"TRACEPARENT=00-"+root.SpanContext.TraceID+"-"+root.SpanContext.SpanID+"-01",
+			"OTEL_TRACES_EXPORTER=otlp",
+			"OTEL_EXPORTER_OTLP_ENDPOINT="+endpoint,
+			"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT="+endpoint+receiver.Path,
+			"OTEL_EXPORTER_OTLP_PROTOCOL=http/protobuf",
+			"OTEL_EXPORTER_OTLP_TRACES_PROTOCOL=http/protobuf",
+		)
+		c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if *out == "" && !*open {
+			// The page goes to stdout.
+			c.Stdout = os.Stderr
+		}
+		// Give the command a chance to flush its spans on ^C.
+		c.Cancel = func() error {
+			return c.Process.Signal(os.Interrupt)
+		}
+		c.WaitDelay = 5 * time.Second
+		root.StartTime = time.Now()
+		runErr := c.Run()
+		root.EndTime = time.Now()
+
+		// Shutdown waits for exports that are still in flight.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+
+		t := spans.trace()
+		if len(t.Spans) == 0 {
+			slog.Warn("no spans received", "endpoint", endpoint)
+		}
+		attrs := []trot.KeyValue{
+			{Key: "process.command_args", Value: trot.Value{Type: "STRINGSLICE", Value: args}},
+		}
+		if c.ProcessState != nil {
+			code := c.ProcessState.ExitCode()
+			attrs = append(attrs,
+				trot.KeyValue{Key: "process.pid", Value: trot.Value{Type: "INT64", Value: c.ProcessState.Pid()}},
+				trot.KeyValue{Key: "process.exit.code", Value: trot.Value{Type: "INT64", Value: code}},
+			)
+			if code != 0 {
+				root.Status.Code = "Error"
+				root.Status.Description = c.ProcessState.String()
+			}
+		} else if runErr != nil {
+			root.Status.Code = "Error"
+			root.Status.Description = runErr.Error()
+		}
+		if root.Attributes, err = json.Marshal(attrs); err != nil {
+			return err
+		}
+		t.Adopt(root)
+		opts := trot.Options{
+			Title: *title,
+			Theme: *theme,
+		}
+		render := func(w io.Writer, t *trot.Trace) error {
+			warn(t)
+			return trot.RenderHTML(w, t, opts)
+		}
+
+		if *out == "" && !*open {
+			err = render(w, t)
+		} else {
+			path := *out
+			if path == "" {
+				f, err := os.CreateTemp("", "trot-*.html")
+				if err != nil {
+					return err
+				}
+				f.Close()
+				path = f.Name()
+			}
+			err = renderFile(path, t, render)
+			if err == nil && *open {
+				err = openBrowser(path)
+			}
+		}
+
+		if runErr != nil {
+			runErr = fmt.Errorf("%s: %w", args[0], runErr)
+		}
+		return errors.Join(runErr, err)
+	}
+
+	return cmd
+}
+
+// randomID returns n random bytes in hex, for trace and span IDs.
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// collector merges every request the receiver gets into one Trace.
+type collector struct {
+	mu sync.Mutex
+	t  *trot.Trace
+}
+
+func (c *collector) Add(t *trot.Trace) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, span := range t.Spans {
+		c.t.Add(span)
+	}
+}
+
+func (c *collector) trace() *trot.Trace {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.t
+}
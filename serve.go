@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+func serveCmd() *command {
+	cmd := newCommand("serve", "[flags] [file...]", "Serve an index of the input's traces and a page per trace.")
+	cmd.graceful = true
+
+	in := formatFlags(cmd.flags)
+	addr := cmd.flags.String("addr", "localhost:8080", "address to listen on")
+	watchFlag := cmd.flags.Bool("watch", false, "reload the input files when they change and refresh open pages")
+	interval := cmd.flags.Duration("interval", time.Second, "how often -watch polls for changes")
+	loadViewer := viewerFlags(cmd.flags)
+	debugAddr := cmd.flags.String("debug-addr", "", "serve pprof at /debug/pprof/ and trot's own memory, GC, and span counts at /debug/metrics on this address (localhost if it has no host, e.g. :6060), apart from the traces")
+
+	cmd.run = func(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+		if *watchFlag && len(args) == 0 {
+			return fmt.Errorf("-watch requires file or directory arguments")
+		}
+		v, err := loadViewer()
+		if err != nil {
+			return err
+		}
+
+		store := &swapStore{}
+		load := func() error {
+			t, err := readTrace(ctx, r, args, *in)
+			if err != nil {
+				return err
+			}
+			ms := trot.NewMemStore()
+			ms.Add(t)
+			store.swap(ms)
+			return nil
+		}
+
+		if err := load(); err != nil {
+			return err
+		}
+
+		h := v.handler(store)
+		if *watchFlag {
+			go watch(ctx, args, *interval, load)
+			h = liveReload(h, store.version, *interval)
+		}
+		if *debugAddr != "" {
+			serveDebug(ctx, *debugAddr, store)
+		}
+
+		return listenAndServe(ctx, *addr, h)
+	}
+
+	return cmd
+}
+
+// viewer is how serve and receive show what they hold: split into groups
+// by -tenant-by, or not, with baselines kept in -baselines.
+type viewer struct {
+	tenants   *trot.Tenants
+	baselines *trot.Baselines
+	opts      trot.Options
+}
+
+// viewerFlags adds the flags that set up a viewer, and returns a func to
+// load it.
+func viewerFlags(fs *flag.FlagSet) func() (*viewer, error) {
+	dir := fs.String("baselines", "", "keep the traces marked as baselines in this directory, so new traces are compared to them across restarts")
+	regression := fs.Float64("regression", 0.1, "how much slower than its baseline, as a fraction of it, a trace has to be to count as a regression")
+	tenantBy := fs.String("tenant-by", "", "serve traces in groups by this resource attribute of their top-level spans (like service.name or k8s.namespace.name), each with its own index at /<group>/")
+	precision := fs.Int("duration-precision", 3, "significant digits in durations")
+	tokens := &stringList{}
+	fs.Var(tokens, "tenant-token", "require this token, as group=token, to see a -tenant-by group, as the password for HTTP Basic auth or a Bearer token (repeatable)")
+
+	return func() (*viewer, error) {
+		if *regression <= 0 {
+			return nil, fmt.Errorf("-regression must be positive")
+		}
+		opts := trot.Options{Precision: *precision}
+		load := func(group string) (*trot.Baselines, error) {
+			path := *dir
+			if path != "" && group != "" {
+				path = filepath.Join(path, trot.EscapeGroup(group))
+			}
+			b, err := trot.LoadBaselines(path)
+			if err != nil {
+				return nil, fmt.Errorf("-baselines: %w", err)
+			}
+			b.Threshold = *regression
+			return b, nil
+		}
+
+		if *tenantBy == "" {
+			if len(*tokens) != 0 {
+				return nil, fmt.Errorf("-tenant-token requires -tenant-by")
+			}
+			b, err := load("")
+			if err != nil {
+				return nil, err
+			}
+			return &viewer{baselines: b, opts: opts}, nil
+		}
+
+		ts := &trot.Tenants{Key: *tenantBy, Tokens: map[string]string{}, LoadBaselines: load, Options: opts}
+		for _, t := range *tokens {
+			group, token, ok := strings.Cut(t, "=")
+			if !ok || group == "" || token == "" {
+				return nil, fmt.Errorf("-tenant-token %q: want group=token", t)
+			}
+			ts.Tokens[group] = token
+		}
+		return &viewer{tenants: ts, opts: opts}, nil
+	}
+}
+
+func (v *viewer) handler(store trot.Store) http.Handler {
+	if v.tenants != nil {
+		return v.tenants.Handler(store)
+	}
+	return trot.BaselineHandler(store, v.baselines, v.opts)
+}
+
+// path is where t is served.
+func (v *viewer) path(t *trot.Trace) string {
+	tid := t.Summarize().TraceID
+	if v.tenants != nil {
+		return "/" + trot.EscapeGroup(v.tenants.Group(t)) + "/" + tid
+	}
+	return "/" + tid
+}
+
+// baselinesFor returns the Baselines to compare t to.
+func (v *viewer) baselinesFor(t *trot.Trace) (*trot.Baselines, error) {
+	if v.tenants != nil {
+		return v.tenants.Baselines(v.tenants.Group(t))
+	}
+	return v.baselines, nil
+}
+
+// swapStore is a trot.Store whose contents get replaced wholesale on reload.
+type swapStore struct {
+	mu    sync.RWMutex
+	store *trot.MemStore
+	gen   int64
+}
+
+func (s *swapStore) swap(ms *trot.MemStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store = ms
+	s.gen++
+}
+
+func (s *swapStore) version() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.gen
+}
+
+func (s *swapStore) List() []trot.Summary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.store.List()
+}
+
+func (s *swapStore) Get(traceID string) (*trot.Trace, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.store.Get(traceID)
+}
+
+func (s *swapStore) Len() (traces, spans int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.store.Len()
+}
+
+const versionPath = "/-/version"
+
+// liveReload serves version at versionPath and appends a script to every
+// HTML page that reloads it when version changes.
+func liveReload(h http.Handler, version func() int64, interval time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == versionPath {
+			w.Header().Set("Cache-Control", "no-store")
+			fmt.Fprint(w, version())
+			return
+		}
+
+		h.ServeHTTP(w, r)
+
+		if strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+			fmt.Fprintf(w, liveReloadScript, versionPath, interval.Milliseconds())
+		}
+	})
+}
+
+const liveReloadScript = `<script>
+(() => {
+  let version = null;
+  setInterval(async () => {
+    try {
+      const v = await (await fetch(%q)).text();
+      if (version !== null && v !== version) location.reload();
+      version = v;
+    } catch (e) {}
+  }, %d);
+})();
+</script>
+`
+
+// listenAndServe serves h on addr until ctx is cancelled, then waits for
+// the requests it's in the middle of, like spans being received or pages
+// being rendered, to finish.
+func listenAndServe(ctx context.Context, addr string, h http.Handler) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler:           gzipResponses(h),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	shutdown := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		slog.Info("shutting down")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		shutdown <- srv.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("listening", "url", "http://"+l.Addr().String())
+
+	if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	// Serve returns as soon as Shutdown starts, not when it's done.
+	if err := <-shutdown; err != nil {
+		return fmt.Errorf("shutting down: %w", err)
+	}
+	return nil
+}
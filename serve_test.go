@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+func parseViewer(t *testing.T, args ...string) (*viewer, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	load := viewerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return load()
+}
+
+func TestViewerFlags(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{nil, ""},
+		{[]string{"-tenant-by", "service.name"}, ""},
+		{[]string{"-tenant-by", "service.name", "-tenant-token", "a=x", "-tenant-token", "b=y=z"}, ""},
+		{[]string{"-tenant-token", "a=x"}, "-tenant-token requires -tenant-by"},
+		{[]string{"-tenant-by", "service.name", "-tenant-token", "a"}, "want group=token"},
+		{[]string{"-tenant-by", "service.name", "-tenant-token", "=x"}, "want group=token"},
+		{[]string{"-regression", "0"}, "-regression must be positive"},
+	} {
+		_, err := parseViewer(t, tc.args...)
+		if tc.err == "" && err != nil || tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("%q: %v, want %q", tc.args, err, tc.err)
+		}
+	}
+
+	v, err := parseViewer(t, "-tenant-by", "service.name", "-tenant-token", "a=x", "-tenant-token", "b=y=z", "-duration-precision", "5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.tenants == nil || v.tenants.Key != "service.name" || len(v.tenants.Tokens) != 2 || v.tenants.Tokens["a"] != "x" || v.tenants.Tokens["b"] != "y=z" {
+		t.Errorf("tenants = %+v", v.tenants)
+	}
+	if v.opts.Precision != 5 || v.tenants.Options.Precision != 5 {
+		t.Errorf("precision = %d, %d, want 5", v.opts.Precision, v.tenants.Options.Precision)
+	}
+}
+
+// tenantTrace is a one-span trace whose service.name is group.
+func tenantTrace(tid, group string) *trot.Trace {
+	span := &trot.Span{
+		Name:      "op",
+		StartTime: time.Unix(1, 0),
+		EndTime:   time.Unix(2, 0),
+		Resource:  []trot.KeyValue{{Key: "service.name", Value: trot.Value{Type: "STRING", Value: group}}},
+	}
+	span.SpanContext.TraceID = tid
+	span.SpanContext.SpanID = "0000000000000001"
+	span.Parent.SpanID = trot.RootID
+	t := trot.NewTrace()
+	t.Add(span)
+	return t
+}
+
+func TestViewerTenants(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "baselines")
+	v, err := parseViewer(t, "-baselines", dir, "-tenant-by", "service.name", "-tenant-token", "b=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := trot.NewMemStore()
+	a, b, up := tenantTrace("00000000000000000000000000000001", "a"), tenantTrace("00000000000000000000000000000002", "b"), tenantTrace("00000000000000000000000000000003", "..")
+	for _, tr := range []*trot.Trace{a, b, up} {
+		store.Add(tr)
+	}
+	if got, want := v.path(up), "/%2E%2E/00000000000000000000000000000003"; got != want {
+		t.Errorf("path = %s, want %s", got, want)
+	}
+
+	h := v.handler(store)
+	for _, tc := range []struct {
+		path, token string
+		code        int
+	}{
+		{v.path(a), "", http.StatusOK},
+		{v.path(b), "", http.StatusUnauthorized},
+		{v.path(b), "secret", http.StatusOK},
+		{v.path(up), "", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.code {
+			t.Errorf("GET %s: %d, want %d", tc.path, rec.Code, tc.code)
+		}
+	}
+
+	// The ".." group's baselines stay inside -baselines.
+	baselines, err := v.baselinesFor(up)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := baselines.Set(up); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "%2E%2E", "op.json")); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+func siteCmd() *command {
+	cmd := newCommand("site", "[flags] -o dir [file...]", "Write a static site of the input's traces: an index, a page per trace, a stats page, and a search manifest.")
+
+	in := formatFlags(cmd.flags)
+	out := cmd.flags.String("o", "", "directory to write the site to")
+	title := cmd.flags.String("title", "", "index page title")
+	theme := cmd.flags.String("theme", "light", "color theme (light or dark)")
+	precision := cmd.flags.Int("duration-precision", 3, "significant digits in durations")
+
+	cmd.run = func(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+		if *out == "" {
+			return fmt.Errorf("site requires -o <dir>")
+		}
+		t, err := readTrace(ctx, r, args, *in)
+		if err != nil {
+			return err
+		}
+		warn(t)
+		if err := os.MkdirAll(*out, 0o755); err != nil {
+			return err
+		}
+
+		opts := trot.Options{
+			Theme:     *theme,
+			Precision: *precision,
+			Nav:       [][2]string{{"traces", "index.html"}, {"stats", "stats.html"}},
+		}
+		traces := t.Split()
+		for tid, tt := range traces {
+			if err := renderFile(filepath.Join(*out, trot.PageName(tid)+".html"), tt, func(w io.Writer, t *trot.Trace) error {
+				return trot.RenderHTML(w, t, opts)
+			}); err != nil {
+				return fmt.Errorf("rendering %s: %w", tid, err)
+			}
+		}
+
+		if err := writeFile(filepath.Join(*out, "stats.html"), func(w io.Writer) error {
+			return trot.RenderAggregates(w, trot.Aggregates(traces), opts)
+		}); err != nil {
+			return err
+		}
+
+		metas := t.Metas()
+		entries := make([]siteEntry, 0, len(metas))
+		for i, m := range metas {
+			metas[i].Page = trot.PageName(m.TraceID) + ".html"
+			entries = append(entries, siteEntry{Meta: metas[i], Names: spanNames(traces[m.TraceID])})
+		}
+		if err := writeFile(filepath.Join(*out, "search.json"), func(w io.Writer) error {
+			return json.NewEncoder(w).Encode(searchManifest{Traces: entries})
+		}); err != nil {
+			return err
+		}
+
+		// Newest first, like trot index.
+		for i, j := 0, len(metas)-1; i < j; i, j = i+1, j-1 {
+			metas[i], metas[j] = metas[j], metas[i]
+		}
+		index := opts
+		index.Title = *title
+		return writeFile(filepath.Join(*out, "index.html"), func(w io.Writer) error {
+			return trot.RenderArchive(w, metas, index)
+		})
+	}
+
+	return cmd
+}
+
+// searchManifest is a site's search.json. It's a metaFile, so trot index
+// can read it too, with the names of each trace's spans to search by.
+type searchManifest struct {
+	Traces []siteEntry `json:"traces"`
+}
+
+type siteEntry struct {
+	trot.Meta
+	Names []string `json:"span_names"`
+}
+
+// spanNames returns the distinct names of t's spans, sorted.
+func spanNames(t *trot.Trace) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, span := range t.Spans {
+		if !seen[span.Name] {
+			seen[span.Name] = true
+			names = append(names, span.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+func statsCmd() *command {
+	cmd := newCommand("stats", "[flags] [file...]", "Print a text summary of each trace.")
+
+	in := formatFlags(cmd.flags)
+	top := cmd.flags.Int("top", 5, "number of spans with the most self time to list per trace")
+	precision := cmd.flags.Int("duration-precision", 3, "significant digits in durations")
+
+	cmd.run = func(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+		t, err := readTrace(ctx, r, args, *in)
+		if err != nil {
+			return err
+		}
+
+		traces := t.Split()
+		for _, s := range summarize(traces) {
+			writeStats(w, traces[s.TraceID], s, *top, *precision)
+		}
+
+		return nil
+	}
+
+	return cmd
+}
+
+// summarize describes each of traces, oldest first.
+func summarize(traces map[string]*trot.Trace) []trot.Summary {
+	summaries := []trot.Summary{}
+	for _, tt := range traces {
+		summaries = append(summaries, tt.Summarize())
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Start.Before(summaries[j].Start)
+	})
+	return summaries
+}
+
+func writeStats(w io.Writer, t *trot.Trace, s trot.Summary, top, precision int) {
+	services := map[string]struct{}{}
+	for _, span := range t.Spans {
+		services[span.Service()] = struct{}{}
+	}
+	names := make([]string, 0, len(services))
+	for svc := range services {
+		names = append(names, svc)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "trace %s %s\n", s.TraceID, s.Name)
+	fmt.Fprintf(w, "  start     %s\n", s.Start.Format(time.RFC3339Nano))
+	fmt.Fprintf(w, "  duration  %s\n", trot.FormatDuration(s.Duration, precision))
+	fmt.Fprintf(w, "  spans     %d\n", s.Spans)
+	fmt.Fprintf(w, "  errors    %d\n", s.Errors)
+	if s.Database != 0 {
+		fmt.Fprintf(w, "  database  %s\n", trot.FormatDuration(s.Database, precision))
+	}
+	fmt.Fprintf(w, "  services  %s\n", strings.Join(names, ", "))
+
+	if top <= 0 {
+		return
+	}
+
+	type selfTime struct {
+		path string
+		self time.Duration
+	}
+	selves := []selfTime{}
+	for _, root := range t.Roots() {
+		var walk func(n *trot.Node, path []string)
+		walk = func(n *trot.Node, path []string) {
+			path = append(path, n.Span.Name)
+			selves = append(selves, selfTime{strings.Join(path, " > "), n.SelfTime()})
+			for _, child := range n.Children {
+				walk(child, path)
+			}
+		}
+		walk(root, nil)
+	}
+	sort.SliceStable(selves, func(i, j int) bool {
+		return selves[i].self > selves[j].self
+	})
+	if len(selves) > top {
+		selves = selves[:top]
+	}
+
+	fmt.Fprintln(w, "  self time")
+	for _, st := range selves {
+		fmt.Fprintf(w, "    %-12s %s\n", trot.FormatDuration(st.self, precision), st.path)
+	}
+}
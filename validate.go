@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+func validateCmd() *command {
+	cmd := newCommand("validate", "[flags] [file...]", "Check input for missing IDs, bad timestamps, duplicates, dangling parents, and traces without a root.")
+
+	in := formatFlags(cmd.flags)
+	report := cmd.flags.String("report", "json", "report format (json or text)")
+
+	cmd.run = func(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+		if *report != "json" && *report != "text" {
+			return fmt.Errorf("unknown -report format %q (want json or text)", *report)
+		}
+
+		v := trot.NewValidator()
+		if len(args) == 0 {
+			args = []string{"-"}
+		}
+		paths, err := expandArgs(args)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			if path == "-" {
+				zr, err := decompress(r)
+				if err != nil {
+					return fmt.Errorf("stdin: %w", err)
+				}
+				v.Decode(zr, in.format, "stdin")
+				zr.Close()
+				continue
+			}
+			if err := withFile(path, func(r io.Reader) error {
+				v.Decode(r, in.format, path)
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+
+		rep := v.Report()
+		if *report == "json" {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(rep); err != nil {
+				return err
+			}
+		} else {
+			writeReport(w, rep)
+		}
+
+		if rep.Errors != 0 {
+			return fmt.Errorf("%d errors, %d warnings", rep.Errors, rep.Warnings)
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+func writeReport(w io.Writer, rep trot.Report) {
+	for _, p := range rep.Problems {
+		where := p.File
+		if p.TraceID != "" {
+			where = "trace " + p.TraceID
+		}
+		if p.SpanID != "" {
+			where += fmt.Sprintf(" span %s (%s)", p.SpanID, p.Name)
+		}
+		fmt.Fprintf(w, "%s: %s: %s: %s\n", p.Severity, p.Check, where, p.Message)
+	}
+	fmt.Fprintf(w, "%d spans in %d traces: %d errors, %d warnings\n", rep.Spans, rep.Traces, rep.Errors, rep.Warnings)
+}
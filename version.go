@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime/debug"
+)
+
+func versionCmd() *command {
+	cmd := newCommand("version", "", "Print the trot version, commit, and Go version.")
+
+	cmd.run = func(ctx context.Context, w io.Writer, r io.Reader, args []string) error {
+		bi, ok := debug.ReadBuildInfo()
+		if !ok {
+			return fmt.Errorf("no build info")
+		}
+
+		fmt.Fprintf(w, "trot %s\n", bi.Main.Version)
+
+		settings := map[string]string{}
+		for _, s := range bi.Settings {
+			settings[s.Key] = s.Value
+		}
+		if rev := settings["vcs.revision"]; rev != "" {
+			if settings["vcs.modified"] == "true" {
+				rev += " (modified)"
+			}
+			fmt.Fprintf(w, "commit: %s\n", rev)
+		}
+		if date := settings["vcs.time"]; date != "" {
+			fmt.Fprintf(w, "date:   %s\n", date)
+		}
+		fmt.Fprintf(w, "go:     %s\n", bi.GoVersion)
+
+		return nil
+	}
+
+	return cmd
+}
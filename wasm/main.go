@@ -0,0 +1,53 @@
+//go:build js && wasm
+
+// Command wasm exposes trot's parser and renderer to JavaScript for the
+// paste-to-render page in index.html, so traces can be rendered entirely in
+// the browser:
+//
+//	GOOS=js GOARCH=wasm go build -o wasm/trot.wasm ./wasm
+//	cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" wasm/
+package main
+
+import (
+	"bytes"
+	"strings"
+	"syscall/js"
+
+	"github.com/jonjohnsonjr/trot/pkg/trot"
+)
+
+func main() {
+	js.Global().Set("trotRender", js.FuncOf(render))
+	select {}
+}
+
+// render is trotRender(input, {title, theme}). It returns the page as a
+// string, or an object with an error.
+func render(this js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return failure("trotRender: missing input")
+	}
+	opts := trot.Options{}
+	if len(args) > 1 && args[1].Type() == js.TypeObject {
+		if v := args[1].Get("title"); v.Type() == js.TypeString {
+			opts.Title = v.String()
+		}
+		if v := args[1].Get("theme"); v.Type() == js.TypeString {
+			opts.Theme = v.String()
+		}
+	}
+
+	t, err := trot.Parse(strings.NewReader(args[0].String()))
+	if err != nil {
+		return failure(err.Error())
+	}
+	var buf bytes.Buffer
+	if err := trot.RenderHTML(&buf, t, opts); err != nil {
+		return failure(err.Error())
+	}
+	return buf.String()
+}
+
+func failure(msg string) map[string]any {
+	return map[string]any{"error": msg}
+}
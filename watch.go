@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watch calls fn once, then again whenever the files named by args change,
+// polling every interval until ctx is done. Errors from fn are logged rather
+// than returned, since a writer may be midway through appending a span.
+func watch(ctx context.Context, args []string, interval time.Duration, fn func() error) error {
+	last := ""
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fp, err := fingerprint(args)
+		if err != nil {
+			slog.Warn("watch", "err", err)
+		} else if fp != last {
+			slog.Debug("inputs changed", "inputs", args)
+			if err := fn(); err != nil {
+				slog.Warn("watch", "err", err)
+			} else {
+				last = fp
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// fingerprint summarizes the name, size, and mtime of every input.
+func fingerprint(args []string) (string, error) {
+	paths, err := expandArgs(args)
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(paths)
+
+	var sb strings.Builder
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, "%s %d %d\n", path, fi.Size(), fi.ModTime().UnixNano())
+	}
+	return sb.String(), nil
+}